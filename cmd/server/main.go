@@ -4,19 +4,21 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
-	"todolist/internal/handlers"
+	"todolist/internal/grpcserver"
 	"todolist/internal/middleware"
 	"todolist/internal/repository"
+	"todolist/internal/server"
 	"todolist/internal/services"
 
 	"github.com/gocql/gocql"
-	"github.com/gorilla/mux"
 )
 
 func main() {
@@ -31,6 +33,28 @@ func main() {
 
 	var taskRepo repository.TaskRepository
 	var userRepo repository.UserRepository
+	var historyRepo repository.HistoryRepository
+	var idempotencyRepo repository.IdempotencyRepository
+	var templateRepo repository.TemplateRepository
+	var smartListRepo repository.SmartListRepository
+
+	var historyTTL time.Duration // zero keeps history forever
+	if v := os.Getenv("HISTORY_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid HISTORY_TTL %q: %v", v, err)
+		}
+		historyTTL = d
+	}
+
+	idempotencyWindow := services.DefaultIdempotencyWindow
+	if v := os.Getenv("IDEMPOTENCY_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid IDEMPOTENCY_WINDOW %q", v)
+		}
+		idempotencyWindow = d
+	}
 
 	if storageType == "cassandra" {
 		cassandraHostsEnv := os.Getenv("CASSANDRA_HOSTS")
@@ -55,45 +79,146 @@ func main() {
 		log.Println("Successfully connected to Cassandra.")
 		taskRepo = repository.NewCassandraTaskRepository(session)
 		userRepo = repository.NewCassandraUserRepository(session)
+		historyRepo = repository.NewCassandraHistoryRepository(session, historyTTL)
+		idempotencyRepo = repository.NewCassandraIdempotencyRepository(session, idempotencyWindow)
+		templateRepo = repository.NewCassandraTemplateRepository(session)
+		smartListRepo = repository.NewCassandraSmartListRepository(session)
 	} else if storageType == "inmem" {
 		log.Println("Using in-memory storage.")
 		taskRepo = repository.NewInMemTaskRepository()
 		userRepo = repository.NewInMemUserRepository()
+		historyRepo = repository.NewInMemHistoryRepository(historyTTL)
+		idempotencyRepo = repository.NewInMemIdempotencyRepository(idempotencyWindow)
+		templateRepo = repository.NewInMemTemplateRepository()
+		smartListRepo = repository.NewInMemSmartListRepository()
 	} else {
 		log.Fatalf("Invalid STORAGE_TYPE: %s. Supported values are 'cassandra' or 'inmem'.", storageType)
 	}
 
-	taskService := services.NewTaskService(taskRepo)
-	userService := services.NewUserService(userRepo)
+	gracePeriod := services.DefaultGracePeriod
+	if v := os.Getenv("DEACTIVATION_GRACE_PERIOD"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid DEACTIVATION_GRACE_PERIOD %q: %v", v, err)
+		}
+		gracePeriod = d
+	}
+	purgeInterval := time.Hour
+	if v := os.Getenv("PURGE_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid PURGE_INTERVAL %q: %v", v, err)
+		}
+		purgeInterval = d
+	}
+
+	tokenTTL := services.DefaultTokenTTL
+	if v := os.Getenv("TOKEN_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid TOKEN_TTL %q: %v", v, err)
+		}
+		tokenTTL = d
+	}
+
+	trashRetention := 30 * 24 * time.Hour
+	if v := os.Getenv("TRASH_RETENTION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid TRASH_RETENTION %q: %v", v, err)
+		}
+		trashRetention = d
+	}
+	trashSweepInterval := time.Hour
+	if v := os.Getenv("TRASH_SWEEP_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid TRASH_SWEEP_INTERVAL %q: %v", v, err)
+		}
+		trashSweepInterval = d
+	}
+
+	undoWindow := services.DefaultUndoWindow
+	if v := os.Getenv("UNDO_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid UNDO_WINDOW %q: %v", v, err)
+		}
+		undoWindow = d
+	}
+
+	limits := services.DefaultLimits()
+	for name, limit := range map[string]*int{
+		"MAX_NAME_LENGTH":     &limits.MaxNameLength,
+		"MAX_CONTENT_LENGTH":  &limits.MaxContentLength,
+		"MIN_PASSWORD_LENGTH": &limits.MinPasswordLength,
+		"MAX_BATCH_SIZE":      &limits.MaxBatchSize,
+	} {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				log.Fatalf("Invalid %s %q", name, v)
+			}
+			*limit = n
+		}
+	}
+
+	taskService := services.NewTaskService(taskRepo, historyRepo, templateRepo, smartListRepo, userRepo, undoWindow, limits)
+	userService := services.NewUserService(userRepo, idempotencyRepo, gracePeriod, tokenTTL, limits)
 
-	taskHandler := handlers.NewTaskHandler(taskService)
-	welcomeHandler := handlers.NewWelcomeHandler()
-	userHandler := handlers.NewUserHandler(userService, taskService)
 	auth := middleware.NewAuthMiddleware(userService)
 
-	r := mux.NewRouter()
-
-	r.HandleFunc("/welcome", auth.Authenticate(welcomeHandler.Welcome)).Methods("GET", "OPTIONS")
-	r.HandleFunc("/printTasks", auth.Authenticate(taskHandler.GetAllTasksFromPjtHttp)).Methods("GET", "OPTIONS")
-	r.HandleFunc("/printProjects", auth.Authenticate(taskHandler.GetAllProjectsHttp)).Methods("GET", "OPTIONS")
-	r.HandleFunc("/writeTask", auth.Authenticate(taskHandler.WriteTaskHttp)).Methods("POST", "OPTIONS")
-	r.HandleFunc("/completeTask", auth.Authenticate(taskHandler.CompleteTaskHttp)).Methods("GET", "OPTIONS")
-	r.HandleFunc("/removeTask", auth.Authenticate(taskHandler.RemoveTaskHttp)).Methods("DELETE", "OPTIONS")
-	r.HandleFunc("/removeProject", auth.Authenticate(taskHandler.RemoveProjectHttp)).Methods("DELETE", "OPTIONS")
-	r.HandleFunc("/deactivate", auth.Authenticate(userHandler.DeleteUser)).Methods("DELETE", "OPTIONS")
-	r.HandleFunc("/register", userHandler.Register).Methods("POST", "OPTIONS")
-	r.HandleFunc("/createProject", auth.Authenticate(taskHandler.CreateProjectHttp)).Methods("POST", "OPTIONS")
+	webUIDisabled := false
+	if v := os.Getenv("DISABLE_WEB_UI"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid DISABLE_WEB_UI %q: %v", v, err)
+		}
+		webUIDisabled = b
+	}
+	if webUIDisabled {
+		log.Printf("web UI disabled")
+	}
+
+	handler, err := server.New(taskService, userService, idempotencyRepo, !webUIDisabled)
+	if err != nil {
+		log.Fatal(err)
+	}
 	serverPort := os.Getenv("SERVER_PORT")
 	if serverPort == "" {
 		serverPort = "7071" // Default port
 	}
 	serverAddr := ":" + serverPort
-	handler := middleware.CORS(r)
-	server := &http.Server{
+	httpServer := &http.Server{
 		Addr:    serverAddr,
 		Handler: handler,
 	}
+
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "7072" // Default gRPC port
+	}
+	grpcListener, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		log.Fatalf("Failed to listen for gRPC on port %s: %v", grpcPort, err)
+	}
+	grpcServer := grpcserver.NewServer(taskService, userService, auth)
+
+	go func() {
+		users, err := userService.ListUsernames()
+		if err == nil {
+			err = taskService.RebuildSearchIndex(users)
+		}
+		if err != nil {
+			log.Printf("Error building the search index, it is loaded per user on first search: %v", err)
+			return
+		}
+		log.Printf("Search index built for %d users", len(users))
+	}()
+
 	serverCtx, serverStopCtx := context.WithCancel(context.Background())
+	userService.StartPurger(serverCtx, taskService, purgeInterval)
+	taskService.StartTrashSweeper(serverCtx, trashRetention, trashSweepInterval)
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
@@ -111,15 +236,28 @@ func main() {
 		}()
 
 		log.Printf("shutting down server..")
-		err := server.Shutdown(shutdownCtx)
+		grpcStopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(grpcStopped)
+		}()
+		err := httpServer.Shutdown(shutdownCtx)
 		if err != nil {
 			log.Fatal(err)
 		}
+		<-grpcStopped
 		serverStopCtx()
 	}()
 
-	log.Printf("Server starting on %s", server.Addr)
-	err := server.ListenAndServe()
+	go func() {
+		log.Printf("gRPC server starting on %s", grpcListener.Addr())
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	log.Printf("Server starting on %s", httpServer.Addr)
+	err = httpServer.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
 		log.Fatal(err)
 	}
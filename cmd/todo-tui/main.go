@@ -0,0 +1,49 @@
+// Command todo-tui is a keyboard-driven terminal UI for the todo list API.
+// It reads the server and credentials saved by "todo login".
+//
+// Usage:
+//
+//	todo-tui [-config path] [-server URL -user NAME -password PASS] [-refresh 15s]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+	"todolist/internal/cliconfig"
+	"todolist/pkg/client"
+)
+
+func main() {
+	cfgPath := flag.String("config", cliconfig.DefaultPath(), "path of the config file written by 'todo login'")
+	server := flag.String("server", "", "server URL (overrides the config file)")
+	user := flag.String("user", "", "username (overrides the config file)")
+	password := flag.String("password", "", "password (overrides the config file)")
+	refresh := flag.Duration("refresh", 15*time.Second, "how often to reload from the server, 0 to disable")
+	flag.Parse()
+
+	cfg, err := cliconfig.Load(*cfgPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "todo-tui: %v\n", err)
+		os.Exit(1)
+	}
+	if *server != "" {
+		cfg.Server = *server
+	}
+	c := cfg.Client()
+	if *user != "" {
+		cfg.Username = *user
+		c = client.New(cfg.Server, client.WithBasicAuth(*user, *password))
+	}
+	if cfg.Username == "" {
+		fmt.Fprintln(os.Stderr, "todo-tui: no credentials, run 'todo login' or pass -user and -password")
+		os.Exit(2)
+	}
+
+	ui := newUI(c, cfg.Username, cfg.Server)
+	if err := ui.run(*refresh); err != nil {
+		fmt.Fprintf(os.Stderr, "todo-tui: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"todolist/pkg/client"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// noDue mirrors services.DefaultTimestamp, the server's "no due date" sentinel.
+var noDue = time.Date(2099, 12, 31, 23, 59, 59, 0, time.UTC)
+
+const helpText = "[yellow]Tab[-] switch pane  [yellow]a[-] add  [yellow]e[-]/[yellow]Enter[-] edit  [yellow]c[-] complete  " +
+	"[yellow]d[-] delete  [yellow]n[-] new project  [yellow]s[-] sort  [yellow]h[-] hide done  [yellow]u[-] undo  [yellow]r[-] refresh  [yellow]q[-] quit"
+
+type ui struct {
+	api *client.Client
+	app *tview.Application
+
+	pages    *tview.Pages
+	projects *tview.List
+	tasks    *tview.Table
+	status   *tview.TextView
+	// focus is restored when a modal closes.
+	focus tview.Primitive
+
+	// Guarded by mu: written by background loads, read by key handlers.
+	mu        sync.Mutex
+	project   string
+	taskList  []client.Task
+	sortByDue bool
+	hideDone  bool
+	lastUndo  string
+}
+
+func newUI(api *client.Client, user, server string) *ui {
+	u := &ui{
+		api:      api,
+		app:      tview.NewApplication(),
+		pages:    tview.NewPages(),
+		projects: tview.NewList().ShowSecondaryText(false),
+		tasks:    tview.NewTable().SetSelectable(true, false).SetFixed(1, 0),
+		status:   tview.NewTextView().SetDynamicColors(true),
+	}
+	u.projects.SetBorder(true).SetTitle(" Projects ")
+	u.tasks.SetBorder(true).SetTitle(" Tasks ")
+	help := tview.NewTextView().SetDynamicColors(true).SetText(helpText)
+
+	u.projects.SetChangedFunc(func(_ int, name, _ string, _ rune) {
+		u.selectProject(name)
+	})
+	u.projects.SetInputCapture(u.projectKeys)
+	u.tasks.SetInputCapture(u.taskKeys)
+	u.tasks.SetSelectedFunc(func(_, _ int) { u.editTask() })
+
+	body := tview.NewFlex().
+		AddItem(u.projects, 24, 0, true).
+		AddItem(u.tasks, 0, 1, false)
+	root := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tview.NewTextView().SetText(fmt.Sprintf(" %s @ %s", user, server)), 1, 0, false).
+		AddItem(body, 0, 1, true).
+		AddItem(u.status, 1, 0, false).
+		AddItem(help, 1, 0, false)
+	u.pages.AddPage("main", root, true, true)
+	u.app.SetRoot(u.pages, true)
+	u.app.SetInputCapture(u.globalKeys)
+	return u
+}
+
+func (u *ui) run(refresh time.Duration) error {
+	go u.loadProjects()
+	if refresh > 0 {
+		go func() {
+			for range time.Tick(refresh) {
+				u.loadProjects()
+			}
+		}()
+	}
+	return u.app.Run()
+}
+
+// call runs an API request with a timeout. Errors are reported in the status
+// bar instead of ending the program, so a server outage only leaves the last
+// loaded data on screen until the next successful refresh.
+func (u *ui) call(what string, fn func(ctx context.Context) error) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := fn(ctx); err != nil {
+		var apiErr *client.Error
+		msg := fmt.Sprintf("[red]%s failed: offline or unreachable (%v)[-]", what, err)
+		if errors.As(err, &apiErr) {
+			msg = fmt.Sprintf("[red]%s failed: %s[-]", what, apiErr.Message)
+		}
+		u.setStatus(msg)
+		return false
+	}
+	return true
+}
+
+func (u *ui) setStatus(msg string) {
+	u.app.QueueUpdateDraw(func() { u.status.SetText(" " + msg) })
+}
+
+// loadProjects reloads the sidebar, keeping the current selection, and then
+// the tasks of the selected project.
+func (u *ui) loadProjects() {
+	var projects []string
+	if !u.call("loading projects", func(ctx context.Context) error {
+		listed, err := u.api.GetProjects(ctx, false)
+		for _, p := range listed {
+			projects = append(projects, p.Name)
+		}
+		return err
+	}) {
+		return
+	}
+	u.mu.Lock()
+	current := u.project
+	if current == "" && len(projects) > 0 {
+		current = projects[0]
+	}
+	u.project = current
+	u.mu.Unlock()
+
+	u.app.QueueUpdateDraw(func() {
+		u.projects.SetChangedFunc(nil)
+		u.projects.Clear()
+		for i, p := range projects {
+			u.projects.AddItem(p, "", 0, nil)
+			if p == current {
+				u.projects.SetCurrentItem(i)
+			}
+		}
+		u.projects.SetChangedFunc(func(_ int, name, _ string, _ rune) { u.selectProject(name) })
+	})
+	u.loadTasks()
+}
+
+func (u *ui) selectProject(name string) {
+	u.mu.Lock()
+	u.project = name
+	u.mu.Unlock()
+	go u.loadTasks()
+}
+
+func (u *ui) loadTasks() {
+	u.mu.Lock()
+	project := u.project
+	u.mu.Unlock()
+	if project == "" {
+		u.renderTasks(nil)
+		return
+	}
+	var tasks []client.Task
+	if !u.call("loading tasks", func(ctx context.Context) (err error) {
+		tasks, err = u.api.GetTasks(ctx, project)
+		return err
+	}) {
+		return
+	}
+	u.renderTasks(tasks)
+	u.setStatus(fmt.Sprintf("[green]%s: %d tasks, updated %s[-]", project, len(tasks), time.Now().Format("15:04:05")))
+}
+
+func (u *ui) renderTasks(tasks []client.Task) {
+	u.mu.Lock()
+	byDue, hideDone := u.sortByDue, u.hideDone
+	visible := make([]client.Task, 0, len(tasks))
+	for _, t := range tasks {
+		if !(hideDone && t.Completed) {
+			visible = append(visible, t)
+		}
+	}
+	sort.SliceStable(visible, func(i, j int) bool {
+		a, b := visible[i], visible[j]
+		if a.Completed != b.Completed {
+			return !a.Completed
+		}
+		if byDue && !a.Due.Equal(b.Due) {
+			return a.Due.Before(b.Due)
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.Due.Before(b.Due)
+	})
+	u.taskList = visible
+	u.mu.Unlock()
+
+	u.app.QueueUpdateDraw(func() {
+		row, _ := u.tasks.GetSelection()
+		u.tasks.Clear()
+		for col, h := range []string{"", "PRI", "DUE", "CONTENT"} {
+			u.tasks.SetCell(0, col, tview.NewTableCell(h).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+		}
+		now := time.Now()
+		for i, t := range visible {
+			color := tcell.ColorWhite
+			switch {
+			case t.Completed:
+				color = tcell.ColorGray
+			case !t.Due.Equal(noDue) && t.Due.Before(now):
+				color = tcell.ColorRed
+			}
+			check := "[ ]"
+			if t.Completed {
+				check = "[x]"
+			}
+			u.tasks.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(check)).SetTextColor(color))
+			u.tasks.SetCell(i+1, 1, tview.NewTableCell(strconv.Itoa(t.Priority)).SetTextColor(color))
+			u.tasks.SetCell(i+1, 2, tview.NewTableCell(formatDue(t.Due)).SetTextColor(color))
+			u.tasks.SetCell(i+1, 3, tview.NewTableCell(tview.Escape(t.Content)).SetTextColor(color).SetExpansion(1))
+		}
+		if row < 1 {
+			row = 1
+		}
+		if row > len(visible) {
+			row = len(visible)
+		}
+		u.tasks.Select(row, 0)
+	})
+}
+
+// selectedTask returns the task under the cursor, if any.
+func (u *ui) selectedTask() (client.Task, string, bool) {
+	row, _ := u.tasks.GetSelection()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if row < 1 || row > len(u.taskList) {
+		return client.Task{}, u.project, false
+	}
+	return u.taskList[row-1], u.project, true
+}
+
+// mutate runs a change in the background, remembers its undo token and
+// reloads so the screen reflects the server.
+func (u *ui) mutate(what string, fn func(ctx context.Context) (string, error)) {
+	go func() {
+		var undo string
+		if !u.call(what, func(ctx context.Context) (err error) {
+			undo, err = fn(ctx)
+			return err
+		}) {
+			return
+		}
+		u.mu.Lock()
+		if undo != "" {
+			u.lastUndo = undo
+		}
+		u.mu.Unlock()
+		u.loadProjects()
+		u.setStatus(fmt.Sprintf("[green]%s done[-] (u to undo)", what))
+	}()
+}
+
+func (u *ui) globalKeys(ev *tcell.EventKey) *tcell.EventKey {
+	if name, _ := u.pages.GetFrontPage(); name != "main" {
+		return ev
+	}
+	switch {
+	case ev.Key() == tcell.KeyTab:
+		if u.projects.HasFocus() {
+			u.app.SetFocus(u.tasks)
+		} else {
+			u.app.SetFocus(u.projects)
+		}
+		return nil
+	case ev.Rune() == 'q':
+		u.app.Stop()
+		return nil
+	case ev.Rune() == 'r':
+		go u.loadProjects()
+		return nil
+	case ev.Rune() == 'n':
+		u.newProject()
+		return nil
+	case ev.Rune() == 'a':
+		u.taskForm("Add task", client.Task{Due: noDue}, "")
+		return nil
+	case ev.Rune() == 's':
+		u.mu.Lock()
+		u.sortByDue = !u.sortByDue
+		u.mu.Unlock()
+		go u.loadTasks()
+		return nil
+	case ev.Rune() == 'h':
+		u.mu.Lock()
+		u.hideDone = !u.hideDone
+		u.mu.Unlock()
+		go u.loadTasks()
+		return nil
+	case ev.Rune() == 'u':
+		u.mu.Lock()
+		token := u.lastUndo
+		u.lastUndo = ""
+		u.mu.Unlock()
+		if token == "" {
+			u.status.SetText(" nothing to undo")
+			return nil
+		}
+		u.mutate("undo", func(ctx context.Context) (string, error) { return "", u.api.Undo(ctx, token) })
+		return nil
+	}
+	return ev
+}
+
+func (u *ui) projectKeys(ev *tcell.EventKey) *tcell.EventKey {
+	if ev.Rune() == 'd' {
+		u.mu.Lock()
+		project := u.project
+		u.mu.Unlock()
+		if project == "" {
+			return nil
+		}
+		u.confirm(fmt.Sprintf("Move project %q and its tasks to the trash?", project), func() {
+			u.mu.Lock()
+			u.project = ""
+			u.mu.Unlock()
+			u.mutate("removing project "+project, func(ctx context.Context) (string, error) {
+				return u.api.RemoveProject(ctx, project, "")
+			})
+		})
+		return nil
+	}
+	if ev.Key() == tcell.KeyEnter || ev.Key() == tcell.KeyRight {
+		u.app.SetFocus(u.tasks)
+		return nil
+	}
+	return ev
+}
+
+func (u *ui) taskKeys(ev *tcell.EventKey) *tcell.EventKey {
+	switch ev.Rune() {
+	case 'e':
+		u.editTask()
+		return nil
+	case 'c':
+		task, project, ok := u.selectedTask()
+		if ok {
+			u.mutate("completing task", func(ctx context.Context) (string, error) {
+				return u.api.MarkTaskComplete(ctx, project, task.ID)
+			})
+		}
+		return nil
+	case 'd':
+		task, project, ok := u.selectedTask()
+		if ok {
+			u.confirm(fmt.Sprintf("Move %q to the trash?", task.Content), func() {
+				u.mutate("deleting task", func(ctx context.Context) (string, error) {
+					return u.api.RemoveTask(ctx, project, task.ID)
+				})
+			})
+		}
+		return nil
+	}
+	if ev.Key() == tcell.KeyLeft {
+		u.app.SetFocus(u.projects)
+		return nil
+	}
+	return ev
+}
+
+func (u *ui) editTask() {
+	task, _, ok := u.selectedTask()
+	if ok {
+		u.taskForm("Edit task", task, task.ID)
+	}
+}
+
+// taskForm shows a modal form to add (id == "") or edit a task inline.
+func (u *ui) taskForm(title string, task client.Task, id string) {
+	u.mu.Lock()
+	project := u.project
+	u.mu.Unlock()
+	if project == "" {
+		u.status.SetText(" [red]create a project first (n)[-]")
+		return
+	}
+	due := ""
+	if !task.Due.Equal(noDue) && !task.Due.IsZero() {
+		due = task.Due.Local().Format("2006-01-02 15:04")
+	}
+	form := tview.NewForm()
+	form.AddInputField("Content", task.Content, 50, nil, nil).
+		AddInputField("Priority (0-10)", strconv.Itoa(task.Priority), 4, tview.InputFieldInteger, nil).
+		AddInputField("Due (YYYY-MM-DD [HH:MM])", due, 20, nil, nil).
+		AddCheckbox("Completed", task.Completed, nil)
+	form.AddButton("Save", func() {
+		task.Content = form.GetFormItem(0).(*tview.InputField).GetText()
+		task.Priority, _ = strconv.Atoi(form.GetFormItem(1).(*tview.InputField).GetText())
+		parsed, err := parseDue(form.GetFormItem(2).(*tview.InputField).GetText())
+		if err != nil {
+			u.status.SetText(" [red]" + err.Error() + "[-]")
+			return
+		}
+		task.Due = parsed
+		task.Completed = form.GetFormItem(3).(*tview.Checkbox).IsChecked()
+		task.ID = id
+		u.closeModal()
+		u.mutate("saving task", func(ctx context.Context) (string, error) {
+			_, undo, err := u.api.WriteTask(ctx, project, task)
+			return undo, err
+		})
+	})
+	form.AddButton("Cancel", u.closeModal)
+	form.SetCancelFunc(u.closeModal)
+	form.SetBorder(true).SetTitle(fmt.Sprintf(" %s in %s ", title, project))
+	u.showModal(form, 70, 13)
+}
+
+func (u *ui) newProject() {
+	form := tview.NewForm()
+	form.AddInputField("Name", "", 30, nil, nil)
+	form.AddButton("Create", func() {
+		name := strings.TrimSpace(form.GetFormItem(0).(*tview.InputField).GetText())
+		u.closeModal()
+		if name == "" {
+			return
+		}
+		u.mu.Lock()
+		u.project = name
+		u.mu.Unlock()
+		u.mutate("creating project "+name, func(ctx context.Context) (string, error) {
+			_, undo, err := u.api.CreateProject(ctx, name, "")
+			return undo, err
+		})
+	})
+	form.AddButton("Cancel", u.closeModal)
+	form.SetCancelFunc(u.closeModal)
+	form.SetBorder(true).SetTitle(" New project ")
+	u.showModal(form, 50, 7)
+}
+
+func (u *ui) confirm(question string, yes func()) {
+	modal := tview.NewModal().SetText(question).AddButtons([]string{"Yes", "No"}).
+		SetDoneFunc(func(_ int, label string) {
+			u.closeModal()
+			if label == "Yes" {
+				yes()
+			}
+		})
+	u.focus = u.app.GetFocus()
+	u.pages.AddPage("modal", modal, true, true)
+}
+
+func (u *ui) showModal(p tview.Primitive, width, height int) {
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
+	u.focus = u.app.GetFocus()
+	u.pages.AddPage("modal", centered, true, true)
+}
+
+func (u *ui) closeModal() {
+	u.pages.RemovePage("modal")
+	if u.focus != nil {
+		u.app.SetFocus(u.focus)
+	}
+}
+
+// parseDue accepts an empty string (no due date), a date or a date and time
+// in local time.
+func parseDue(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return noDue, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			if layout == time.DateOnly {
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid due date %q, use YYYY-MM-DD or YYYY-MM-DD HH:MM", s)
+}
+
+func formatDue(due time.Time) string {
+	if due.IsZero() || due.Equal(noDue) {
+		return "-"
+	}
+	return due.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,769 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"todolist/pkg/client"
+)
+
+var errUsage = errors.New("usage")
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(a *app, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"login", "[-server URL] -user NAME -password PASS", "log in and save a token", (*app).login},
+		{"logout", "", "revoke and forget the saved token", (*app).logout},
+		{"register", "[-server URL] -user NAME -password PASS", "create an account and save a token for it", (*app).register},
+		{"projects", "[-archived] | [tree [-archived] [NAME]] | [create NAME [-parent PARENT]] | [remove NAME [-lift]] | [move NAME [PARENT]] | [clone NAME NEW] | [edit NAME [-name NEW] [-description TEXT] [-color #RRGGBB] [-archived BOOL]]", "list, nest, create, clone, remove or edit projects", (*app).projects},
+		{"templates", "[save PROJECT [-name NAME] [-anchor DATE]] | [use TEMPLATE NAME [-parent PARENT] [-anchor DATE]] | [remove TEMPLATE]", "list, save, instantiate or remove project templates", (*app).templates},
+		{"list", "PROJECT [-status open|done|all] [-max-priority N] [-due-before DATE] [-sort priority|due]", "list the tasks of a project", (*app).list},
+		{"add", "PROJECT CONTENT... [-priority N] [-due DATE]", "add a task", (*app).add},
+		{"quickadd", "TEXT... [-project PROJECT] [-preview]", "add a task from text such as 'Pay rent tomorrow 9am p1 #home'", (*app).quickAdd},
+		{"edit", "PROJECT ID [-content TEXT] [-priority N] [-due DATE] [-completed BOOL]", "change fields of a task", (*app).edit},
+		{"complete", "PROJECT ID", "mark a task as completed", (*app).complete},
+		{"remove", "PROJECT ID", "move a task to the trash", (*app).remove},
+		{"search", "WORDS... [-project PROJECT] [-limit N]", "search the content of tasks in all projects", (*app).search},
+		{"today", "[-tz ZONE] [-archived]", "list open tasks overdue or due today across all projects", (*app).today},
+		{"upcoming", "[-days N] [-tz ZONE] [-archived]", "list open tasks due in the coming days across all projects", (*app).upcoming},
+		{"filter", "QUERY...", "list the tasks of all projects matching a filter query", (*app).filter},
+		{"smartlists", "[save NAME QUERY...] | [run NAME] | [remove NAME]", "list, save, run or remove smart lists (saved filter queries)", (*app).smartLists},
+		{"trash", "[restore|purge] [PROJECT [ID]]", "list, restore or purge trashed items", (*app).trash},
+		{"history", "PROJECT [ID]", "show the change history of a project or task", (*app).history},
+		{"undo", "TOKEN", "revert a mutation", (*app).undo},
+		{"settings", "[-tz ZONE] [-week-start DAY] [-locale TAG] [-date-format FORMAT]", "show or change the account's settings", (*app).settings},
+		{"deactivate", "", "deactivate the account", (*app).deactivate},
+		{"reactivate", "[-server URL] -user NAME -password PASS", "reactivate a deactivated account and save a token", (*app).reactivate},
+		{"completion", "bash|zsh", "print a shell completion script", (*app).completion},
+	}
+}
+
+func commandByName(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// parseArgs parses flags that may appear before, between or after the
+// positional arguments, and returns the positional ones.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %v", errUsage, err)
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// parseDue accepts RFC 3339 timestamps and plain dates (end of that day, local time).
+func parseDue(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD or RFC 3339", s)
+	}
+	return d.Add(24*time.Hour - time.Second), nil
+}
+
+// credentialFlags parses -server, -user and -password. The password is
+// returned rather than kept in the config, which only stores tokens.
+func (a *app) credentialFlags(name string, args []string) (username, password string, err error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&a.cfg.Server, "server", a.cfg.Server, "server URL")
+	fs.StringVar(&username, "user", a.cfg.Username, "username")
+	fs.StringVar(&password, "password", "", "password")
+	if _, err := parseArgs(fs, args); err != nil {
+		return "", "", err
+	}
+	if username == "" || password == "" {
+		return "", "", errUsage
+	}
+	return username, password, nil
+}
+
+// saveLogin logs in, saves the token and reports it with msg.
+func (a *app) saveLogin(username, password, msg string) error {
+	if err := a.cfg.Login(a.ctx, username, password); err != nil {
+		if errors.Is(err, client.ErrUnauthorized) {
+			return fmt.Errorf("invalid credentials for %s", username)
+		}
+		return err
+	}
+	if err := a.cfg.Save(a.cfgPath); err != nil {
+		return err
+	}
+	return a.out.message(fmt.Sprintf("%s %s at %s", msg, a.cfg.Username, a.cfg.Server), "")
+}
+
+func (a *app) login(args []string) error {
+	username, password, err := a.credentialFlags("login", args)
+	if err != nil {
+		return err
+	}
+	return a.saveLogin(username, password, "logged in as")
+}
+
+// logout forgets the saved token even when the server cannot be reached to
+// revoke it; the error is reported after the config is saved.
+func (a *app) logout(args []string) error {
+	var revokeErr error
+	if a.cfg.Token != "" {
+		revokeErr = a.client.Logout(a.ctx)
+	}
+	a.cfg.Username, a.cfg.Token = "", ""
+	if err := a.cfg.Save(a.cfgPath); err != nil {
+		return err
+	}
+	if revokeErr != nil && !errors.Is(revokeErr, client.ErrUnauthorized) {
+		return fmt.Errorf("logged out, but the server did not revoke the token: %w", revokeErr)
+	}
+	return a.out.message("logged out", "")
+}
+
+func (a *app) register(args []string) error {
+	username, password, err := a.credentialFlags("register", args)
+	if err != nil {
+		return err
+	}
+	if err := client.New(a.cfg.Server).RegisterUser(a.ctx, username, password); err != nil {
+		return err
+	}
+	return a.saveLogin(username, password, "registered")
+}
+
+func (a *app) projects(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "edit":
+			return a.editProject(args[1:])
+		case "tree":
+			return a.projectTree(args[1:])
+		case "create":
+			return a.createProject(args[1:])
+		case "remove":
+			return a.removeProject(args[1:])
+		case "move":
+			return a.moveProject(args[1:])
+		case "clone":
+			return a.cloneProject(args[1:])
+		}
+	}
+	fs := flag.NewFlagSet("projects", flag.ContinueOnError)
+	archived := fs.Bool("archived", false, "include archived projects")
+	if rest, err := parseArgs(fs, args); err != nil || len(rest) != 0 {
+		return errUsage
+	}
+	projects, err := a.client.GetProjects(a.ctx, *archived)
+	if err != nil {
+		return err
+	}
+	return a.out.projects(projects)
+}
+
+func (a *app) createProject(args []string) error {
+	fs := flag.NewFlagSet("projects create", flag.ContinueOnError)
+	parent := fs.String("parent", "", "project to nest the new one in")
+	rest, err := parseArgs(fs, args)
+	if err != nil || len(rest) != 1 {
+		return errUsage
+	}
+	project, undo, err := a.client.CreateProject(a.ctx, rest[0], *parent)
+	if err != nil {
+		return err
+	}
+	if undo == "" {
+		return a.out.message(fmt.Sprintf("project %s already exists", project.Name), "")
+	}
+	return a.out.message(fmt.Sprintf("project %s created", project.Name), undo)
+}
+
+func (a *app) removeProject(args []string) error {
+	fs := flag.NewFlagSet("projects remove", flag.ContinueOnError)
+	lift := fs.Bool("lift", false, "keep sub-projects, moving them up a level")
+	rest, err := parseArgs(fs, args)
+	if err != nil || len(rest) != 1 {
+		return errUsage
+	}
+	children := "trash"
+	if *lift {
+		children = "lift"
+	}
+	undo, err := a.client.RemoveProject(a.ctx, rest[0], children)
+	if err != nil {
+		return err
+	}
+	return a.out.message(fmt.Sprintf("project %s moved to trash", rest[0]), undo)
+}
+
+// moveProject nests a project in another one, or moves it to the top level
+// when no parent is given.
+func (a *app) moveProject(args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return errUsage
+	}
+	parent := ""
+	if len(args) == 2 {
+		parent = args[1]
+	}
+	_, undo, err := a.client.MoveProject(a.ctx, args[0], parent)
+	if err != nil {
+		return err
+	}
+	if parent == "" {
+		return a.out.message(fmt.Sprintf("project %s moved to the top level", args[0]), undo)
+	}
+	return a.out.message(fmt.Sprintf("project %s moved under %s", args[0], parent), undo)
+}
+
+func (a *app) cloneProject(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	if _, err := a.client.CloneProject(a.ctx, args[0], args[1]); err != nil {
+		return err
+	}
+	return a.out.message(fmt.Sprintf("project %s cloned to %s", args[0], args[1]), "")
+}
+
+func (a *app) templates(args []string) error {
+	if len(args) == 0 {
+		templates, err := a.client.GetTemplates(a.ctx)
+		if err != nil {
+			return err
+		}
+		return a.out.templates(templates)
+	}
+	switch args[0] {
+	case "save":
+		fs := flag.NewFlagSet("templates save", flag.ContinueOnError)
+		name := fs.String("name", "", "template name, the project's by default")
+		anchor := fs.String("anchor", "", "date due dates are saved relative to, today by default")
+		rest, err := parseArgs(fs, args[1:])
+		if err != nil || len(rest) != 1 {
+			return errUsage
+		}
+		at, err := parseAnchor(*anchor)
+		if err != nil {
+			return err
+		}
+		template, err := a.client.SaveTemplate(a.ctx, rest[0], *name, at)
+		if err != nil {
+			return err
+		}
+		return a.out.message(fmt.Sprintf("template %s saved with %d tasks", template.Name, len(template.Tasks)), "")
+	case "use":
+		fs := flag.NewFlagSet("templates use", flag.ContinueOnError)
+		parent := fs.String("parent", "", "project to nest the new one in")
+		anchor := fs.String("anchor", "", "date due dates are relative to, today by default")
+		rest, err := parseArgs(fs, args[1:])
+		if err != nil || len(rest) != 2 {
+			return errUsage
+		}
+		at, err := parseAnchor(*anchor)
+		if err != nil {
+			return err
+		}
+		if _, err := a.client.InstantiateTemplate(a.ctx, rest[0], rest[1], *parent, at); err != nil {
+			return err
+		}
+		return a.out.message(fmt.Sprintf("project %s created from template %s", rest[1], rest[0]), "")
+	case "remove":
+		if len(args) != 2 {
+			return errUsage
+		}
+		if err := a.client.RemoveTemplate(a.ctx, args[1]); err != nil {
+			return err
+		}
+		return a.out.message(fmt.Sprintf("template %s removed", args[1]), "")
+	}
+	return errUsage
+}
+
+// parseAnchor parses a template anchor like a due date, so that a task due
+// on the anchor date keeps its time of day; empty means now.
+func parseAnchor(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return parseDue(s)
+}
+
+func (a *app) search(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	project := fs.String("project", "", "only search this project")
+	limit := fs.Int("limit", 0, "maximum number of results")
+	words, err := parseArgs(fs, args)
+	if err != nil || len(words) == 0 {
+		return errUsage
+	}
+	hits, err := a.client.Search(a.ctx, strings.Join(words, " "), *project, *limit)
+	if err != nil {
+		return err
+	}
+	return a.out.searchHits(hits)
+}
+
+func (a *app) today(args []string) error {
+	fs := flag.NewFlagSet("today", flag.ContinueOnError)
+	tz := fs.String("tz", "", "IANA time zone to count days in")
+	archived := fs.Bool("archived", false, "include archived projects")
+	if rest, err := parseArgs(fs, args); err != nil || len(rest) != 0 {
+		return errUsage
+	}
+	agenda, err := a.client.Today(a.ctx, *tz, *archived)
+	if err != nil {
+		return err
+	}
+	return a.out.agenda(agenda)
+}
+
+func (a *app) upcoming(args []string) error {
+	fs := flag.NewFlagSet("upcoming", flag.ContinueOnError)
+	days := fs.Int("days", 0, "number of days, today included")
+	tz := fs.String("tz", "", "IANA time zone to count days in")
+	archived := fs.Bool("archived", false, "include archived projects")
+	if rest, err := parseArgs(fs, args); err != nil || len(rest) != 0 {
+		return errUsage
+	}
+	agenda, err := a.client.Upcoming(a.ctx, *days, *tz, *archived)
+	if err != nil {
+		return err
+	}
+	return a.out.agenda(agenda)
+}
+
+func (a *app) filter(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	tasks, err := a.client.FilterTasks(a.ctx, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	return a.out.projectTasks(tasks)
+}
+
+func (a *app) smartLists(args []string) error {
+	if len(args) == 0 {
+		lists, err := a.client.GetSmartLists(a.ctx)
+		if err != nil {
+			return err
+		}
+		return a.out.smartLists(lists)
+	}
+	switch args[0] {
+	case "save":
+		if len(args) < 3 {
+			return errUsage
+		}
+		list, err := a.client.SaveSmartList(a.ctx, args[1], strings.Join(args[2:], " "))
+		if err != nil {
+			return err
+		}
+		return a.out.message(fmt.Sprintf("smart list %s saved", list.Name), "")
+	case "run":
+		if len(args) != 2 {
+			return errUsage
+		}
+		tasks, err := a.client.RunSmartList(a.ctx, args[1])
+		if err != nil {
+			return err
+		}
+		return a.out.projectTasks(tasks)
+	case "remove":
+		if len(args) != 2 {
+			return errUsage
+		}
+		if err := a.client.RemoveSmartList(a.ctx, args[1]); err != nil {
+			return err
+		}
+		return a.out.message(fmt.Sprintf("smart list %s removed", args[1]), "")
+	}
+	return errUsage
+}
+
+func (a *app) projectTree(args []string) error {
+	fs := flag.NewFlagSet("projects tree", flag.ContinueOnError)
+	archived := fs.Bool("archived", false, "include archived projects")
+	rest, err := parseArgs(fs, args)
+	if err != nil || len(rest) > 1 {
+		return errUsage
+	}
+	root := ""
+	if len(rest) == 1 {
+		root = rest[0]
+	}
+	tree, err := a.client.GetProjectTree(a.ctx, root, *archived)
+	if err != nil {
+		return err
+	}
+	return a.out.projectTree(tree)
+}
+
+func (a *app) editProject(args []string) error {
+	fs := flag.NewFlagSet("projects edit", flag.ContinueOnError)
+	name := fs.String("name", "", "new name")
+	description := fs.String("description", "", "new description")
+	color := fs.String("color", "", "new color, empty for none")
+	archived := fs.Bool("archived", false, "archived state")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return errUsage
+	}
+	var update client.ProjectUpdate
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			update.Name = name
+		case "description":
+			update.Description = description
+		case "color":
+			update.Color = color
+		case "archived":
+			update.Archived = archived
+		}
+	})
+	if update.IsEmpty() {
+		return errUsage
+	}
+	project, undo, err := a.client.UpdateProject(a.ctx, rest[0], update)
+	if err != nil {
+		return err
+	}
+	if undo == "" {
+		return a.out.message(fmt.Sprintf("project %s unchanged", project.Name), "")
+	}
+	return a.out.message(fmt.Sprintf("project %s updated", project.Name), undo)
+}
+
+func (a *app) list(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	status := fs.String("status", "open", "open, done or all")
+	maxPriority := fs.Int("max-priority", -1, "only tasks with priority <= N")
+	dueBefore := fs.String("due-before", "", "only tasks due before DATE")
+	sortBy := fs.String("sort", "priority", "priority or due")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return errUsage
+	}
+	var before time.Time
+	if *dueBefore != "" {
+		if before, err = parseDue(*dueBefore); err != nil {
+			return err
+		}
+	}
+
+	tasks, err := a.client.GetTasks(a.ctx, rest[0])
+	if err != nil {
+		return err
+	}
+	filtered := tasks[:0]
+	for _, t := range tasks {
+		switch {
+		case *status == "open" && t.Completed, *status == "done" && !t.Completed:
+			continue
+		case *maxPriority >= 0 && t.Priority > *maxPriority:
+			continue
+		case !before.IsZero() && !t.Due.Before(before):
+			continue
+		}
+		filtered = append(filtered, t)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if *sortBy == "due" && !filtered[i].Due.Equal(filtered[j].Due) {
+			return filtered[i].Due.Before(filtered[j].Due)
+		}
+		if filtered[i].Priority != filtered[j].Priority {
+			return filtered[i].Priority < filtered[j].Priority
+		}
+		return filtered[i].Due.Before(filtered[j].Due)
+	})
+	return a.out.tasks(filtered)
+}
+
+func (a *app) writeTask(project string, task client.Task) error {
+	written, undo, err := a.client.WriteTask(a.ctx, project, task)
+	if err != nil {
+		return err
+	}
+	return a.out.message(fmt.Sprintf("task %s written", written.ID), undo)
+}
+
+func (a *app) add(args []string) error {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	priority := fs.Int("priority", 0, "priority, 0 (highest) to 10")
+	due := fs.String("due", "", "due date")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 2 {
+		return errUsage
+	}
+	task := client.Task{Content: strings.Join(rest[1:], " "), Priority: *priority}
+	if *due != "" {
+		if task.Due, err = parseDue(*due); err != nil {
+			return err
+		}
+	}
+	return a.writeTask(rest[0], task)
+}
+
+func (a *app) quickAdd(args []string) error {
+	fs := flag.NewFlagSet("quickadd", flag.ContinueOnError)
+	project := fs.String("project", "", "project used when the text names no #project")
+	preview := fs.Bool("preview", false, "show what is recognized without adding the task")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		return errUsage
+	}
+	text := strings.Join(rest, " ")
+	if *preview {
+		parsed, err := a.client.ParseQuickAdd(a.ctx, text)
+		if err != nil {
+			return err
+		}
+		return a.out.quickAdd(parsed)
+	}
+	result, undo, err := a.client.QuickAdd(a.ctx, text, *project)
+	if err != nil {
+		return err
+	}
+	return a.out.message(fmt.Sprintf("task %s added to %s", result.Task.ID, result.ProjectName), undo)
+}
+
+func (a *app) edit(args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+	content := fs.String("content", "", "new content")
+	priority := fs.Int("priority", 0, "new priority")
+	due := fs.String("due", "", "new due date")
+	completed := fs.Bool("completed", false, "completion state")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 2 {
+		return errUsage
+	}
+	project, id := rest[0], rest[1]
+
+	// WriteTask replaces the whole task, so start from the current one.
+	tasks, err := a.client.GetTasks(a.ctx, project)
+	if err != nil {
+		return err
+	}
+	var task *client.Task
+	for i := range tasks {
+		if tasks[i].ID == id {
+			task = &tasks[i]
+		}
+	}
+	if task == nil {
+		return fmt.Errorf("task %s not found in project %s", id, project)
+	}
+	var dueErr error
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "content":
+			task.Content = *content
+		case "priority":
+			task.Priority = *priority
+		case "due":
+			task.Due, dueErr = parseDue(*due)
+		case "completed":
+			task.Completed = *completed
+		}
+	})
+	if dueErr != nil {
+		return dueErr
+	}
+	return a.writeTask(project, *task)
+}
+
+func (a *app) complete(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	undo, err := a.client.MarkTaskComplete(a.ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return a.out.message(fmt.Sprintf("task %s completed", args[1]), undo)
+}
+
+func (a *app) remove(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	undo, err := a.client.RemoveTask(a.ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return a.out.message(fmt.Sprintf("task %s moved to trash", args[1]), undo)
+}
+
+func (a *app) trash(args []string) error {
+	if len(args) == 0 {
+		items, err := a.client.GetTrash(a.ctx)
+		if err != nil {
+			return err
+		}
+		return a.out.trash(items)
+	}
+	if len(args) > 3 {
+		return errUsage
+	}
+	switch {
+	case args[0] == "restore" && len(args) == 2:
+		undo, err := a.client.RestoreProject(a.ctx, args[1])
+		if err != nil {
+			return err
+		}
+		return a.out.message(fmt.Sprintf("project %s restored", args[1]), undo)
+	case args[0] == "restore" && len(args) == 3:
+		undo, err := a.client.RestoreTask(a.ctx, args[1], args[2])
+		if err != nil {
+			return err
+		}
+		return a.out.message(fmt.Sprintf("task %s restored", args[2]), undo)
+	case args[0] == "purge" && len(args) == 1:
+		purged, err := a.client.EmptyTrash(a.ctx)
+		if err != nil {
+			return err
+		}
+		return a.out.message(fmt.Sprintf("trash emptied, %d items purged", purged), "")
+	case args[0] == "purge" && len(args) == 2:
+		if err := a.client.PurgeProject(a.ctx, args[1]); err != nil {
+			return err
+		}
+		return a.out.message(fmt.Sprintf("project %s purged", args[1]), "")
+	case args[0] == "purge" && len(args) == 3:
+		if err := a.client.PurgeTask(a.ctx, args[1], args[2]); err != nil {
+			return err
+		}
+		return a.out.message(fmt.Sprintf("task %s purged", args[2]), "")
+	}
+	return errUsage
+}
+
+func (a *app) history(args []string) error {
+	var changes []client.TaskChange
+	var err error
+	switch len(args) {
+	case 1:
+		changes, err = a.client.GetProjectHistory(a.ctx, args[0])
+	case 2:
+		changes, err = a.client.GetTaskHistory(a.ctx, args[0], args[1])
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+	return a.out.history(changes)
+}
+
+func (a *app) undo(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	if err := a.client.Undo(a.ctx, args[0]); err != nil {
+		return err
+	}
+	return a.out.message(fmt.Sprintf("%s undone", args[0]), "")
+}
+
+func (a *app) settings(args []string) error {
+	fs := flag.NewFlagSet("settings", flag.ContinueOnError)
+	tz := fs.String("tz", "", "IANA time zone, empty for UTC")
+	weekStart := fs.String("week-start", "", "monday, sunday or saturday")
+	locale := fs.String("locale", "", "BCP 47 language tag, such as fr-FR")
+	dateFormat := fs.String("date-format", "", "YYYY-MM-DD, DD/MM/YYYY, MM/DD/YYYY or DD.MM.YYYY")
+	if rest, err := parseArgs(fs, args); err != nil || len(rest) != 0 {
+		return errUsage
+	}
+	var update client.SettingsUpdate
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "tz":
+			update.TimeZone = tz
+		case "week-start":
+			update.WeekStart = weekStart
+		case "locale":
+			update.Locale = locale
+		case "date-format":
+			update.DateFormat = dateFormat
+		}
+	})
+	var settings client.UserSettings
+	var err error
+	if fs.NFlag() == 0 {
+		settings, err = a.client.GetSettings(a.ctx)
+	} else {
+		settings, err = a.client.UpdateSettings(a.ctx, update)
+	}
+	if err != nil {
+		return err
+	}
+	return a.out.settings(settings)
+}
+
+// deactivate also drops the saved token, which the server has revoked. The
+// username is kept for 'todo reactivate'.
+func (a *app) deactivate(args []string) error {
+	purgeAfter, err := a.client.DeactivateUser(a.ctx)
+	if err != nil {
+		return err
+	}
+	a.cfg.Token = ""
+	if err := a.cfg.Save(a.cfgPath); err != nil {
+		return err
+	}
+	return a.out.message(fmt.Sprintf("account %s deactivated, run 'todo reactivate' before %s to restore it",
+		a.cfg.Username, purgeAfter.Local().Format(time.DateTime)), "")
+}
+
+// reactivate takes the password, since deactivation revoked the saved token,
+// and saves a new token once the account is back.
+func (a *app) reactivate(args []string) error {
+	username, password, err := a.credentialFlags("reactivate", args)
+	if err != nil {
+		return err
+	}
+	if err := client.New(a.cfg.Server, client.WithBasicAuth(username, password)).ReactivateUser(a.ctx); err != nil {
+		return err
+	}
+	return a.saveLogin(username, password, "reactivated")
+}
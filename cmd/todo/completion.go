@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Completion scripts complete command names and, for commands taking a
+// project, the project names returned by "todo -o plain projects".
+
+const bashCompletion = `# bash completion for todo; load with: source <(todo completion bash)
+_todo() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    if [ "$COMP_CWORD" -eq 1 ]; then
+        COMPREPLY=($(compgen -W "%s" -- "$cur"))
+        return
+    fi
+    case "${COMP_WORDS[1]}" in
+        list|add|edit|complete|remove|history)
+            if [ "$COMP_CWORD" -eq 2 ]; then
+                COMPREPLY=($(compgen -W "$(todo -o plain projects 2>/dev/null)" -- "$cur"))
+            fi ;;
+        projects) [ "$COMP_CWORD" -eq 2 ] && COMPREPLY=($(compgen -W "tree create remove move clone edit" -- "$cur")) ;;
+        templates) [ "$COMP_CWORD" -eq 2 ] && COMPREPLY=($(compgen -W "save use remove" -- "$cur")) ;;
+        smartlists) [ "$COMP_CWORD" -eq 2 ] && COMPREPLY=($(compgen -W "save run remove" -- "$cur")) ;;
+        trash) [ "$COMP_CWORD" -eq 2 ] && COMPREPLY=($(compgen -W "restore purge" -- "$cur")) ;;
+        completion) COMPREPLY=($(compgen -W "bash zsh" -- "$cur")) ;;
+    esac
+}
+complete -F _todo todo
+`
+
+const zshCompletion = `#compdef todo
+# zsh completion for todo; load with: source <(todo completion zsh)
+_todo() {
+    local -a cmds
+    cmds=(%s)
+    if (( CURRENT == 2 )); then
+        _describe 'command' cmds
+        return
+    fi
+    case "$words[2]" in
+        list|add|edit|complete|remove|history)
+            (( CURRENT == 3 )) && compadd -- ${(f)"$(todo -o plain projects 2>/dev/null)"} ;;
+        projects) (( CURRENT == 3 )) && compadd tree create remove move clone edit ;;
+        templates) (( CURRENT == 3 )) && compadd save use remove ;;
+        smartlists) (( CURRENT == 3 )) && compadd save run remove ;;
+        trash) (( CURRENT == 3 )) && compadd restore purge ;;
+        completion) compadd bash zsh ;;
+    esac
+}
+compdef _todo todo
+`
+
+func (a *app) completion(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	switch args[0] {
+	case "bash":
+		names := make([]string, 0, len(commands))
+		for _, cmd := range commands {
+			names = append(names, cmd.name)
+		}
+		fmt.Fprintf(a.out.w, bashCompletion, strings.Join(names, " "))
+	case "zsh":
+		described := make([]string, 0, len(commands))
+		for _, cmd := range commands {
+			described = append(described, fmt.Sprintf("'%s:%s'", cmd.name, cmd.summary))
+		}
+		fmt.Fprintf(a.out.w, zshCompletion, strings.Join(described, " "))
+	default:
+		return errUsage
+	}
+	return nil
+}
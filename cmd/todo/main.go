@@ -0,0 +1,93 @@
+// Command todo is a command-line client for the todo list API.
+//
+// Usage:
+//
+//	todo [-o table|json|plain] [-config path] <command> [arguments]
+//
+// Run "todo help" for the list of commands.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"todolist/internal/cliconfig"
+	"todolist/pkg/client"
+)
+
+type app struct {
+	ctx     context.Context
+	cfg     *cliconfig.Config
+	cfgPath string
+	client  *client.Client
+	out     *printer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("todo", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("o", outputTable, "output format: table, json or plain")
+	cfgPath := fs.String("config", cliconfig.DefaultPath(), "path of the config file")
+	fs.Usage = func() { usage(stderr) }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *output != outputTable && *output != outputJSON && *output != outputPlain {
+		fmt.Fprintf(stderr, "todo: unknown output format %q\n", *output)
+		return 2
+	}
+	if fs.NArg() == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	cfg, err := cliconfig.Load(*cfgPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "todo: %v\n", err)
+		return 1
+	}
+	a := &app{
+		ctx:     context.Background(),
+		cfg:     cfg,
+		cfgPath: *cfgPath,
+		client:  cfg.Client(),
+		out:     &printer{mode: *output, w: stdout},
+	}
+
+	name, rest := fs.Arg(0), fs.Args()[1:]
+	if name == "help" {
+		usage(stdout)
+		return 0
+	}
+	cmd, ok := commandByName(name)
+	if !ok {
+		fmt.Fprintf(stderr, "todo: unknown command %q\n", name)
+		usage(stderr)
+		return 2
+	}
+	if err := cmd.run(a, rest); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(stderr, "usage: todo %s %s\n", cmd.name, cmd.args)
+			return 2
+		}
+		fmt.Fprintf(stderr, "todo %s: %v\n", cmd.name, err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: todo [-o table|json|plain] [-config path] <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"todolist/internal/cliconfig"
+	"todolist/internal/server/servertest"
+	"todolist/pkg/client"
+)
+
+// cli runs the command line against a config file of its own.
+type cli struct {
+	t      *testing.T
+	config string
+}
+
+func newCLI(t *testing.T) *cli {
+	return &cli{t: t, config: filepath.Join(t.TempDir(), "todo", "config.json")}
+}
+
+// run runs the command line and returns its exit code and output.
+func (c *cli) run(args ...string) (int, string, string) {
+	c.t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"-config", c.config}, args...), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// ok runs the command line, fails the test unless it succeeds and returns its
+// output.
+func (c *cli) ok(args ...string) string {
+	c.t.Helper()
+	code, stdout, stderr := c.run(args...)
+	if code != 0 {
+		c.t.Fatalf("todo %s: exit code %d, stderr %q", strings.Join(args, " "), code, stderr)
+	}
+	return stdout
+}
+
+// tasks lists the tasks of project as JSON and decodes them.
+func (c *cli) tasks(project string, args ...string) []client.Task {
+	c.t.Helper()
+	var tasks []client.Task
+	out := c.ok(append([]string{"-o", "json", "list", project}, args...)...)
+	if err := json.Unmarshal([]byte(out), &tasks); err != nil {
+		c.t.Fatalf("decoding the task list %q: %v", out, err)
+	}
+	return tasks
+}
+
+// added returns the ID of the task reported by an add or edit in JSON output.
+func added(t *testing.T, out string) string {
+	t.Helper()
+	var msg struct {
+		Message   string `json:"message"`
+		UndoToken string `json:"undoToken"`
+	}
+	if err := json.Unmarshal([]byte(out), &msg); err != nil {
+		t.Fatalf("decoding the message %q: %v", out, err)
+	}
+	if msg.UndoToken == "" {
+		t.Errorf("message %q has no undo token", out)
+	}
+	id, ok := strings.CutPrefix(msg.Message, "task ")
+	if !ok || !strings.HasSuffix(id, " written") {
+		t.Fatalf("message = %q, want \"task ID written\"", msg.Message)
+	}
+	return strings.TrimSuffix(id, " written")
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	srv := servertest.New(t, nil)
+	c := newCLI(t)
+
+	if out := c.ok("register", "-server", srv.URL, "-user", "alice", "-password", "secret1"); out != "registered alice at "+srv.URL+"\n" {
+		t.Errorf("register printed %q", out)
+	}
+	out := c.ok("projects", "create", "work")
+	if !strings.HasPrefix(out, "project work created\nundo with: todo undo ") {
+		t.Errorf("projects create printed %q", out)
+	}
+	if out := c.ok("projects", "create", "work"); out != "project work already exists\n" {
+		t.Errorf("creating work again printed %q", out)
+	}
+
+	report := added(t, c.ok("-o", "json", "add", "work", "Write", "report", "-priority", "2", "-due", "2030-01-15"))
+	call := added(t, c.ok("-o", "json", "add", "-priority", "1", "work", "Call", "Bob"))
+
+	tasks := c.tasks("work")
+	if len(tasks) != 2 || tasks[0].ID != call || tasks[1].ID != report {
+		t.Fatalf("tasks = %+v, want Call Bob then Write report", tasks)
+	}
+	if tasks[1].Content != "Write report" || tasks[1].Priority != 2 || tasks[1].Due.Format("2006-01-02") != "2030-01-15" {
+		t.Errorf("Write report = %+v", tasks[1])
+	}
+
+	if id := added(t, c.ok("-o", "json", "edit", "work", report, "-content", "Write the report", "-priority", "0")); id != report {
+		t.Errorf("edit reported task %s, want %s", id, report)
+	}
+	tasks = c.tasks("work")
+	if len(tasks) != 2 || tasks[0].ID != report || tasks[0].Content != "Write the report" || tasks[0].Priority != 0 {
+		t.Fatalf("tasks after edit = %+v", tasks)
+	}
+	if tasks[0].Due.Format("2006-01-02") != "2030-01-15" {
+		t.Errorf("edit changed the due date to %v", tasks[0].Due)
+	}
+
+	if out := c.ok("-o", "plain", "complete", "work", call); out != "task "+call+" completed\n" {
+		t.Errorf("complete printed %q", out)
+	}
+	if tasks := c.tasks("work"); len(tasks) != 1 || tasks[0].ID != report {
+		t.Errorf("open tasks = %+v, want only %s", tasks, report)
+	}
+	if tasks := c.tasks("work", "-status", "done"); len(tasks) != 1 || tasks[0].ID != call || !tasks[0].Completed {
+		t.Errorf("done tasks = %+v, want only %s", tasks, call)
+	}
+
+	if out := c.ok("-o", "plain", "remove", "work", report); out != "task "+report+" moved to trash\n" {
+		t.Errorf("remove printed %q", out)
+	}
+	if tasks := c.tasks("work", "-status", "all"); len(tasks) != 1 || tasks[0].ID != call {
+		t.Errorf("tasks after remove = %+v, want only %s", tasks, call)
+	}
+
+	if code, _, stderr := c.run("complete", "work", "no-such-task"); code != 1 || !strings.HasPrefix(stderr, "todo complete: ") {
+		t.Errorf("completing a missing task: exit code %d, stderr %q", code, stderr)
+	}
+}
+
+func TestOutputFormats(t *testing.T) {
+	srv := servertest.New(t, nil)
+	c := newCLI(t)
+	c.ok("register", "-server", srv.URL, "-user", "bob", "-password", "secret1")
+	c.ok("projects", "create", "home")
+	id := added(t, c.ok("-o", "json", "add", "home", "Pay", "rent", "-priority", "1", "-due", "2030-03-01"))
+
+	table := c.ok("list", "home")
+	lines := strings.Split(strings.TrimSuffix(table, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("table = %q, want a header and one row", table)
+	}
+	if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "ID DONE PRI DUE CONTENT" {
+		t.Errorf("table header = %q", lines[0])
+	}
+	if row := strings.Fields(lines[1]); len(row) != 8 || row[0] != id || row[1]+row[2] != "[]" || row[3] != "1" || row[6]+" "+row[7] != "Pay rent" {
+		t.Errorf("table row = %q", lines[1])
+	}
+
+	plain := c.ok("-o", "plain", "list", "home")
+	fields := strings.Split(strings.TrimSuffix(plain, "\n"), "\t")
+	if len(fields) != 5 || fields[0] != id || fields[2] != "1" || fields[4] != "Pay rent" {
+		t.Errorf("plain = %q, want tab-separated ID, done, priority, due and content", plain)
+	}
+
+	var tasks []client.Task
+	if err := json.Unmarshal([]byte(c.ok("-o", "json", "list", "home")), &tasks); err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != id || tasks[0].Content != "Pay rent" {
+		t.Errorf("json = %+v", tasks)
+	}
+
+	if out := c.ok("-o", "plain", "projects"); out != "home\n" {
+		t.Errorf("plain projects = %q", out)
+	}
+	var projects []client.Project
+	if err := json.Unmarshal([]byte(c.ok("-o", "json", "projects")), &projects); err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 || projects[0].Name != "home" || projects[0].ID == "" {
+		t.Errorf("json projects = %+v", projects)
+	}
+
+	if out := c.ok("-o", "json", "list", "-status", "done", "home"); strings.TrimSpace(out) != "[]" {
+		t.Errorf("empty json list = %q, want []", out)
+	}
+	if code, _, stderr := c.run("-o", "yaml", "list", "home"); code != 2 || !strings.Contains(stderr, `unknown output format "yaml"`) {
+		t.Errorf("unknown format: exit code %d, stderr %q", code, stderr)
+	}
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	srv := servertest.New(t, nil)
+	c := newCLI(t)
+
+	if code, _, _ := c.run("register", "-server", srv.URL, "-user", "carol", "-password", "short"); code != 1 {
+		t.Errorf("register with a short password: exit code %d, want 1", code)
+	}
+	if _, err := os.Stat(c.config); !os.IsNotExist(err) {
+		t.Fatalf("a failed register wrote the config file: %v", err)
+	}
+
+	c.ok("register", "-server", srv.URL, "-user", "carol", "-password", "secret1")
+	info, err := os.Stat(c.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("config file mode = %v, want 0600", perm)
+	}
+	cfg, err := cliconfig.Load(c.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server != srv.URL || cfg.Username != "carol" || cfg.Token == "" {
+		t.Errorf("saved config = %+v, want the server, carol and a token", *cfg)
+	}
+	if raw, _ := os.ReadFile(c.config); strings.Contains(string(raw), "secret1") {
+		t.Errorf("config file holds the password: %s", raw)
+	}
+	token := cfg.Token
+
+	// Later runs take the server and credentials from the file.
+	c.ok("projects", "create", "errands")
+	if out := c.ok("-o", "plain", "projects"); out != "errands\n" {
+		t.Errorf("projects = %q", out)
+	}
+
+	if out := c.ok("logout"); out != "logged out\n" {
+		t.Errorf("logout printed %q", out)
+	}
+	if cfg, err = cliconfig.Load(c.config); err != nil {
+		t.Fatal(err)
+	}
+	if *cfg != (cliconfig.Config{Server: srv.URL}) {
+		t.Errorf("config after logout = %+v, want only the server", *cfg)
+	}
+	if code, _, _ := c.run("projects"); code != 1 {
+		t.Errorf("projects after logout: exit code %d, want 1", code)
+	}
+	if _, err := client.New(srv.URL, client.WithToken(token)).GetProjects(context.Background(), false); !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("token after logout: err = %v, want ErrUnauthorized", err)
+	}
+
+	if code, _, stderr := c.run("login", "-user", "carol", "-password", "wrong-password"); code != 1 || !strings.Contains(stderr, "invalid credentials") {
+		t.Errorf("login with a wrong password: exit code %d, stderr %q", code, stderr)
+	}
+	if cfg, _ = cliconfig.Load(c.config); cfg.Username != "" {
+		t.Errorf("a failed login saved %+v", *cfg)
+	}
+	if out := c.ok("login", "-user", "carol", "-password", "secret1"); out != "logged in as carol at "+srv.URL+"\n" {
+		t.Errorf("login printed %q", out)
+	}
+	if out := c.ok("-o", "plain", "projects"); out != "errands\n" {
+		t.Errorf("projects after login = %q", out)
+	}
+
+	// Deactivation revokes the token, so reactivating takes the password.
+	c.ok("deactivate")
+	if cfg, _ = cliconfig.Load(c.config); cfg.Username != "carol" || cfg.Token != "" {
+		t.Errorf("config after deactivate = %+v, want carol without a token", *cfg)
+	}
+	if code, _, _ := c.run("reactivate"); code != 2 {
+		t.Errorf("reactivate without a password: exit code %d, want 2", code)
+	}
+	if out := c.ok("reactivate", "-password", "secret1"); out != "reactivated carol at "+srv.URL+"\n" {
+		t.Errorf("reactivate printed %q", out)
+	}
+	if out := c.ok("-o", "plain", "projects"); out != "errands\n" {
+		t.Errorf("projects after reactivate = %q", out)
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"todolist/pkg/client"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputPlain = "plain"
+)
+
+// noDue mirrors services.DefaultTimestamp, the server's "no due date" sentinel.
+var noDue = time.Date(2099, 12, 31, 23, 59, 59, 0, time.UTC)
+
+type printer struct {
+	mode string
+	w    io.Writer
+}
+
+func (p *printer) json(v any) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (p *printer) table(header []string, rows [][]string) {
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+}
+
+// message reports the outcome of a mutation.
+func (p *printer) message(msg, undoToken string) error {
+	switch p.mode {
+	case outputJSON:
+		return p.json(map[string]string{"message": msg, "undoToken": undoToken})
+	case outputPlain:
+		fmt.Fprintln(p.w, msg)
+	default:
+		fmt.Fprintln(p.w, msg)
+		if undoToken != "" {
+			fmt.Fprintf(p.w, "undo with: todo undo %s\n", undoToken)
+		}
+	}
+	return nil
+}
+
+func (p *printer) projects(projects []client.Project) error {
+	switch p.mode {
+	case outputJSON:
+		return p.json(projects)
+	case outputPlain:
+		for _, project := range projects {
+			fmt.Fprintln(p.w, project.Name)
+		}
+	default:
+		rows := make([][]string, 0, len(projects))
+		for _, project := range projects {
+			archived := ""
+			if project.Archived {
+				archived = "archived"
+			}
+			rows = append(rows, []string{project.Name, project.ID, project.Color, archived, project.Description})
+		}
+		p.table([]string{"PROJECT", "ID", "COLOR", "STATE", "DESCRIPTION"}, rows)
+	}
+	return nil
+}
+
+// projectTree prints the tree indented by depth, with open and total task
+// counts that include sub-projects.
+func (p *printer) projectTree(tree []client.ProjectNode) error {
+	if p.mode == outputJSON {
+		return p.json(tree)
+	}
+	var rows [][]string
+	var walk func(nodes []client.ProjectNode, depth int)
+	walk = func(nodes []client.ProjectNode, depth int) {
+		for _, node := range nodes {
+			name := strings.Repeat("  ", depth) + node.Project.Name
+			if p.mode == outputPlain {
+				fmt.Fprintln(p.w, name)
+			} else {
+				rows = append(rows, []string{name, strconv.Itoa(node.TotalOpenTaskCount), strconv.Itoa(node.TotalTaskCount)})
+			}
+			walk(node.Children, depth+1)
+		}
+	}
+	walk(tree, 0)
+	if p.mode != outputPlain {
+		p.table([]string{"PROJECT", "OPEN", "TASKS"}, rows)
+	}
+	return nil
+}
+
+func (p *printer) templates(templates []client.Template) error {
+	switch p.mode {
+	case outputJSON:
+		return p.json(templates)
+	case outputPlain:
+		for _, t := range templates {
+			fmt.Fprintln(p.w, t.Name)
+		}
+	default:
+		rows := make([][]string, 0, len(templates))
+		for _, t := range templates {
+			rows = append(rows, []string{t.Name, t.ID, strconv.Itoa(len(t.Tasks)), t.Description})
+		}
+		p.table([]string{"TEMPLATE", "ID", "TASKS", "DESCRIPTION"}, rows)
+	}
+	return nil
+}
+
+func (p *printer) smartLists(lists []client.SmartList) error {
+	switch p.mode {
+	case outputJSON:
+		return p.json(lists)
+	case outputPlain:
+		for _, l := range lists {
+			fmt.Fprintln(p.w, l.Name)
+		}
+	default:
+		rows := make([][]string, 0, len(lists))
+		for _, l := range lists {
+			rows = append(rows, []string{l.Name, l.ID, l.Query})
+		}
+		p.table([]string{"LIST", "ID", "QUERY"}, rows)
+	}
+	return nil
+}
+
+func (p *printer) settings(settings client.UserSettings) error {
+	if p.mode == outputJSON {
+		return p.json(settings)
+	}
+	rows := [][]string{
+		{"timeZone", settings.TimeZone},
+		{"weekStart", settings.WeekStart},
+		{"locale", settings.Locale},
+		{"dateFormat", settings.DateFormat},
+	}
+	if p.mode == outputPlain {
+		for _, row := range rows {
+			fmt.Fprintln(p.w, strings.Join(row, "\t"))
+		}
+		return nil
+	}
+	p.table([]string{"SETTING", "VALUE"}, rows)
+	return nil
+}
+
+// quickAdd prints the fields recognized in a quick-add string.
+func (p *printer) quickAdd(parsed client.QuickAdd) error {
+	if p.mode == outputJSON {
+		return p.json(parsed)
+	}
+	rows := [][]string{{"content", parsed.Content}}
+	if parsed.Due != nil {
+		rows = append(rows, []string{"due", formatDue(*parsed.Due)})
+	}
+	if parsed.Priority != nil {
+		rows = append(rows, []string{"priority", strconv.Itoa(*parsed.Priority)})
+	}
+	if parsed.Project != "" {
+		rows = append(rows, []string{"project", parsed.Project})
+	}
+	if parsed.Recurrence != nil {
+		rows = append(rows, []string{"recurrence", parsed.Recurrence.String()})
+	}
+	if p.mode == outputPlain {
+		for _, row := range rows {
+			fmt.Fprintln(p.w, strings.Join(row, "\t"))
+		}
+		return nil
+	}
+	p.table([]string{"FIELD", "VALUE"}, rows)
+	return nil
+}
+
+// projectTasks prints tasks from several projects, like tasks with the
+// project in front.
+func (p *printer) projectTasks(tasks []client.ProjectTask) error {
+	switch p.mode {
+	case outputJSON:
+		return p.json(tasks)
+	case outputPlain:
+		for _, pt := range tasks {
+			t := pt.Task
+			fmt.Fprintf(p.w, "%s\t%s\t%s\t%d\t%s\t%s\n", pt.ProjectName, t.ID, checkbox(t.Completed), t.Priority, formatDue(t.Due), t.Content)
+		}
+	default:
+		rows := make([][]string, 0, len(tasks))
+		for _, pt := range tasks {
+			t := pt.Task
+			rows = append(rows, []string{pt.ProjectName, t.ID, checkbox(t.Completed), strconv.Itoa(t.Priority), formatDue(t.Due), t.Content})
+		}
+		p.table([]string{"PROJECT", "ID", "DONE", "PRI", "DUE", "CONTENT"}, rows)
+	}
+	return nil
+}
+
+// agenda prints the overdue tasks and then those of every day with
+// something due, with times in the agenda's time zone.
+func (p *printer) agenda(agenda client.Agenda) error {
+	if p.mode == outputJSON {
+		return p.json(agenda)
+	}
+	loc, err := time.LoadLocation(agenda.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	var rows [][]string
+	add := func(day string, tasks []client.ProjectTask, layout string) {
+		for _, pt := range tasks {
+			t := pt.Task
+			row := []string{day, pt.ProjectName, t.ID, strconv.Itoa(t.Priority), t.Due.In(loc).Format(layout), t.Content}
+			if p.mode == outputPlain {
+				fmt.Fprintln(p.w, strings.Join(row, "\t"))
+			}
+			rows = append(rows, row)
+		}
+	}
+	add("overdue", agenda.Overdue, "2006-01-02 15:04")
+	for _, day := range agenda.Days {
+		add(day.Label, day.Tasks, "15:04")
+	}
+	if p.mode != outputPlain {
+		p.table([]string{"DAY", "PROJECT", "ID", "PRI", "DUE", "CONTENT"}, rows)
+	}
+	return nil
+}
+
+func (p *printer) searchHits(hits []client.SearchHit) error {
+	switch p.mode {
+	case outputJSON:
+		return p.json(hits)
+	case outputPlain:
+		for _, h := range hits {
+			fmt.Fprintf(p.w, "%s\t%s\t%s\n", h.ProjectName, h.Task.ID, h.Task.Content)
+		}
+	default:
+		rows := make([][]string, 0, len(hits))
+		for _, h := range hits {
+			rows = append(rows, []string{h.ProjectName, h.Task.ID, checkbox(h.Task.Completed), markMatches(h.Task.Content, h.Matches)})
+		}
+		p.table([]string{"PROJECT", "ID", "DONE", "CONTENT"}, rows)
+	}
+	return nil
+}
+
+// markMatches wraps the matched ranges of s in asterisks.
+func markMatches(s string, matches []client.TextRange) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(s[last:m.Start])
+		b.WriteString("*" + s[m.Start:m.End] + "*")
+		last = m.End
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func (p *printer) tasks(tasks []client.Task) error {
+	switch p.mode {
+	case outputJSON:
+		return p.json(tasks)
+	case outputPlain:
+		for _, t := range tasks {
+			fmt.Fprintf(p.w, "%s\t%s\t%d\t%s\t%s\n", t.ID, checkbox(t.Completed), t.Priority, formatDue(t.Due), t.Content)
+		}
+	default:
+		rows := make([][]string, 0, len(tasks))
+		for _, t := range tasks {
+			rows = append(rows, []string{t.ID, checkbox(t.Completed), strconv.Itoa(t.Priority), formatDue(t.Due), t.Content})
+		}
+		p.table([]string{"ID", "DONE", "PRI", "DUE", "CONTENT"}, rows)
+	}
+	return nil
+}
+
+func (p *printer) trash(items []client.TrashItem) error {
+	switch p.mode {
+	case outputJSON:
+		return p.json(items)
+	default:
+		rows := make([][]string, 0, len(items))
+		for _, item := range items {
+			what := fmt.Sprintf("%d tasks", item.TaskCount)
+			id := ""
+			if item.Task != nil {
+				id, what = item.Task.ID, item.Task.Content
+			}
+			rows = append(rows, []string{item.Kind, item.ProjectName, id, item.DeletedAt.Local().Format(time.DateTime), what})
+		}
+		if p.mode == outputPlain {
+			for _, row := range rows {
+				fmt.Fprintln(p.w, strings.Join(row, "\t"))
+			}
+			return nil
+		}
+		p.table([]string{"KIND", "PROJECT", "ID", "DELETED", "CONTENT"}, rows)
+	}
+	return nil
+}
+
+func (p *printer) history(changes []client.TaskChange) error {
+	switch p.mode {
+	case outputJSON:
+		return p.json(changes)
+	default:
+		rows := make([][]string, 0, len(changes))
+		for _, c := range changes {
+			diffs := make([]string, 0, len(c.Changes))
+			for _, f := range c.Changes {
+				diffs = append(diffs, fmt.Sprintf("%s: %q -> %q", f.Field, f.Old, f.New))
+			}
+			rows = append(rows, []string{c.Time.Local().Format(time.DateTime), c.Actor, c.Action, c.TaskID, strings.Join(diffs, "; "), c.ID})
+		}
+		if p.mode == outputPlain {
+			for _, row := range rows {
+				fmt.Fprintln(p.w, strings.Join(row, "\t"))
+			}
+			return nil
+		}
+		p.table([]string{"TIME", "ACTOR", "ACTION", "TASK", "CHANGES", "TOKEN"}, rows)
+	}
+	return nil
+}
+
+func checkbox(done bool) string {
+	if done {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+func formatDue(due time.Time) string {
+	if due.IsZero() || due.Equal(noDue) {
+		return "-"
+	}
+	return due.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,279 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"todolist/internal/services"
+)
+
+// Document is the subset of OpenAPI 3.0 the server describes itself with.
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+	Security   []map[string][]string            `json:"security"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	Responses       map[string]*Response       `json:"responses"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme"`
+	Description string `json:"description,omitempty"`
+}
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	// Security is an empty list for public operations, overriding the
+	// document-wide requirement, and nil otherwise.
+	Security []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+}
+
+// Spec builds the OpenAPI document describing routes.
+func Spec(routes []Route) *Document {
+	gen := &schemaGen{schemas: make(map[string]*Schema)}
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "Todo List API",
+			Description: "Every response carries an X-Request-ID header, echoing the request's header when it was sent. Errors are plain-text messages, except validation failures, which are reported as JSON listing every rejected field.",
+			Version:     "1.0.0",
+		},
+		Paths: make(map[string]map[string]*Operation),
+		Components: Components{
+			Schemas: gen.schemas,
+			Responses: map[string]*Response{
+				"Error": {
+					Description: "Error message",
+					Content:     map[string]*MediaType{"text/plain": {Schema: &Schema{Type: "string"}}},
+				},
+				"BadRequest": {
+					Description: "Invalid input",
+					Content: map[string]*MediaType{
+						"application/json": {Schema: gen.schema(reflect.TypeOf(services.ValidationError{}))},
+						"text/plain":       {Schema: &Schema{Type: "string"}},
+					},
+				},
+			},
+			SecuritySchemes: map[string]*SecurityScheme{
+				"basicAuth":  {Type: "http", Scheme: "basic"},
+				"bearerAuth": {Type: "http", Scheme: "bearer", Description: "A token issued by /login."},
+			},
+		},
+		Security: []map[string][]string{{"basicAuth": {}}, {"bearerAuth": {}}},
+	}
+	for _, rt := range routes {
+		if doc.Paths[rt.Path] == nil {
+			doc.Paths[rt.Path] = make(map[string]*Operation)
+		}
+		doc.Paths[rt.Path][strings.ToLower(rt.Method)] = gen.operation(rt)
+	}
+	return doc
+}
+
+func (g *schemaGen) operation(rt Route) *Operation {
+	op := &Operation{
+		OperationID: operationID(rt),
+		Summary:     rt.Summary,
+		Description: rt.Description,
+		Responses:   make(map[string]*Response),
+	}
+	for _, p := range rt.Query {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name: p.Name, In: "query", Description: p.Description, Required: p.Required, Schema: &Schema{Type: "string"},
+		})
+	}
+	if rt.Idempotent {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name: "Idempotency-Key", In: "header", Schema: &Schema{Type: "string"},
+			Description: "Unique key of this write. Retries with the same key replay the first response instead of writing again.",
+		})
+	}
+	if rt.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: g.schema(reflect.TypeOf(rt.Body))}},
+		}
+	}
+
+	status := rt.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	ok := &Response{Description: http.StatusText(status)}
+	switch {
+	case rt.Response != nil:
+		ok.Content = map[string]*MediaType{"application/json": {Schema: g.schema(reflect.TypeOf(rt.Response))}}
+	case rt.ContentType != "":
+		ok.Content = map[string]*MediaType{rt.ContentType: {Schema: &Schema{Type: "string"}}}
+	default:
+		ok.Content = map[string]*MediaType{"text/plain": {Schema: &Schema{Type: "string"}}}
+	}
+	if rt.UndoToken {
+		ok.Headers = map[string]*Header{
+			"X-Undo-Token": {Description: "Token to pass to /undo to revert this mutation.", Schema: &Schema{Type: "string"}},
+		}
+	}
+	if rt.Idempotent {
+		if ok.Headers == nil {
+			ok.Headers = make(map[string]*Header)
+		}
+		ok.Headers["Idempotent-Replayed"] = &Header{Description: "Set to true on a replayed response.", Schema: &Schema{Type: "string"}}
+	}
+	op.Responses[strconv.Itoa(status)] = ok
+
+	errs := append([]int{http.StatusInternalServerError}, rt.Errors...)
+	if rt.Idempotent {
+		errs = append(errs, http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity)
+	}
+	if rt.Public {
+		op.Security = []map[string][]string{}
+	} else {
+		errs = append(errs, http.StatusUnauthorized)
+	}
+	for _, code := range errs {
+		ref := "#/components/responses/Error"
+		if code == http.StatusBadRequest {
+			ref = "#/components/responses/BadRequest"
+		}
+		op.Responses[strconv.Itoa(code)] = &Response{Ref: ref}
+	}
+	return op
+}
+
+// operationID derives a stable ID such as "getPrintTasks".
+func operationID(rt Route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(rt.Method))
+	for _, part := range strings.FieldsFunc(rt.Path, func(r rune) bool { return r == '/' || r == '.' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// schemaGen converts Go types to schemas, collecting named structs as
+// components so they are described once and referenced everywhere.
+type schemaGen struct {
+	schemas map[string]*Schema
+}
+
+func (g *schemaGen) schema(t reflect.Type) *Schema {
+	switch {
+	case t.Kind() == reflect.Pointer:
+		s := g.schema(t.Elem())
+		if s.Ref != "" {
+			// $ref siblings are ignored in 3.0, so wrap it to mark it nullable.
+			return &Schema{Nullable: true, AllOf: []*Schema{s}}
+		}
+		s.Nullable = true
+		return s
+	case t.PkgPath() == "time" && t.Name() == "Time":
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			g.schemas[t.Name()] = &Schema{} // placeholder against recursion
+			g.schemas[t.Name()] = g.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	default:
+		return &Schema{}
+	}
+}
+
+// object describes a struct by its JSON field names. The same schemas serve
+// request and response bodies, so no property is marked required.
+func (g *schemaGen) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = g.schema(f.Type)
+	}
+	return s
+}
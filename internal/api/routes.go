@@ -0,0 +1,121 @@
+// Package api holds the HTTP route table. The same table registers the routes
+// on the router and generates the OpenAPI document served at /openapi.json,
+// so the two cannot drift apart.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Route is one HTTP operation together with its documentation.
+type Route struct {
+	Method  string
+	Path    string
+	Handler http.HandlerFunc
+	// Public routes are served without authentication.
+	Public bool
+	// Prefix routes also serve every path below Path.
+	Prefix bool
+
+	Summary     string
+	Description string
+	Query       []Param
+	// Body is a value of the JSON request body type, nil for no body.
+	Body any
+	// Status is the success status, 200 when zero.
+	Status int
+	// Response is a value of the JSON response type; nil for a plain-text
+	// response, or ContentType when that is set.
+	Response    any
+	ContentType string
+	// UndoToken marks mutations that return an X-Undo-Token header.
+	UndoToken bool
+	// Idempotent writes honour an Idempotency-Key header.
+	Idempotent bool
+	// Errors are the error statuses besides 401 and 500.
+	Errors []int
+}
+
+// Param is a query parameter.
+type Param struct {
+	Name        string
+	Required    bool
+	Description string
+}
+
+// Register adds routes to r, wrapping the non-public ones with authenticate
+// and the idempotent ones with idempotent.
+func Register(r *mux.Router, routes []Route, authenticate, idempotent func(http.HandlerFunc) http.HandlerFunc) {
+	for _, rt := range routes {
+		h := rt.Handler
+		if rt.Idempotent {
+			h = idempotent(h)
+		}
+		if !rt.Public {
+			h = authenticate(h)
+		}
+		if rt.Prefix {
+			r.PathPrefix(rt.Path).Handler(h).Methods(rt.Method, http.MethodHead)
+			continue
+		}
+		r.HandleFunc(rt.Path, h).Methods(rt.Method, http.MethodOptions)
+	}
+}
+
+// SpecRoute returns the route serving the OpenAPI document of routes and of
+// itself at path.
+func SpecRoute(path string, routes []Route) Route {
+	rt := Route{
+		Method:   http.MethodGet,
+		Path:     path,
+		Public:   true,
+		Summary:  "This OpenAPI document",
+		Response: map[string]any{},
+	}
+	var body []byte
+	rt.Handler = func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+	body, _ = json.MarshalIndent(Spec(append(slices.Clone(routes), rt)), "", "  ")
+	return rt
+}
+
+// Check verifies that every route registered on r, including any added
+// outside the route table, is described by doc.
+func Check(r *mux.Router, doc *Document) error {
+	var missing []string
+	err := r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil // not a path route
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			missing = append(missing, "* "+path)
+			return nil
+		}
+		for _, m := range methods {
+			if m == http.MethodOptions || m == http.MethodHead {
+				continue
+			}
+			if _, ok := doc.Paths[path][strings.ToLower(m)]; !ok {
+				missing = append(missing, m+" "+path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("routes missing from the OpenAPI document: %v", missing)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// Package cliconfig stores the server address and the token shared by the
+// command-line and terminal clients.
+package cliconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"todolist/pkg/client"
+)
+
+// Config is persisted as JSON so that credentials only have to be given once.
+// It keeps the bearer token issued by the server at login, never the
+// password.
+type Config struct {
+	Server   string `json:"server"`
+	Username string `json:"username"`
+	Token    string `json:"token"`
+}
+
+const DefaultServer = "http://localhost:7071"
+
+// DefaultPath honours TODO_CONFIG, then the user's config directory.
+func DefaultPath() string {
+	if p := os.Getenv("TODO_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".todo.json"
+	}
+	return filepath.Join(dir, "todo", "config.json")
+}
+
+// Load reads the config at path. A missing file yields the defaults.
+func Load(path string) (*Config, error) {
+	cfg := &Config{Server: DefaultServer}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes the config readable only by the owner, since it holds a token.
+func (c *Config) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o600)
+}
+
+// Client returns an API client for the configured server and token.
+func (c *Config) Client() *client.Client {
+	return client.New(c.Server, client.WithToken(c.Token))
+}
+
+// Login exchanges the password for a token and stores the token in c.
+func (c *Config) Login(ctx context.Context, username, password string) error {
+	token, _, err := client.New(c.Server, client.WithBasicAuth(username, password)).Login(ctx)
+	if err != nil {
+		return err
+	}
+	c.Username, c.Token = username, token
+	return nil
+}
@@ -0,0 +1,165 @@
+// Package filter implements the query language of saved filters and smart
+// lists, such as
+//
+//	priority <= 2 and due < +3d and not completed in project:work
+//
+// A query is parsed into an expression tree that is evaluated against a task
+// and the project it is in.
+package filter
+
+import (
+	"strings"
+	"time"
+	"todolist/internal/models"
+)
+
+// Field is a property of a task, or of its project, that a query compares.
+type Field string
+
+const (
+	FieldContent   Field = "content"
+	FieldPriority  Field = "priority"
+	FieldDue       Field = "due"
+	FieldUpdated   Field = "updated"
+	FieldCompleted Field = "completed"
+	FieldProject   Field = "project"
+	FieldArchived  Field = "archived"
+)
+
+// Op is a comparison operator.
+type Op string
+
+const (
+	OpEq       Op = "="
+	OpNe       Op = "!="
+	OpLt       Op = "<"
+	OpLe       Op = "<="
+	OpGt       Op = ">"
+	OpGe       Op = ">="
+	OpContains Op = ":" // content only; ":" means "=" for every other field
+)
+
+// Expr is a parsed query.
+type Expr interface {
+	// Match reports whether task, which is in project, satisfies the query.
+	Match(task models.Task, project models.Project) bool
+}
+
+// And matches what both X and Y match.
+type And struct{ X, Y Expr }
+
+// Or matches what X or Y matches.
+type Or struct{ X, Y Expr }
+
+// Not matches what X does not.
+type Not struct{ X Expr }
+
+func (e *And) Match(task models.Task, project models.Project) bool {
+	return e.X.Match(task, project) && e.Y.Match(task, project)
+}
+
+func (e *Or) Match(task models.Task, project models.Project) bool {
+	return e.X.Match(task, project) || e.Y.Match(task, project)
+}
+
+func (e *Not) Match(task models.Task, project models.Project) bool {
+	return !e.X.Match(task, project)
+}
+
+// Compare compares a field to a value. Which of the value fields is set
+// depends on the field.
+type Compare struct {
+	Field Field
+	Op    Op
+	Text  string // content, lowercased; project name or ID
+	Int   int    // priority
+	Bool  bool   // completed, archived
+	// From and To bound the time a due or updated date is compared to, as
+	// the range [From, To): a whole day for dates such as "today", a single
+	// nanosecond for instants such as "+3d".
+	From, To time.Time
+	// None compares a due date to "none", no due date at all.
+	None bool
+}
+
+func (c *Compare) Match(task models.Task, project models.Project) bool {
+	switch c.Field {
+	case FieldContent:
+		content := strings.ToLower(task.Content)
+		switch c.Op {
+		case OpContains:
+			return strings.Contains(content, c.Text)
+		case OpEq:
+			return content == c.Text
+		case OpNe:
+			return content != c.Text
+		}
+	case FieldPriority:
+		return compareInts(task.Priority, c.Op, c.Int)
+	case FieldDue:
+		hasDue := !task.Due.IsZero() && !task.Due.Equal(models.NoDue)
+		if c.None {
+			return hasDue == (c.Op == OpNe)
+		}
+		if !hasDue {
+			return c.Op == OpNe
+		}
+		return c.matchTime(task.Due)
+	case FieldUpdated:
+		return c.matchTime(task.UpdatedTime)
+	case FieldCompleted:
+		return (task.Completed == c.Bool) == (c.Op == OpEq)
+	case FieldArchived:
+		return (project.Archived == c.Bool) == (c.Op == OpEq)
+	case FieldProject:
+		same := project.Name == c.Text || project.ID == c.Text
+		return same == (c.Op == OpEq)
+	}
+	return false
+}
+
+func (c *Compare) matchTime(t time.Time) bool {
+	switch c.Op {
+	case OpEq:
+		return !t.Before(c.From) && t.Before(c.To)
+	case OpNe:
+		return t.Before(c.From) || !t.Before(c.To)
+	case OpLt:
+		return t.Before(c.From)
+	case OpLe:
+		return t.Before(c.To)
+	case OpGt:
+		return !t.Before(c.To)
+	case OpGe:
+		return !t.Before(c.From)
+	}
+	return false
+}
+
+func compareInts(a int, op Op, b int) bool {
+	switch op {
+	case OpEq:
+		return a == b
+	case OpNe:
+		return a != b
+	case OpLt:
+		return a < b
+	case OpLe:
+		return a <= b
+	case OpGt:
+		return a > b
+	case OpGe:
+		return a >= b
+	}
+	return false
+}
+
+// Conjuncts splits e into the expressions joined by its top-level ands, every
+// one of which a match must satisfy. Storage can use those it understands to
+// narrow down the tasks it reads before matching them against e.
+func Conjuncts(e Expr) []Expr {
+	if and, ok := e.(*And); ok {
+		return append(Conjuncts(and.X), Conjuncts(and.Y)...)
+	}
+	return []Expr{e}
+}
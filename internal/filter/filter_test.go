@@ -0,0 +1,185 @@
+package filter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"todolist/internal/models"
+)
+
+// now is 01:00 on Wednesday May 7 at UTC+2, still Tuesday in UTC.
+var (
+	loc = time.FixedZone("UTC+2", 2*60*60)
+	now = time.Date(2025, 5, 7, 1, 0, 0, 0, loc)
+)
+
+type taskIn struct {
+	task    models.Task
+	project models.Project
+}
+
+// matching returns the contents of the tasks e matches, space separated.
+func matching(e Expr, tasks []taskIn) string {
+	var names []string
+	for _, t := range tasks {
+		if e.Match(t.task, t.project) {
+			names = append(names, t.task.Content)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func TestParsePrecedence(t *testing.T) {
+	work := models.Project{ID: "p_work", Name: "work"}
+	home := models.Project{ID: "p_home", Name: "home"}
+	tasks := []taskIn{
+		{models.Task{Content: "a", Priority: 1}, work},
+		{models.Task{Content: "b", Priority: 2, Completed: true}, work},
+		{models.Task{Content: "c", Priority: 3}, work},
+		{models.Task{Content: "d", Priority: 1, Completed: true}, home},
+	}
+	for _, tt := range []struct {
+		query, want string
+	}{
+		{"priority = 1 or priority = 2 and completed", "a b d"},
+		{"(priority = 1 or priority = 2) and completed", "b d"},
+		{"not completed and priority >= 2", "c"},
+		{"not (completed or priority = 1)", "c"},
+		{"not not completed", "b d"},
+		{"priority >= 2 not completed", "c"},
+		{"completed or priority = 1 in project:home", "b d"},
+		{"(completed or priority = 1) in project:home", "d"},
+		{"PRIORITY = 1 AND Project:home", "d"},
+		{"project:p_work and priority < 3", "a b"},
+		{"project:Work", ""},
+	} {
+		e, err := Parse(tt.query, now, time.Monday)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.query, err)
+			continue
+		}
+		if got := matching(e, tasks); got != tt.want {
+			t.Errorf("%q matches %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseDates(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2025, m, d, 0, 0, 0, 0, loc) }
+	for _, tt := range []struct {
+		value     string
+		weekStart time.Weekday
+		from, to  time.Time
+	}{
+		{"now", time.Monday, now, now.Add(time.Nanosecond)},
+		{"today", time.Monday, day(5, 7), day(5, 8)},
+		{"TOMORROW", time.Monday, day(5, 8), day(5, 9)},
+		{"yesterday", time.Monday, day(5, 6), day(5, 7)},
+		{"thisweek", time.Monday, day(5, 5), day(5, 12)},
+		{"thisweek", time.Sunday, day(5, 4), day(5, 11)},
+		{"thisweek", time.Wednesday, day(5, 7), day(5, 14)},
+		{"thisweek", time.Thursday, day(5, 1), day(5, 8)},
+		{"lastweek", time.Monday, day(4, 28), day(5, 5)},
+		{"nextweek", time.Sunday, day(5, 11), day(5, 18)},
+		{"+3d", time.Monday, now.Add(72 * time.Hour), now.Add(72*time.Hour + time.Nanosecond)},
+		{"-30m", time.Monday, now.Add(-30 * time.Minute), now.Add(-30*time.Minute + time.Nanosecond)},
+		{"+4H", time.Monday, now.Add(4 * time.Hour), now.Add(4*time.Hour + time.Nanosecond)},
+		{"-1w", time.Monday, now.AddDate(0, 0, -7), now.AddDate(0, 0, -7).Add(time.Nanosecond)},
+		{"2025-06-01", time.Monday, day(6, 1), day(6, 2)},
+		{"2025-06-01T12:00:00Z", time.Monday, time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), time.Date(2025, 6, 1, 12, 0, 0, 1, time.UTC)},
+	} {
+		e, err := Parse("due = "+tt.value, now, tt.weekStart)
+		if err != nil {
+			t.Errorf("Parse(due = %s): %v", tt.value, err)
+			continue
+		}
+		c := e.(*Compare)
+		if !c.From.Equal(tt.from) || !c.To.Equal(tt.to) {
+			t.Errorf("%s with weeks from %s = [%v, %v), want [%v, %v)", tt.value, tt.weekStart, c.From, c.To, tt.from, tt.to)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tt := range []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{"", 0, "expected a comparison"},
+		{"not", 3, "expected a comparison"},
+		{"priority = 1 or", 15, "expected a comparison"},
+		{"priority", 8, "expected an operator after priority"},
+		{"priority <=", 11, "expected a value after priority <="},
+		{"priority = high", 11, "priority must be a number"},
+		{"colour = red", 0, `unknown field "colour"`},
+		{"completed < true", 10, "completed only supports :, = and !="},
+		{"completed = maybe", 12, "completed must be true or false"},
+		{"due > none", 4, "due can only be compared to none with = and !="},
+		{"due = someday", 6, `invalid date "someday"`},
+		{"due = +3x", 6, `invalid date "+3x"`},
+		{"(priority = 1", 0, "unclosed parenthesis"},
+		{"priority = 1)", 12, `unexpected ")"`},
+		{"priority = 1 & completed", 13, `unexpected "&"`},
+		{`content:"milk`, 8, "unterminated quoted value"},
+		{`content:""`, 8, "empty quoted value"},
+	} {
+		_, err := Parse(tt.query, now, time.Monday)
+		var serr *SyntaxError
+		if !errors.As(err, &serr) {
+			t.Errorf("Parse(%q) = %v, want a syntax error", tt.query, err)
+			continue
+		}
+		if serr.Pos != tt.pos || !strings.HasPrefix(serr.Msg, tt.msg) {
+			t.Errorf("Parse(%q) = %q at %d, want %q at %d", tt.query, serr.Msg, serr.Pos, tt.msg, tt.pos)
+		}
+	}
+}
+
+// Backslashes only escape within quotes.
+func TestParseQuotedValues(t *testing.T) {
+	e, err := Parse(`content = "say \"hi\" (now)" or content:"C:\\tmp" or content:a\b`, now, time.Monday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks := []taskIn{
+		{task: models.Task{Content: `Say "hi" (now)`}},
+		{task: models.Task{Content: `clean c:\tmp`}},
+		{task: models.Task{Content: "say hi"}},
+		{task: models.Task{Content: `a\b`}},
+	}
+	if got, want := matching(e, tasks), `Say "hi" (now) clean c:\tmp a\b`; got != want {
+		t.Errorf("matches %q, want %q", got, want)
+	}
+}
+
+func TestMatchNoDue(t *testing.T) {
+	tasks := []taskIn{
+		{task: models.Task{Content: "zero"}},
+		{task: models.Task{Content: "sentinel", Due: models.NoDue}},
+		{task: models.Task{Content: "today", Due: time.Date(2025, 5, 7, 12, 0, 0, 0, loc)}},
+	}
+	for _, tt := range []struct {
+		query, want string
+	}{
+		{"due = none", "zero sentinel"},
+		{"DUE : NONE", "zero sentinel"},
+		{"due != none", "today"},
+		{"not due = none", "today"},
+		{"due = today", "today"},
+		{"due != today", "zero sentinel"},
+		{"due < +3d", "today"},
+		{"due >= -1w", "today"},
+		{"due > 2099-01-01", ""},
+	} {
+		e, err := Parse(tt.query, now, time.Monday)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.query, err)
+			continue
+		}
+		if got := matching(e, tasks); got != tt.want {
+			t.Errorf("%q matches %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyntaxError reports where and why a query could not be parsed.
+type SyntaxError struct {
+	Pos int // byte offset in the query
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos+1)
+}
+
+// Parse parses a query. Relative dates in it, such as "today" or "+3d", are
+// taken from now, in its location, with weeks starting on weekStart.
+//
+// A query combines comparisons with "and" (also written "in", or left out),
+// "or", "not" and parentheses; "not" binds tightest and "or" loosest. A
+// comparison is a field, an operator and a value:
+//
+//	content:milk     the content contains "milk", ignoring case; = and != compare all of it
+//	priority <= 2    = != < <= > >= against a number
+//	due < +3d        the same operators against now, now plus or minus minutes, hours,
+//	                 days or weeks (-30m, +4h, +3d, -1w), today, tomorrow, yesterday,
+//	                 lastweek, thisweek, nextweek (standing for the whole week),
+//	                 a date (YYYY-MM-DD, standing for the whole day) or an RFC 3339 time;
+//	                 "due = none" matches the tasks without a due date
+//	updated >= -1w   like due, on the time the task was last written
+//	completed        alone, or = and != against true or false; likewise archived,
+//	                 which is true for the tasks of archived projects
+//	project:work     the task's project, by name or ID
+//
+// Keywords, field names and values are case-insensitive, project names
+// excepted. Values containing spaces or parentheses are written in double
+// quotes.
+func Parse(query string, now time.Time, weekStart time.Weekday) (Expr, error) {
+	p := &parser{query: query, now: now, weekStart: weekStart}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.query) {
+		return nil, p.errorf(p.pos, "unexpected %q", p.query[p.pos:p.pos+1])
+	}
+	return e, nil
+}
+
+type parser struct {
+	query     string
+	pos       int
+	now       time.Time
+	weekStart time.Weekday
+}
+
+func (p *parser) or() (Expr, error) {
+	x, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		y, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		x = &Or{X: x, Y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) and() (Expr, error) {
+	x, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if !p.keyword("and") && !p.keyword("in") {
+			// Comparisons written one after the other are joined by and.
+			p.skipSpace()
+			if p.pos == len(p.query) || p.query[p.pos] == ')' || p.peekKeyword("or") {
+				return x, nil
+			}
+		}
+		y, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		x = &And{X: x, Y: y}
+	}
+}
+
+func (p *parser) not() (Expr, error) {
+	if p.keyword("not") {
+		x, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{X: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (Expr, error) {
+	p.skipSpace()
+	if p.pos == len(p.query) {
+		return nil, p.errorf(p.pos, "expected a comparison")
+	}
+	if p.query[p.pos] == '(' {
+		open := p.pos
+		p.pos++
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.skipSpace(); p.pos == len(p.query) || p.query[p.pos] != ')' {
+			return nil, p.errorf(open, "unclosed parenthesis")
+		}
+		p.pos++
+		return e, nil
+	}
+	start := p.pos
+	name := p.ident()
+	if name == "" {
+		return nil, p.errorf(start, "unexpected %q", p.query[start:start+1])
+	}
+	return p.comparison(Field(name), start)
+}
+
+func (p *parser) comparison(field Field, start int) (Expr, error) {
+	switch field {
+	case FieldContent, FieldPriority, FieldDue, FieldUpdated, FieldCompleted, FieldProject, FieldArchived:
+	default:
+		return nil, p.errorf(start, "unknown field %q", string(field))
+	}
+	p.skipSpace()
+	opPos := p.pos
+	op := p.op()
+	if op == "" {
+		if field == FieldCompleted || field == FieldArchived {
+			return &Compare{Field: field, Op: OpEq, Bool: true}, nil
+		}
+		return nil, p.errorf(opPos, "expected an operator after %s", field)
+	}
+	p.skipSpace()
+	valuePos := p.pos
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, p.errorf(valuePos, "expected a value after %s %s", field, op)
+	}
+
+	c := &Compare{Field: field, Op: op}
+	if op == OpContains && field != FieldContent {
+		c.Op = OpEq
+	}
+	switch field {
+	case FieldContent, FieldProject, FieldCompleted, FieldArchived:
+		if op != OpContains && op != OpEq && op != OpNe {
+			return nil, p.errorf(opPos, "%s only supports :, = and !=", field)
+		}
+	}
+	switch field {
+	case FieldContent:
+		c.Text = strings.ToLower(value)
+	case FieldProject:
+		c.Text = value
+	case FieldPriority:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, p.errorf(valuePos, "priority must be a number")
+		}
+		c.Int = n
+	case FieldCompleted, FieldArchived:
+		b, err := strconv.ParseBool(strings.ToLower(value))
+		if err != nil {
+			return nil, p.errorf(valuePos, "%s must be true or false", field)
+		}
+		c.Bool = b
+	case FieldDue, FieldUpdated:
+		if field == FieldDue && strings.EqualFold(value, "none") {
+			if c.Op != OpEq && c.Op != OpNe {
+				return nil, p.errorf(opPos, "due can only be compared to none with = and !=")
+			}
+			c.None = true
+			break
+		}
+		from, to, ok := p.date(value)
+		if !ok {
+			return nil, p.errorf(valuePos, "invalid date %q, use now, today, tomorrow, yesterday, thisweek, +3d, YYYY-MM-DD or RFC 3339", value)
+		}
+		c.From, c.To = from, to
+	}
+	return c, nil
+}
+
+// date resolves a date value to the range [from, to) it stands for.
+func (p *parser) date(v string) (from, to time.Time, ok bool) {
+	loc := p.now.Location()
+	day := func(t time.Time) (time.Time, time.Time, bool) {
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 0, 1), true
+	}
+	instant := func(t time.Time) (time.Time, time.Time, bool) {
+		return t, t.Add(time.Nanosecond), true
+	}
+	switch strings.ToLower(v) {
+	case "now":
+		return instant(p.now)
+	case "today":
+		return day(p.now)
+	case "tomorrow":
+		return day(p.now.AddDate(0, 0, 1))
+	case "yesterday":
+		return day(p.now.AddDate(0, 0, -1))
+	case "lastweek", "thisweek", "nextweek":
+		into := (7 + int(p.now.Weekday()) - int(p.weekStart)) % 7
+		start, _, _ := day(p.now.AddDate(0, 0, -into))
+		switch strings.ToLower(v) {
+		case "lastweek":
+			start = start.AddDate(0, 0, -7)
+		case "nextweek":
+			start = start.AddDate(0, 0, 7)
+		}
+		return start, start.AddDate(0, 0, 7), true
+	}
+	if d, ok := parseOffset(v); ok {
+		return instant(p.now.Add(d))
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, v, loc); err == nil {
+		return day(t)
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return instant(t)
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// parseOffset parses a signed number of minutes, hours, days or weeks, such
+// as +3d or -1w.
+func parseOffset(v string) (time.Duration, bool) {
+	if len(v) < 3 || (v[0] != '+' && v[0] != '-') {
+		return 0, false
+	}
+	units := map[byte]time.Duration{'m': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	unit, ok := units[v[len(v)-1]|0x20]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v[1 : len(v)-1])
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	if v[0] == '-' {
+		n = -n
+	}
+	return time.Duration(n) * unit, true
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.query) && isSpace(p.query[p.pos]) {
+		p.pos++
+	}
+}
+
+// ident reads a field name or keyword, lowercased.
+func (p *parser) ident() string {
+	start := p.pos
+	for p.pos < len(p.query) && isIdent(p.query[p.pos]) {
+		p.pos++
+	}
+	return strings.ToLower(p.query[start:p.pos])
+}
+
+// keyword consumes the keyword kw when it comes next.
+func (p *parser) keyword(kw string) bool {
+	if p.peekKeyword(kw) {
+		p.skipSpace()
+		p.pos += len(kw)
+		return true
+	}
+	return false
+}
+
+func (p *parser) peekKeyword(kw string) bool {
+	p.skipSpace()
+	end := p.pos + len(kw)
+	if end > len(p.query) || !strings.EqualFold(p.query[p.pos:end], kw) {
+		return false
+	}
+	return end == len(p.query) || !isIdent(p.query[end])
+}
+
+func (p *parser) op() Op {
+	for _, op := range []Op{OpNe, OpLe, OpGe, OpEq, OpLt, OpGt, OpContains} {
+		if strings.HasPrefix(p.query[p.pos:], string(op)) {
+			p.pos += len(op)
+			return op
+		}
+	}
+	return ""
+}
+
+// value reads a bare value, which ends at a space or parenthesis, or a quoted
+// one, in which \" and \\ stand for " and \.
+func (p *parser) value() (string, error) {
+	if p.pos == len(p.query) || p.query[p.pos] != '"' {
+		start := p.pos
+		for p.pos < len(p.query) && !isSpace(p.query[p.pos]) && p.query[p.pos] != '(' && p.query[p.pos] != ')' {
+			p.pos++
+		}
+		return p.query[start:p.pos], nil
+	}
+	open := p.pos
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.query) {
+		c := p.query[p.pos]
+		p.pos++
+		switch {
+		case c == '"':
+			if b.Len() == 0 {
+				return "", p.errorf(open, "empty quoted value")
+			}
+			return b.String(), nil
+		case c == '\\' && p.pos < len(p.query) && (p.query[p.pos] == '"' || p.query[p.pos] == '\\'):
+			b.WriteByte(p.query[p.pos])
+			p.pos++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf(open, "unterminated quoted value")
+}
+
+func (p *parser) errorf(pos int, format string, args ...any) error {
+	return &SyntaxError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isIdent(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
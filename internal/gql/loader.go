@@ -0,0 +1,62 @@
+package gql
+
+import (
+	"slices"
+	"sync"
+	"todolist/internal/models"
+	"todolist/internal/services"
+)
+
+// taskLoader batches task lookups within one GraphQL request. Resolvers
+// register the projects they need and return thunks; the executor runs the
+// thunks only after every field at the same depth has been resolved, so the
+// first thunk loads all registered projects with a single service call.
+type taskLoader struct {
+	svc  *services.TaskService
+	user string
+
+	mu      sync.Mutex
+	pending []string
+	loaded  map[string][]models.Task
+}
+
+func newTaskLoader(svc *services.TaskService, user string) *taskLoader {
+	return &taskLoader{svc: svc, user: user, loaded: make(map[string][]models.Task)}
+}
+
+// load registers project and returns a thunk yielding its tasks.
+func (l *taskLoader) load(project string) func() ([]models.Task, error) {
+	l.mu.Lock()
+	if _, ok := l.loaded[project]; !ok && !slices.Contains(l.pending, project) {
+		l.pending = append(l.pending, project)
+	}
+	l.mu.Unlock()
+
+	return func() ([]models.Task, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if tasks, ok := l.loaded[project]; ok {
+			return tasks, nil
+		}
+		batch := l.pending
+		if !slices.Contains(batch, project) {
+			batch = append(batch, project)
+		}
+		l.pending = nil
+		tasks, err := l.svc.GetTasksInProjects(l.user, batch)
+		if err != nil {
+			return nil, err
+		}
+		for p, t := range tasks {
+			l.loaded[p] = t
+		}
+		return l.loaded[project], nil
+	}
+}
+
+// reset drops cached tasks after a mutation.
+func (l *taskLoader) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loaded = make(map[string][]models.Task)
+}
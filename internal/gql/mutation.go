@@ -0,0 +1,193 @@
+package gql
+
+import (
+	"errors"
+	"time"
+	"todolist/internal/models"
+	"todolist/internal/services"
+
+	"github.com/graphql-go/graphql"
+)
+
+// newMutation exposes the write operations of TaskService. Each returns the
+// undo token of the change, like the X-Undo-Token header of the HTTP API.
+func newMutation(svc *services.TaskService, taskType, projectType, changeType *graphql.Object) *graphql.Object {
+	resultType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "MutationResult",
+		Fields: graphql.Fields{
+			"undoToken": &graphql.Field{Type: graphql.String},
+		},
+	})
+	writeResultType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "WriteTaskResult",
+		Fields: graphql.Fields{
+			"task":      &graphql.Field{Type: graphql.NewNonNull(taskType)},
+			"undoToken": &graphql.Field{Type: graphql.String},
+		},
+	})
+	updateProjectResultType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "UpdateProjectResult",
+		Fields: graphql.Fields{
+			"project":   &graphql.Field{Type: graphql.NewNonNull(projectType)},
+			"undoToken": &graphql.Field{Type: graphql.String},
+		},
+	})
+	taskInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "TaskInput",
+		Description: "A task to create, or to update when id is set.",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"id":        &graphql.InputObjectFieldConfig{Type: graphql.ID},
+			"content":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"priority":  &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: 0},
+			"due":       &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+			"completed": &graphql.InputObjectFieldConfig{Type: graphql.Boolean, DefaultValue: false},
+		},
+	})
+
+	projectArgs := graphql.FieldConfigArgument{
+		"project": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+	}
+	taskArgs := graphql.FieldConfigArgument{
+		"project": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+		"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	}
+
+	// mutate wraps a service call returning an undo token. The loader cache is
+	// dropped so later fields see the change.
+	mutate := func(args graphql.FieldConfigArgument, call func(p graphql.ResolveParams, user string) (string, error)) *graphql.Field {
+		return &graphql.Field{
+			Type: graphql.NewNonNull(resultType),
+			Args: args,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				token, err := call(p, state(p).user)
+				if err != nil {
+					return nil, fieldError(err)
+				}
+				state(p).loader.reset()
+				return map[string]interface{}{"undoToken": token}, nil
+			},
+		}
+	}
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createProject": mutate(graphql.FieldConfigArgument{
+				"project": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"parent":  &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: "", Description: "Name or ID of the project to nest the new one in."},
+			}, func(p graphql.ResolveParams, user string) (string, error) {
+				_, token, err := svc.CreateProject(p.Context, user, p.Args["project"].(string), p.Args["parent"].(string))
+				return token, err
+			}),
+			"updateProject": &graphql.Field{
+				Type:        graphql.NewNonNull(updateProjectResultType),
+				Description: "Changes the given fields of a project; omitted ones are kept.",
+				Args: graphql.FieldConfigArgument{
+					"project":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"name":        &graphql.ArgumentConfig{Type: graphql.String},
+					"description": &graphql.ArgumentConfig{Type: graphql.String},
+					"color":       &graphql.ArgumentConfig{Type: graphql.String},
+					"archived":    &graphql.ArgumentConfig{Type: graphql.Boolean},
+					"parent":      &graphql.ArgumentConfig{Type: graphql.String, Description: "Name or ID of the new parent; empty moves the project to the top level."},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					var update models.ProjectUpdate
+					if v, ok := p.Args["name"].(string); ok {
+						update.Name = &v
+					}
+					if v, ok := p.Args["description"].(string); ok {
+						update.Description = &v
+					}
+					if v, ok := p.Args["color"].(string); ok {
+						update.Color = &v
+					}
+					if v, ok := p.Args["archived"].(bool); ok {
+						update.Archived = &v
+					}
+					if v, ok := p.Args["parent"].(string); ok {
+						update.Parent = &v
+					}
+					project, token, err := svc.UpdateProject(p.Context, state(p).user, p.Args["project"].(string), update)
+					if err != nil {
+						return nil, fieldError(err)
+					}
+					state(p).loader.reset()
+					return map[string]interface{}{"project": project, "undoToken": token}, nil
+				},
+			},
+			"removeProject": mutate(graphql.FieldConfigArgument{
+				"project":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"children": &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: services.ChildrenTrash, Description: "What happens to sub-projects: trash or lift."},
+			}, func(p graphql.ResolveParams, user string) (string, error) {
+				return svc.RemoveProject(p.Context, user, p.Args["project"].(string), p.Args["children"].(string))
+			}),
+			"restoreProject": mutate(projectArgs, func(p graphql.ResolveParams, user string) (string, error) {
+				return svc.RestoreProject(p.Context, user, p.Args["project"].(string))
+			}),
+			"completeTask": mutate(taskArgs, func(p graphql.ResolveParams, user string) (string, error) {
+				return svc.MarkTaskComplete(p.Context, user, p.Args["project"].(string), p.Args["id"].(string))
+			}),
+			"removeTask": mutate(taskArgs, func(p graphql.ResolveParams, user string) (string, error) {
+				return svc.RemoveTask(p.Context, user, p.Args["project"].(string), p.Args["id"].(string))
+			}),
+			"restoreTask": mutate(taskArgs, func(p graphql.ResolveParams, user string) (string, error) {
+				return svc.RestoreTask(p.Context, user, p.Args["project"].(string), p.Args["id"].(string))
+			}),
+			"writeTask": &graphql.Field{
+				Type: graphql.NewNonNull(writeResultType),
+				Args: graphql.FieldConfigArgument{
+					"project": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"task":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(taskInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					in := p.Args["task"].(map[string]interface{})
+					task := models.Task{Content: in["content"].(string)}
+					task.ID, _ = in["id"].(string)
+					task.Priority, _ = in["priority"].(int)
+					task.Due, _ = in["due"].(time.Time)
+					task.Completed, _ = in["completed"].(bool)
+					task, token, err := svc.WriteTask(p.Context, state(p).user, p.Args["project"].(string), task)
+					if err != nil {
+						return nil, fieldError(err)
+					}
+					state(p).loader.reset()
+					return map[string]interface{}{"task": task, "undoToken": token}, nil
+				},
+			},
+			"undo": &graphql.Field{
+				Type:        graphql.NewNonNull(changeType),
+				Description: "Reverts the mutation that returned token and returns the reverted change.",
+				Args: graphql.FieldConfigArgument{
+					"token": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					change, err := svc.Undo(p.Context, state(p).user, p.Args["token"].(string))
+					if err != nil {
+						return nil, fieldError(err)
+					}
+					state(p).loader.reset()
+					return change, nil
+				},
+			},
+		},
+	})
+}
+
+// validationError reports the rejected fields of a validation error in the
+// extensions member of the GraphQL error.
+type validationError struct {
+	*services.ValidationError
+}
+
+func (e validationError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "BAD_USER_INPUT", "fields": e.Fields}
+}
+
+// fieldError wraps validation errors so their fields reach the client.
+func fieldError(err error) error {
+	var verr *services.ValidationError
+	if errors.As(err, &verr) {
+		return validationError{verr}
+	}
+	return err
+}
@@ -0,0 +1,392 @@
+// Package gql implements the GraphQL API over TaskService. Project and task
+// fields are resolved through a per-request loader, so a query touching many
+// projects reads their tasks with one repository call.
+package gql
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"todolist/internal/models"
+	"todolist/internal/services"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Request is the standard GraphQL request body.
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// Executor runs GraphQL requests on behalf of authenticated users.
+type Executor struct {
+	schema graphql.Schema
+	svc    *services.TaskService
+}
+
+type stateKey struct{}
+
+// requestState is what resolvers need to know about the request.
+type requestState struct {
+	user   string
+	loader *taskLoader
+}
+
+func state(p graphql.ResolveParams) *requestState {
+	return p.Context.Value(stateKey{}).(*requestState)
+}
+
+func NewExecutor(svc *services.TaskService) (*Executor, error) {
+	schema, err := newSchema(svc)
+	if err != nil {
+		return nil, err
+	}
+	return &Executor{schema: schema, svc: svc}, nil
+}
+
+// Execute runs req as user. Errors are reported in the result.
+func (e *Executor) Execute(ctx context.Context, user string, req Request) *graphql.Result {
+	ctx = context.WithValue(ctx, stateKey{}, &requestState{user: user, loader: newTaskLoader(e.svc, user)})
+	return graphql.Do(graphql.Params{
+		Schema:         e.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+}
+
+var taskOrderEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "TaskOrder",
+	Values: graphql.EnumValueConfigMap{
+		"PRIORITY": &graphql.EnumValueConfig{Value: "priority", Description: "Highest priority first, then earliest due."},
+		"DUE":      &graphql.EnumValueConfig{Value: "due", Description: "Earliest due first, then highest priority."},
+	},
+})
+
+// filterArgs narrow a project's tasks; every argument is optional.
+func filterArgs() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		"completed":   &graphql.ArgumentConfig{Type: graphql.Boolean},
+		"maxPriority": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Only tasks with priority <= maxPriority (0 is highest)."},
+		"dueBefore":   &graphql.ArgumentConfig{Type: graphql.DateTime},
+		"dueAfter":    &graphql.ArgumentConfig{Type: graphql.DateTime},
+		"contains":    &graphql.ArgumentConfig{Type: graphql.String, Description: "Case-insensitive substring of the content."},
+	}
+}
+
+// listArgs are filterArgs plus ordering and a limit.
+func listArgs() graphql.FieldConfigArgument {
+	args := filterArgs()
+	args["orderBy"] = &graphql.ArgumentConfig{Type: taskOrderEnum, DefaultValue: "priority"}
+	args["limit"] = &graphql.ArgumentConfig{Type: graphql.Int}
+	return args
+}
+
+func newSchema(svc *services.TaskService) (graphql.Schema, error) {
+	taskType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Task",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"content":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"priority":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"updatedTime": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+			"completed":   &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"recurrence": &graphql.Field{
+				Type:        graphql.String,
+				Description: "How the task repeats, such as \"every 2 weeks\"; null when it does not.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					task := p.Source.(models.Task)
+					if task.Recurrence == nil {
+						return nil, nil
+					}
+					return task.Recurrence.String(), nil
+				},
+			},
+			"due": &graphql.Field{
+				Type:        graphql.DateTime,
+				Description: "Null when the task has no due date.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					task := p.Source.(models.Task)
+					if task.Due.IsZero() || task.Due.Equal(services.DefaultTimestamp) {
+						return nil, nil
+					}
+					return task.Due, nil
+				},
+			},
+		},
+	})
+
+	projectType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Project",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"description": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"color":       &graphql.Field{Type: graphql.String, Description: "Null when the project has no color.", Resolve: nullIfEmpty(func(p models.Project) string { return p.Color })},
+			"created":     &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+			"archived":    &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"tasks": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(taskType))),
+				Args: listArgs(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return projectTasks(p, p.Source.(models.Project).ID), nil
+				},
+			},
+			"taskCount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Args: filterArgs(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return countTasks(p, p.Source.(models.Project).ID, p.Args), nil
+				},
+			},
+			"openTaskCount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return countTasks(p, p.Source.(models.Project).ID, map[string]interface{}{"completed": false}), nil
+				},
+			},
+		},
+	})
+
+	projectType.AddFieldConfig("parentId", &graphql.Field{
+		Type:        graphql.ID,
+		Description: "The project this one is nested in; null at the top level.",
+		Resolve:     nullIfEmpty(func(p models.Project) string { return p.ParentID }),
+	})
+	projectType.AddFieldConfig("children", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(projectType))),
+		Description: "The projects nested directly in this one, archived ones included.",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			projects, err := svc.GetProjects(state(p).user, true)
+			if err != nil {
+				return nil, err
+			}
+			id := p.Source.(models.Project).ID
+			return slices.DeleteFunc(projects, func(project models.Project) bool { return project.ParentID != id }), nil
+		},
+	})
+	projectTotal := func(open bool) graphql.FieldResolveFn {
+		return func(p graphql.ResolveParams) (interface{}, error) {
+			tree, err := svc.GetProjectTree(state(p).user, p.Source.(models.Project).ID, true)
+			if err != nil || len(tree) == 0 {
+				return 0, err
+			}
+			if open {
+				return tree[0].TotalOpenTaskCount, nil
+			}
+			return tree[0].TotalTaskCount, nil
+		}
+	}
+	projectType.AddFieldConfig("totalTaskCount", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.Int),
+		Description: "Tasks in this project and every project nested below it.",
+		Resolve:     projectTotal(false),
+	})
+	projectType.AddFieldConfig("totalOpenTaskCount", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.Int),
+		Description: "Open tasks in this project and every project nested below it.",
+		Resolve:     projectTotal(true),
+	})
+
+	projectsField := &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(projectType))),
+		Args: graphql.FieldConfigArgument{
+			"names":    &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "Only these projects, archived or not."},
+			"archived": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false, Description: "Include archived projects."},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			names, filtered := p.Args["names"].([]interface{})
+			projects, err := svc.GetProjects(state(p).user, filtered || p.Args["archived"].(bool))
+			if err != nil {
+				return nil, err
+			}
+			if filtered {
+				projects = slices.DeleteFunc(projects, func(project models.Project) bool {
+					return !slices.Contains(names, interface{}(project.Name))
+				})
+			}
+			return projects, nil
+		},
+	}
+	projectField := &graphql.Field{
+		Type: projectType,
+		Args: graphql.FieldConfigArgument{
+			"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String), Description: "The project's name or ID."},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			project, err := svc.GetProject(state(p).user, p.Args["name"].(string))
+			if errors.Is(err, services.ErrProjectNotFound) {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, fieldError(err)
+			}
+			return project, nil
+		},
+	}
+
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"username": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return state(p).user, nil },
+			},
+			"projects": projectsField,
+			"project":  projectField,
+		},
+	})
+
+	fieldChangeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "FieldChange",
+		Fields: graphql.Fields{
+			"field": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"old":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"new":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	changeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Change",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"project":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"taskId":    &graphql.Field{Type: graphql.String, Description: "Null for project-level changes.", Resolve: nullIfEmpty(func(c models.TaskChange) string { return c.TaskID })},
+			"actor":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"action":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"time":      &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+			"requestId": &graphql.Field{Type: graphql.String, Resolve: nullIfEmpty(func(c models.TaskChange) string { return c.RequestID })},
+			"changes":   &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(fieldChangeType)))},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": &graphql.Field{
+				Type:    graphql.NewNonNull(userType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return state(p).user, nil },
+			},
+			"projects": projectsField,
+			"project":  projectField,
+			"tasks": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(taskType))),
+				Args: withArg(listArgs(), "project", &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return projectTasks(p, p.Args["project"].(string)), nil
+				},
+			},
+			"history": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(changeType))),
+				Args: graphql.FieldConfigArgument{
+					"project": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"taskId":  &graphql.ArgumentConfig{Type: graphql.String, Description: "Omit for the whole project."},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					project := p.Args["project"].(string)
+					var changes []models.TaskChange
+					var err error
+					if taskID, ok := p.Args["taskId"].(string); ok {
+						changes, err = svc.GetTaskHistory(state(p).user, project, taskID)
+					} else {
+						changes, err = svc.GetProjectHistory(state(p).user, project)
+					}
+					return changes, fieldError(err)
+				},
+			},
+		},
+	})
+
+	mutation := newMutation(svc, taskType, projectType, changeType)
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func nullIfEmpty[T any](get func(T) string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if v := get(p.Source.(T)); v != "" {
+			return v, nil
+		}
+		return nil, nil
+	}
+}
+
+func withArg(args graphql.FieldConfigArgument, name string, arg *graphql.ArgumentConfig) graphql.FieldConfigArgument {
+	args[name] = arg
+	return args
+}
+
+// projectTasks returns a thunk resolving to the project's tasks after the
+// filter, order and limit arguments are applied.
+func projectTasks(p graphql.ResolveParams, project string) func() (interface{}, error) {
+	thunk := state(p).loader.load(project)
+	args := p.Args
+	return func() (interface{}, error) {
+		tasks, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+		tasks = filterTasks(tasks, args)
+		orderTasks(tasks, args["orderBy"])
+		if limit, ok := args["limit"].(int); ok && limit >= 0 && limit < len(tasks) {
+			tasks = tasks[:limit]
+		}
+		return tasks, nil
+	}
+}
+
+func countTasks(p graphql.ResolveParams, project string, args map[string]interface{}) func() (interface{}, error) {
+	thunk := state(p).loader.load(project)
+	return func() (interface{}, error) {
+		tasks, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+		return len(filterTasks(tasks, args)), nil
+	}
+}
+
+func filterTasks(tasks []models.Task, args map[string]interface{}) []models.Task {
+	out := make([]models.Task, 0, len(tasks))
+	for _, t := range tasks {
+		if completed, ok := args["completed"].(bool); ok && t.Completed != completed {
+			continue
+		}
+		if maxPriority, ok := args["maxPriority"].(int); ok && t.Priority > maxPriority {
+			continue
+		}
+		if before, ok := args["dueBefore"].(time.Time); ok && !t.Due.Before(before) {
+			continue
+		}
+		if after, ok := args["dueAfter"].(time.Time); ok && !t.Due.After(after) {
+			continue
+		}
+		if contains, ok := args["contains"].(string); ok && !strings.Contains(strings.ToLower(t.Content), strings.ToLower(contains)) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func orderTasks(tasks []models.Task, orderBy interface{}) {
+	byDue := orderBy == "due"
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if byDue && !a.Due.Equal(b.Due) {
+			return a.Due.Before(b.Due)
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if !a.Due.Equal(b.Due) {
+			return a.Due.Before(b.Due)
+		}
+		return a.ID < b.ID
+	})
+}
@@ -0,0 +1,88 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+	"todolist/internal/models"
+	"todolist/internal/repository"
+	"todolist/internal/services"
+)
+
+// countingRepo counts the task reads that reach the repository.
+type countingRepo struct {
+	*repository.InMemTaskRepository
+
+	mu         sync.Mutex
+	batched    [][]string // projects of each ListTasksInProjects call
+	singleList int        // ListTasks calls
+}
+
+func (r *countingRepo) ListTasksInProjects(username string, projects []string) (map[string][]models.Task, error) {
+	r.mu.Lock()
+	r.batched = append(r.batched, projects)
+	r.mu.Unlock()
+	return r.InMemTaskRepository.ListTasksInProjects(username, projects)
+}
+
+func (r *countingRepo) ListTasks(username, project string) ([]models.Task, error) {
+	r.mu.Lock()
+	r.singleList++
+	r.mu.Unlock()
+	return r.InMemTaskRepository.ListTasks(username, project)
+}
+
+func TestProjectsLoadTasksInOneCall(t *testing.T) {
+	ctx := context.Background()
+	repo := &countingRepo{InMemTaskRepository: repository.NewInMemTaskRepository()}
+	users := repository.NewInMemUserRepository()
+	svc := services.NewTaskService(repo, repository.NewInMemHistoryRepository(0), repository.NewInMemTemplateRepository(),
+		repository.NewInMemSmartListRepository(), users, services.DefaultUndoWindow, services.DefaultLimits())
+	if err := users.AddUser(models.User{Username: "alice", Password: "secret1", Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"home", "work", "garden"} {
+		if _, _, err := svc.CreateProject(ctx, "alice", name, ""); err != nil {
+			t.Fatal(err)
+		}
+		for _, content := range []string{name + " 1", name + " 2"} {
+			if _, _, err := svc.WriteTask(ctx, "alice", name, models.Task{Content: content, Due: time.Now().Add(time.Hour)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	exec, err := NewExecutor(svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.batched, repo.singleList = nil, 0
+
+	result := exec.Execute(ctx, "alice", Request{Query: `{ projects { name taskCount tasks(orderBy: PRIORITY) { content } } }`})
+	if len(result.Errors) > 0 {
+		t.Fatalf("errors: %v", result.Errors)
+	}
+	var data struct {
+		Projects []struct {
+			Name      string
+			TaskCount int
+			Tasks     []struct{ Content string }
+		}
+	}
+	b, _ := json.Marshal(result.Data)
+	if err := json.Unmarshal(b, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Projects) != 3 {
+		t.Fatalf("projects = %s, want 3", b)
+	}
+	for _, p := range data.Projects {
+		if p.TaskCount != 2 || len(p.Tasks) != 2 {
+			t.Errorf("project %s = %+v, want 2 tasks", p.Name, p)
+		}
+	}
+	if len(repo.batched) != 1 || len(repo.batched[0]) != 3 || repo.singleList != 0 {
+		t.Errorf("ListTasksInProjects calls = %v, ListTasks calls = %d; want one call for 3 projects", repo.batched, repo.singleList)
+	}
+}
@@ -0,0 +1,108 @@
+package grpcserver
+
+import (
+	"errors"
+	"todolist/internal/models"
+	"todolist/internal/services"
+	"todolist/pkg/todopb"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toPBTask(t models.Task) *todopb.Task {
+	pb := &todopb.Task{
+		Id:          t.ID,
+		Content:     t.Content,
+		Priority:    int32(t.Priority),
+		UpdatedTime: timestamppb.New(t.UpdatedTime),
+		Completed:   t.Completed,
+	}
+	if !t.Due.IsZero() && !t.Due.Equal(services.DefaultTimestamp) {
+		pb.Due = timestamppb.New(t.Due)
+	}
+	return pb
+}
+
+func fromPBTask(pb *todopb.Task) models.Task {
+	t := models.Task{
+		ID:        pb.GetId(),
+		Content:   pb.GetContent(),
+		Priority:  int(pb.GetPriority()),
+		Completed: pb.GetCompleted(),
+	}
+	if pb.GetDue() != nil {
+		t.Due = pb.GetDue().AsTime()
+	}
+	return t
+}
+
+func toPBProject(p models.Project) *todopb.Project {
+	return &todopb.Project{
+		Id:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Color:       p.Color,
+		Created:     timestamppb.New(p.Created),
+		Archived:    p.Archived,
+		ParentId:    p.ParentID,
+	}
+}
+
+func toPBChange(c models.TaskChange) *todopb.TaskChange {
+	pb := &todopb.TaskChange{
+		Id:        c.ID,
+		Project:   c.Project,
+		TaskId:    c.TaskID,
+		Actor:     c.Actor,
+		Action:    c.Action,
+		Time:      timestamppb.New(c.Time),
+		RequestId: c.RequestID,
+	}
+	for _, fc := range c.Changes {
+		pb.Changes = append(pb.Changes, &todopb.FieldChange{Field: fc.Field, Old: fc.Old, New: fc.New})
+	}
+	return pb
+}
+
+// statusError maps service errors to gRPC status codes, mirroring the HTTP
+// status codes the handlers use. Validation errors carry their field
+// violations as BadRequest details.
+func statusError(err error) error {
+	var verr *services.ValidationError
+	if errors.As(err, &verr) {
+		br := &errdetails.BadRequest{}
+		for _, f := range verr.Fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message})
+		}
+		st, detailErr := status.New(codes.InvalidArgument, verr.Error()).WithDetails(br)
+		if detailErr != nil {
+			return status.Error(codes.InvalidArgument, verr.Error())
+		}
+		return st.Err()
+	}
+	switch {
+	case errors.Is(err, services.ErrTaskNotFound),
+		errors.Is(err, services.ErrProjectNotFound),
+		errors.Is(err, services.ErrNotInTrash),
+		errors.Is(err, services.ErrUndoNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, services.ErrUserExists),
+		errors.Is(err, services.ErrProjectExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, services.ErrInvalidCredentials):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, services.ErrProjectTrashed),
+		errors.Is(err, services.ErrUndoConflict),
+		errors.Is(err, services.ErrUserNotDeactivated),
+		errors.Is(err, services.ErrGracePeriodExpired),
+		errors.Is(err, services.ErrUndoExpired):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, services.ErrNotUndoable):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,137 @@
+// Package grpcserver exposes TaskService and UserService over gRPC, as
+// defined in proto/todolist/v1/todolist.proto.
+package grpcserver
+
+import (
+	"context"
+	"log"
+	"net"
+	"strings"
+	"todolist/internal/middleware"
+	"todolist/internal/services"
+	"todolist/pkg/todopb"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey is the metadata key carrying the request ID, the gRPC
+// counterpart of the X-Request-ID header.
+const requestIDKey = "x-request-id"
+
+// publicMethods can be called without credentials.
+var publicMethods = map[string]bool{
+	todopb.UserService_Register_FullMethodName:   true,
+	todopb.UserService_Reactivate_FullMethodName: true,
+}
+
+type userKey struct{}
+
+// Server is a gRPC server for the todo services.
+type Server struct {
+	srv *grpc.Server
+	// stopping is closed when the server shuts down, ending the
+	// subscription streams that would otherwise keep it waiting.
+	stopping chan struct{}
+}
+
+func NewServer(taskSvc *services.TaskService, userSvc *services.UserService, auth *middleware.AuthMiddleware) *Server {
+	s := &Server{stopping: make(chan struct{})}
+	s.srv = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryAuth(auth)),
+		grpc.ChainStreamInterceptor(streamAuth(auth)),
+	)
+	todopb.RegisterTaskServiceServer(s.srv, &taskServer{svc: taskSvc, stopping: s.stopping})
+	todopb.RegisterUserServiceServer(s.srv, &userServer{svc: userSvc})
+	return s
+}
+
+// Serve accepts connections on lis until the server is stopped.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.srv.Serve(lis)
+}
+
+// GracefulStop ends open subscriptions, stops accepting new calls and waits
+// for the running ones to finish.
+func (s *Server) GracefulStop() {
+	close(s.stopping)
+	s.srv.GracefulStop()
+}
+
+// Stop closes all connections immediately.
+func (s *Server) Stop() {
+	s.srv.Stop()
+}
+
+func unaryAuth(auth *middleware.AuthMiddleware) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, auth, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuth(auth *middleware.AuthMiddleware) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), auth, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate checks the "authorization" metadata with the same logic as the
+// HTTP middleware and attaches the user and request ID to the context.
+func authenticate(ctx context.Context, auth *middleware.AuthMiddleware, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestID := first(md, requestIDKey)
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+	ctx = services.ContextWithRequestID(ctx, requestID)
+
+	if publicMethods[method] {
+		log.Printf("gRPC call %s, request_id= '%s'", method, requestID)
+		return ctx, nil
+	}
+	authorization := first(md, "authorization")
+	if authorization == "" {
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata required")
+	}
+	username, err := auth.Check(authorization)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	log.Printf("gRPC call %s for user '%s', request_id= '%s'", method, username, requestID)
+	return context.WithValue(ctx, userKey{}, username), nil
+}
+
+// userFrom returns the authenticated user of the call.
+func userFrom(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
+
+func first(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return strings.TrimSpace(v[0])
+	}
+	return ""
+}
+
+// contextStream replaces the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,145 @@
+package grpcserver
+
+import (
+	"context"
+	"todolist/internal/models"
+	"todolist/internal/services"
+	"todolist/pkg/todopb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type taskServer struct {
+	todopb.UnimplementedTaskServiceServer
+	svc      *services.TaskService
+	stopping <-chan struct{}
+}
+
+func (s *taskServer) ListProjects(ctx context.Context, req *todopb.ListProjectsRequest) (*todopb.ListProjectsResponse, error) {
+	projects, err := s.svc.GetProjects(userFrom(ctx), req.GetIncludeArchived())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp := &todopb.ListProjectsResponse{}
+	for _, p := range projects {
+		resp.Projects = append(resp.Projects, p.Name)
+		resp.Details = append(resp.Details, toPBProject(p))
+	}
+	return resp, nil
+}
+
+func (s *taskServer) CreateProject(ctx context.Context, req *todopb.CreateProjectRequest) (*todopb.MutationResponse, error) {
+	_, token, err := s.svc.CreateProject(ctx, userFrom(ctx), req.GetProject(), req.GetParent())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &todopb.MutationResponse{UndoToken: token}, nil
+}
+
+func (s *taskServer) UpdateProject(ctx context.Context, req *todopb.UpdateProjectRequest) (*todopb.UpdateProjectResponse, error) {
+	update := models.ProjectUpdate{
+		Name:        req.Name,
+		Description: req.Description,
+		Color:       req.Color,
+		Archived:    req.Archived,
+		Parent:      req.Parent,
+	}
+	project, token, err := s.svc.UpdateProject(ctx, userFrom(ctx), req.GetProject(), update)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &todopb.UpdateProjectResponse{Project: toPBProject(project), UndoToken: token}, nil
+}
+
+func (s *taskServer) RemoveProject(ctx context.Context, req *todopb.RemoveProjectRequest) (*todopb.MutationResponse, error) {
+	token, err := s.svc.RemoveProject(ctx, userFrom(ctx), req.GetProject(), req.GetChildren())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &todopb.MutationResponse{UndoToken: token}, nil
+}
+
+func (s *taskServer) ListTasks(ctx context.Context, req *todopb.ListTasksRequest) (*todopb.ListTasksResponse, error) {
+	tasks, err := s.svc.GetTasks(userFrom(ctx), req.GetProject())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp := &todopb.ListTasksResponse{}
+	for _, t := range tasks {
+		resp.Tasks = append(resp.Tasks, toPBTask(t))
+	}
+	return resp, nil
+}
+
+func (s *taskServer) WriteTask(ctx context.Context, req *todopb.WriteTaskRequest) (*todopb.WriteTaskResponse, error) {
+	task := fromPBTask(req.GetTask())
+	task, token, err := s.svc.WriteTask(ctx, userFrom(ctx), req.GetProject(), task)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &todopb.WriteTaskResponse{Task: toPBTask(task), UndoToken: token}, nil
+}
+
+func (s *taskServer) CompleteTask(ctx context.Context, req *todopb.TaskRef) (*todopb.MutationResponse, error) {
+	token, err := s.svc.MarkTaskComplete(ctx, userFrom(ctx), req.GetProject(), req.GetTaskId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &todopb.MutationResponse{UndoToken: token}, nil
+}
+
+func (s *taskServer) RemoveTask(ctx context.Context, req *todopb.TaskRef) (*todopb.MutationResponse, error) {
+	token, err := s.svc.RemoveTask(ctx, userFrom(ctx), req.GetProject(), req.GetTaskId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &todopb.MutationResponse{UndoToken: token}, nil
+}
+
+func (s *taskServer) GetTaskHistory(ctx context.Context, req *todopb.TaskRef) (*todopb.GetTaskHistoryResponse, error) {
+	changes, err := s.svc.GetTaskHistory(userFrom(ctx), req.GetProject(), req.GetTaskId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp := &todopb.GetTaskHistoryResponse{}
+	for _, c := range changes {
+		resp.Changes = append(resp.Changes, toPBChange(c))
+	}
+	return resp, nil
+}
+
+func (s *taskServer) Undo(ctx context.Context, req *todopb.UndoRequest) (*todopb.UndoResponse, error) {
+	change, err := s.svc.Undo(ctx, userFrom(ctx), req.GetUndoToken())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &todopb.UndoResponse{Reverted: toPBChange(change)}, nil
+}
+
+func (s *taskServer) SubscribeTaskChanges(req *todopb.SubscribeTaskChangesRequest, stream todopb.TaskService_SubscribeTaskChangesServer) error {
+	ctx := stream.Context()
+	project := req.GetProject()
+	if project != "" {
+		if p, err := s.svc.GetProject(userFrom(ctx), project); err == nil {
+			project = p.ID
+		}
+	}
+	changes, cancel := s.svc.SubscribeChanges(userFrom(ctx))
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.stopping:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case change := <-changes:
+			if project != "" && change.Project != project {
+				continue
+			}
+			if err := stream.Send(toPBChange(change)); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package grpcserver
+
+import (
+	"context"
+	"todolist/internal/services"
+	"todolist/pkg/todopb"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type userServer struct {
+	todopb.UnimplementedUserServiceServer
+	svc *services.UserService
+}
+
+func (s *userServer) Register(ctx context.Context, req *todopb.RegisterRequest) (*todopb.RegisterResponse, error) {
+	if err := s.svc.RegisterUser(req.GetUsername(), req.GetPassword()); err != nil {
+		return nil, statusError(err)
+	}
+	return &todopb.RegisterResponse{}, nil
+}
+
+func (s *userServer) Deactivate(ctx context.Context, _ *todopb.DeactivateRequest) (*todopb.DeactivateResponse, error) {
+	purgeAfter, err := s.svc.DeactivateUser(userFrom(ctx))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &todopb.DeactivateResponse{PurgeAfter: timestamppb.New(purgeAfter)}, nil
+}
+
+func (s *userServer) Reactivate(ctx context.Context, req *todopb.ReactivateRequest) (*todopb.ReactivateResponse, error) {
+	if err := s.svc.ReactivateUser(req.GetUsername(), req.GetPassword()); err != nil {
+		return nil, statusError(err)
+	}
+	return &todopb.ReactivateResponse{}, nil
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"todolist/internal/services"
+)
+
+// TodayHttp lists the open tasks overdue or due today in time zone 'tz'.
+func (h *TaskHandler) TodayHttp(w http.ResponseWriter, r *http.Request) {
+	h.agenda(w, r, 1)
+}
+
+// UpcomingHttp lists the open tasks overdue or due in the next 'days' days,
+// today included, in time zone 'tz'.
+func (h *TaskHandler) UpcomingHttp(w http.ResponseWriter, r *http.Request) {
+	days := services.DefaultUpcomingDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid days", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+	h.agenda(w, r, days)
+}
+
+func (h *TaskHandler) agenda(w http.ResponseWriter, r *http.Request, days int) {
+	user, _, _ := r.BasicAuth()
+	tz := r.URL.Query().Get("tz")
+	archived := r.URL.Query().Get("archived") == "true"
+	log.Printf("Retrieving agenda of %d days for user '%s', URI = '%s', method = '%s'", days, user, r.RequestURI, r.Method)
+
+	agenda, err := h.svc.GetAgenda(user, tz, days, archived)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		http.Error(w, "Error retrieving agenda", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(agenda); err != nil {
+		http.Error(w, "Error serializing agenda", http.StatusInternalServerError)
+		return
+	}
+}
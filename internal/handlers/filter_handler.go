@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"todolist/internal/models"
+	"todolist/internal/services"
+)
+
+// FilterTasksHttp lists the tasks of all projects matching filter query 'q'.
+func (h *TaskHandler) FilterTasksHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	query := r.URL.Query().Get("q")
+	log.Printf("Filtering tasks of user '%s', URI = '%s', method = '%s'", user, r.RequestURI, r.Method)
+
+	tasks, err := h.svc.FilterTasks(user, query)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		http.Error(w, "Error filtering tasks", http.StatusInternalServerError)
+		return
+	}
+	writeProjectTasks(w, tasks)
+}
+
+// SaveSmartListHttp saves filter query 'q' as smart list 'name' and answers
+// with the smart list.
+func (h *TaskHandler) SaveSmartListHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	name := r.URL.Query().Get("name")
+	query := r.URL.Query().Get("q")
+	log.Printf("Saving smart list '%s' for user '%s', URI= '%s', method= '%s'", name, user, r.RequestURI, r.Method)
+
+	list, err := h.svc.SaveSmartList(user, name, query)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error saving smart list: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// GetSmartListsHttp lists the user's smart lists sorted by name.
+func (h *TaskHandler) GetSmartListsHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	log.Printf("Retrieving smart lists for user '%s', URI = '%s', method = '%s'", user, r.RequestURI, r.Method)
+	lists, err := h.svc.GetSmartLists(user)
+	if err != nil {
+		http.Error(w, "Error retrieving smart lists", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(lists); err != nil {
+		http.Error(w, "Error serializing smart lists", http.StatusInternalServerError)
+		return
+	}
+}
+
+// RunSmartListHttp lists the tasks currently matching smart list 'list'.
+func (h *TaskHandler) RunSmartListHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	name := r.URL.Query().Get("list")
+	log.Printf("Running smart list '%s' for user '%s', URI = '%s', method = '%s'", name, user, r.RequestURI, r.Method)
+
+	tasks, err := h.svc.RunSmartList(user, name)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrSmartListNotFound) {
+			http.Error(w, fmt.Sprintf("Smart list %s not found", name), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error running smart list: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writeProjectTasks(w, tasks)
+}
+
+// RemoveSmartListHttp deletes smart list 'list'.
+func (h *TaskHandler) RemoveSmartListHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	name := r.URL.Query().Get("list")
+	log.Printf("Removing smart list '%s' for user '%s', URI= '%s', method= '%s'", name, user, r.RequestURI, r.Method)
+	if err := h.svc.RemoveSmartList(user, name); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrSmartListNotFound) {
+			http.Error(w, fmt.Sprintf("Smart list %s not found", name), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error removing smart list: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "smart list: %s removed", name)
+}
+
+func writeProjectTasks(w http.ResponseWriter, tasks []models.ProjectTask) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(tasks); err != nil {
+		http.Error(w, "Error serializing tasks", http.StatusInternalServerError)
+		return
+	}
+}
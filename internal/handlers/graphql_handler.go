@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"todolist/internal/gql"
+)
+
+type GraphQLHandler struct {
+	exec *gql.Executor
+}
+
+func NewGraphQLHandler(exec *gql.Executor) *GraphQLHandler {
+	return &GraphQLHandler{exec: exec}
+}
+
+// Serve executes a GraphQL request posted as {"query", "variables",
+// "operationName"}. Query errors are reported in the "errors" member of a
+// 200 response, as GraphQL clients expect.
+func (h *GraphQLHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	var req gql.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query == "" {
+		http.Error(w, "Invalid GraphQL request body", http.StatusBadRequest)
+		return
+	}
+	log.Printf("GraphQL request for user '%s', operation= '%s', URI= '%s', method= '%s'", user, req.OperationName, r.RequestURI, r.Method)
+
+	result := h.exec.Execute(r.Context(), user, req)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, "Error serializing GraphQL result", http.StatusInternalServerError)
+	}
+}
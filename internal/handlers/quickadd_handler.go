@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"todolist/internal/services"
+)
+
+// ParseQuickAddHttp answers with what is recognized in quick-add string 'q',
+// without creating the task.
+func (h *TaskHandler) ParseQuickAddHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	text := r.URL.Query().Get("q")
+	log.Printf("Parsing quick add for user '%s', URI = '%s', method = '%s'", user, r.RequestURI, r.Method)
+
+	parsed, err := h.svc.ParseQuickAdd(user, text)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		http.Error(w, "Error parsing quick add", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(parsed)
+}
+
+// QuickAddHttp creates the task parsed from quick-add string 'q', in the
+// project it names or else in project 'pjt', and answers with the task and
+// what was recognized.
+func (h *TaskHandler) QuickAddHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	text := r.URL.Query().Get("q")
+	project := r.URL.Query().Get("pjt")
+	log.Printf("Quick adding task for user '%s', URI= '%s', method= '%s'", user, r.RequestURI, r.Method)
+
+	result, undoToken, err := h.svc.QuickAdd(r.Context(), user, text, project)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrProjectNotFound) {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error adding task: %v", err), http.StatusInternalServerError)
+		return
+	}
+	setUndoToken(w, undoToken)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"todolist/internal/services"
+)
+
+// SearchHttp searches the content of the user's tasks for 'q', in project
+// 'pjt' only when given, returning at most 'limit' ranked hits.
+func (h *TaskHandler) SearchHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	query := r.URL.Query().Get("q")
+	project := r.URL.Query().Get("pjt")
+	log.Printf("Searching tasks of user '%s', URI = '%s', method = '%s'", user, r.RequestURI, r.Method)
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	hits, err := h.svc.Search(user, query, project, limit)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrProjectNotFound) {
+			http.Error(w, fmt.Sprintf("Project %s not found", project), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error searching tasks", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(hits); err != nil {
+		http.Error(w, "Error serializing search results", http.StatusInternalServerError)
+		return
+	}
+}
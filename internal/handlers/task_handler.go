@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"mime"
 	"net/http"
+	"strings"
 	"todolist/internal/models"
 	"todolist/internal/services"
 )
@@ -24,64 +29,151 @@ func (h *TaskHandler) GetAllTasksFromPjtHttp(w http.ResponseWriter, r *http.Requ
 	log.Printf("Retrieving tasks for user '%s', URI = '%s', method = '%s', project = '%s'", user, r.RequestURI, r.Method, project)
 
 	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
 		http.Error(w, "Error retrieving tasks", http.StatusInternalServerError)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
-	if len(tasks) == 0 {
-		fmt.Fprintln(w, "No tasks found!")
-		return
-	}
-
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(tasks); err != nil {
 		http.Error(w, "Error serializing tasks", http.StatusInternalServerError)
 		return
 	}
 }
 
+// GetAllProjectsHttp lists the user's projects sorted by name, including
+// archived ones only when 'archived' is true.
 func (h *TaskHandler) GetAllProjectsHttp(w http.ResponseWriter, r *http.Request) {
 	user, _, _ := r.BasicAuth()
 	log.Printf("Retrieving projects for user '%s', URI = '%s', method = '%s'", user, r.RequestURI, r.Method)
-	projects, err := h.svc.GetProjects(user)
+	archived := r.URL.Query().Get("archived") == "true"
+	projects, err := h.svc.GetProjects(user, archived)
 	if err != nil {
 		http.Error(w, "Error retrieving projects", http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if len(projects) == 0 {
-		fmt.Fprintln(w, "No projects found!")
+	if err := json.NewEncoder(w).Encode(projects); err != nil {
+		http.Error(w, "Error serializing projects", http.StatusInternalServerError)
 		return
 	}
-
-	for _, project := range projects {
-		fmt.Fprintf(w, "%s \n", project)
-	}
 }
 
 func (h *TaskHandler) CreateProjectHttp(w http.ResponseWriter, r *http.Request) {
 	user, _, _ := r.BasicAuth()
 	project := r.URL.Query().Get("pjt")
-	if project == "" {
-		http.Error(w, "Project query parameter 'pjt' is required", http.StatusBadRequest)
-		return
-	}
+	parent := r.URL.Query().Get("parent")
 	log.Printf("Creating project '%s' for user '%s', URI= '%s', method= '%s'", project, user, r.RequestURI, r.Method)
 
-	if err := h.svc.CreateProject(user, project); err != nil {
+	created, undoToken, err := h.svc.CreateProject(r.Context(), user, project, parent)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrProjectNotFound) {
+			http.Error(w, fmt.Sprintf("Parent project %s not found", parent), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, services.ErrProjectTrashed) {
+			http.Error(w, fmt.Sprintf("Project '%s' is in the trash, restore or purge it first", project), http.StatusConflict)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Error creating project: %v", err), http.StatusInternalServerError)
 		return
 	}
+	setUndoToken(w, undoToken)
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "Project '%s' created successfully", project)
+	json.NewEncoder(w).Encode(created)
 }
 
-func (h *TaskHandler) WriteTaskHttp(w http.ResponseWriter, r *http.Request) {
+// UpdateProjectHttp changes the fields of project 'pjt' present in the JSON
+// body and answers with the updated project.
+func (h *TaskHandler) UpdateProjectHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	project := r.URL.Query().Get("pjt")
+	var update models.ProjectUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	log.Printf("Updating project '%s' for user '%s', URI= '%s', method= '%s'", project, user, r.RequestURI, r.Method)
+
+	updated, undoToken, err := h.svc.UpdateProject(r.Context(), user, project, update)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrProjectNotFound):
+			http.Error(w, fmt.Sprintf("Project %s not found", project), http.StatusNotFound)
+		case errors.Is(err, services.ErrProjectExists):
+			http.Error(w, fmt.Sprintf("A project named '%s' already exists", *update.Name), http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Error updating project: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+	setUndoToken(w, undoToken)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// MoveProjectHttp nests project 'pjt' in project 'parent', or moves it to
+// the top level when 'parent' is empty, and answers with the moved project.
+func (h *TaskHandler) MoveProjectHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	project := r.URL.Query().Get("pjt")
+	parent := r.URL.Query().Get("parent")
+	log.Printf("Moving project '%s' under '%s' for user '%s', URI= '%s', method= '%s'", project, parent, user, r.RequestURI, r.Method)
+
+	moved, undoToken, err := h.svc.UpdateProject(r.Context(), user, project, models.ProjectUpdate{Parent: &parent})
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrProjectNotFound) {
+			http.Error(w, fmt.Sprintf("Project %s not found", project), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error moving project: %v", err), http.StatusInternalServerError)
+		return
+	}
+	setUndoToken(w, undoToken)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(moved)
+}
+
+// GetProjectTreeHttp lists the user's projects as a tree with task counts,
+// only the tree below project 'pjt' when given. Archived projects are
+// included only when 'archived' is true.
+func (h *TaskHandler) GetProjectTreeHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
 	project := r.URL.Query().Get("pjt")
-	if project == "" {
-		http.Error(w, "Project query parameter 'pjt' is required", http.StatusBadRequest)
+	log.Printf("Retrieving project tree for user '%s', URI = '%s', method = '%s'", user, r.RequestURI, r.Method)
+	archived := r.URL.Query().Get("archived") == "true"
+	tree, err := h.svc.GetProjectTree(user, project, archived)
+	if err != nil {
+		if errors.Is(err, services.ErrProjectNotFound) {
+			http.Error(w, fmt.Sprintf("Project %s not found", project), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error retrieving projects", http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(tree); err != nil {
+		http.Error(w, "Error serializing projects", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *TaskHandler) WriteTaskHttp(w http.ResponseWriter, r *http.Request) {
+	project := r.URL.Query().Get("pjt")
 	var task models.Task
 	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
 		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
@@ -89,38 +181,159 @@ func (h *TaskHandler) WriteTaskHttp(w http.ResponseWriter, r *http.Request) {
 	}
 	log.Printf("Writing task for project '%s', URI= '%s', method= '%s', task= '%s'", project, r.RequestURI, r.Method, task)
 
-	if valid, err := h.validTask(task); !valid {
-		http.Error(w, fmt.Sprintf("Invalid task: %v", err), http.StatusBadRequest)
-		return
-	}
 	user, _, _ := r.BasicAuth()
 
-	task, err := h.svc.WriteTask(user, project, task)
+	task, undoToken, err := h.svc.WriteTask(r.Context(), user, project, task)
 	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Error writing task: %v", err), http.StatusInternalServerError)
 		return
 	}
-	// Return success message
+	setUndoToken(w, undoToken)
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "Write task with key: %s at: %v", task.ID, task.UpdatedTime)
+	json.NewEncoder(w).Encode(task)
 }
 
-func (h *TaskHandler) CompleteTaskHttp(w http.ResponseWriter, r *http.Request) {
-	key := r.URL.Query().Get("key")
+// PatchTaskHttp partially updates task 'key' of project 'pjt'. The body is a
+// JSON Merge Patch or, with Content-Type application/json-patch+json, a JSON
+// Patch; plain application/json is read as a merge patch.
+func (h *TaskHandler) PatchTaskHttp(w http.ResponseWriter, r *http.Request) {
 	user, _, _ := r.BasicAuth()
 	project := r.URL.Query().Get("pjt")
-	if project == "" {
-		http.Error(w, "Project query parameter 'pjt' is required", http.StatusBadRequest)
+	key := r.URL.Query().Get("key")
+	log.Printf("Patching task '%s' for project '%s', URI= '%s', method= '%s'", key, project, r.RequestURI, r.Method)
+
+	format, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || format == "application/json" {
+		format = services.MergePatch
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
-	if key == "" {
-		http.Error(w, "Key query parameter 'key' is required", http.StatusBadRequest)
+
+	task, undoToken, err := h.svc.PatchTask(r.Context(), user, project, key, format, patch)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrTaskNotFound):
+			http.Error(w, fmt.Sprintf("Task in project %s with key %s not found", project, key), http.StatusNotFound)
+		case errors.Is(err, services.ErrInvalidPatch):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrPatchTestFailed):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, services.ErrUnsupportedPatch):
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		default:
+			http.Error(w, fmt.Sprintf("Error patching task: %v", err), http.StatusInternalServerError)
+		}
 		return
 	}
+	setUndoToken(w, undoToken)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// BatchRequest is the body of /batch.
+type BatchRequest struct {
+	Atomic     bool               `json:"atomic"`
+	Operations []services.BatchOp `json:"operations"`
+}
+
+// BatchResponse lists the outcome of every operation of a batch.
+type BatchResponse struct {
+	Results []services.BatchResult `json:"results"`
+}
+
+// BatchHttp runs several task operations in one request. An atomic batch that
+// fails is answered with 422 and applies nothing; otherwise the status is 200
+// and each result tells whether its operation succeeded.
+func (h *TaskHandler) BatchHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	log.Printf("Running batch of %d operations (atomic= %t), URI= '%s', method= '%s'", len(req.Operations), req.Atomic, r.RequestURI, r.Method)
+
+	results, err := h.svc.Batch(r.Context(), user, req.Operations, req.Atomic)
+	if err != nil && !errors.Is(err, services.ErrBatchAborted) {
+		if writeValidationError(w, err) {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error running batch: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(BatchResponse{Results: results})
+}
+
+// MoveTaskHttp moves task 'key' from project 'pjt' to project 'to', keeping
+// its ID.
+func (h *TaskHandler) MoveTaskHttp(w http.ResponseWriter, r *http.Request) {
+	h.transfer(w, r, "Moving", h.svc.MoveTask, http.StatusOK)
+}
+
+// CopyTaskHttp copies task 'key' of project 'pjt' into project 'to' under a
+// new ID.
+func (h *TaskHandler) CopyTaskHttp(w http.ResponseWriter, r *http.Request) {
+	h.transfer(w, r, "Copying", h.svc.CopyTask, http.StatusCreated)
+}
+
+func (h *TaskHandler) transfer(w http.ResponseWriter, r *http.Request, verb string,
+	fn func(ctx context.Context, user, from, to, taskID string) (models.Task, string, error), status int) {
+	user, _, _ := r.BasicAuth()
+	project := r.URL.Query().Get("pjt")
+	key := r.URL.Query().Get("key")
+	to := r.URL.Query().Get("to")
+	log.Printf("%s task '%s' from project '%s' to '%s', URI= '%s', method= '%s'", verb, key, project, to, r.RequestURI, r.Method)
+
+	task, undoToken, err := fn(r.Context(), user, project, to, key)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrTaskNotFound):
+			http.Error(w, fmt.Sprintf("Task in project %s with key %s not found", project, key), http.StatusNotFound)
+		case errors.Is(err, services.ErrProjectNotFound):
+			http.Error(w, fmt.Sprintf("Project %s not found", to), http.StatusNotFound)
+		case errors.Is(err, services.ErrTaskExists):
+			http.Error(w, fmt.Sprintf("Project %s already has a task with key %s", to, key), http.StatusConflict)
+		case errors.Is(err, services.ErrTaskChanged):
+			http.Error(w, fmt.Sprintf("Task %s changed during the request, try again", key), http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Error %s task: %v", strings.ToLower(verb), err), http.StatusInternalServerError)
+		}
+		return
+	}
+	setUndoToken(w, undoToken)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(task)
+}
+
+func (h *TaskHandler) CompleteTaskHttp(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	user, _, _ := r.BasicAuth()
+	project := r.URL.Query().Get("pjt")
 	log.Printf("Completing task '%s' for project '%s', URI= '%s', method= '%s'", key, project, r.RequestURI, r.Method)
 
-	err := h.svc.MarkTaskComplete(user, project, key)
+	undoToken, err := h.svc.MarkTaskComplete(r.Context(), user, project, key)
 	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
 		if err == services.ErrTaskNotFound {
 			http.Error(w, fmt.Sprintf("Task in project %s with key %s not found", project, key), http.StatusNotFound)
 			return
@@ -128,6 +341,7 @@ func (h *TaskHandler) CompleteTaskHttp(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Error marking task as complete: %v", err), http.StatusInternalServerError)
 		return
 	}
+	setUndoToken(w, undoToken)
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, "task: %s completed", key)
 }
@@ -136,17 +350,12 @@ func (h *TaskHandler) RemoveTaskHttp(w http.ResponseWriter, r *http.Request) {
 	key := r.URL.Query().Get("key")
 	user, _, _ := r.BasicAuth()
 	project := r.URL.Query().Get("pjt")
-	if project == "" {
-		http.Error(w, "Project query parameter 'pjt' is required", http.StatusBadRequest)
-		return
-	}
-	if key == "" {
-		http.Error(w, "Key query parameter 'key' is required", http.StatusBadRequest)
-		return
-	}
 	log.Printf("Removing task '%s' for project '%s', URI= '%s', method= '%s'", key, project, r.RequestURI, r.Method)
-	err := h.svc.RemoveTask(user, project, key)
+	undoToken, err := h.svc.RemoveTask(r.Context(), user, project, key)
 	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
 		if err == services.ErrTaskNotFound {
 			http.Error(w, fmt.Sprintf("Task in project %s with key %s not found", project, key), http.StatusNotFound)
 			return
@@ -154,33 +363,209 @@ func (h *TaskHandler) RemoveTaskHttp(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Error removing task: %v", err), http.StatusInternalServerError)
 		return
 	}
+	setUndoToken(w, undoToken)
 	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "task: %s removed", key)
+	fmt.Fprintf(w, "task: %s moved to trash", key)
 }
 
+// RemoveProjectHttp moves project 'pjt' to the trash. 'children' says what
+// happens to its sub-projects: "trash" (the default) or "lift".
 func (h *TaskHandler) RemoveProjectHttp(w http.ResponseWriter, r *http.Request) {
 	project := r.URL.Query().Get("pjt")
+	children := r.URL.Query().Get("children")
 	user, _, _ := r.BasicAuth()
-	if project == "" {
-		http.Error(w, "Project query parameter 'pjt' is required", http.StatusBadRequest)
-		return
-	}
 	log.Printf("Removing project '%s' for user '%s', URI= '%s', method= '%s'", project, user, r.RequestURI, r.Method)
-	err := h.svc.RemoveProject(user, project)
+	undoToken, err := h.svc.RemoveProject(r.Context(), user, project, children)
 	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrProjectNotFound) {
+			http.Error(w, fmt.Sprintf("Project %s not found", project), http.StatusNotFound)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Error removing project: %v", err), http.StatusInternalServerError)
 		return
 	}
+	setUndoToken(w, undoToken)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "project: %s moved to trash", project)
+}
+
+func (h *TaskHandler) GetTrashHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	log.Printf("Retrieving trash for user '%s', URI = '%s', method = '%s'", user, r.RequestURI, r.Method)
+	items, err := h.svc.GetTrash(user)
+	if err != nil {
+		http.Error(w, "Error retrieving trash", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(items); err != nil {
+		http.Error(w, "Error serializing trash", http.StatusInternalServerError)
+		return
+	}
+}
+
+// RestoreTrashHttp restores the task 'key' of project 'pjt', or the whole
+// project when 'key' is omitted.
+func (h *TaskHandler) RestoreTrashHttp(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	user, _, _ := r.BasicAuth()
+	project := r.URL.Query().Get("pjt")
+	log.Printf("Restoring '%s' in project '%s' for user '%s', URI= '%s', method= '%s'", key, project, user, r.RequestURI, r.Method)
+
+	var undoToken string
+	var err error
+	if key == "" {
+		undoToken, err = h.svc.RestoreProject(r.Context(), user, project)
+	} else {
+		undoToken, err = h.svc.RestoreTask(r.Context(), user, project, key)
+	}
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrNotInTrash):
+			http.Error(w, "Item not found in trash", http.StatusNotFound)
+		case errors.Is(err, services.ErrProjectTrashed) && key == "":
+			http.Error(w, fmt.Sprintf("The parent of project %s is in the trash, restore it first", project), http.StatusConflict)
+		case errors.Is(err, services.ErrProjectTrashed):
+			http.Error(w, fmt.Sprintf("Project %s is in the trash, restore it first", project), http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Error restoring from trash: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+	setUndoToken(w, undoToken)
+	w.WriteHeader(http.StatusOK)
+	if key == "" {
+		fmt.Fprintf(w, "project: %s restored", project)
+		return
+	}
+	fmt.Fprintf(w, "task: %s restored", key)
+}
+
+// PurgeTrashHttp permanently deletes the trashed task 'key' of project 'pjt',
+// the trashed project 'pjt' when 'key' is omitted, or the whole trash when
+// neither is given.
+func (h *TaskHandler) PurgeTrashHttp(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	user, _, _ := r.BasicAuth()
+	project := r.URL.Query().Get("pjt")
+	log.Printf("Purging '%s' in project '%s' for user '%s', URI= '%s', method= '%s'", key, project, user, r.RequestURI, r.Method)
+
+	if project == "" && key == "" {
+		purged, err := h.svc.EmptyTrash(r.Context(), user)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error emptying trash: %v", err), http.StatusInternalServerError)
+			return
+		}
+		writePurged(w, purged)
+		return
+	}
+
+	var err error
+	if key == "" {
+		err = h.svc.PurgeProject(r.Context(), user, project)
+	} else {
+		err = h.svc.PurgeTask(r.Context(), user, project, key)
+	}
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrNotInTrash) {
+			http.Error(w, "Item not found in trash", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error purging from trash: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writePurged(w, 1)
+}
+
+// PurgeResult is the body of /purgeTrash.
+type PurgeResult struct {
+	Purged int `json:"purged"` // the number of trash items deleted
+}
+
+func writePurged(w http.ResponseWriter, purged int) {
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "project: %s removed", project)
+	json.NewEncoder(w).Encode(PurgeResult{Purged: purged})
 }
 
-func (h *TaskHandler) validTask(task models.Task) (bool, error) {
-	if task.Content == "" {
-		return false, fmt.Errorf("task content cannot be empty")
+// GetHistoryHttp renders the change history of task 'key' in project 'pjt',
+// or of the whole project when 'key' is omitted.
+func (h *TaskHandler) GetHistoryHttp(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	user, _, _ := r.BasicAuth()
+	project := r.URL.Query().Get("pjt")
+	log.Printf("Retrieving history of '%s' in project '%s' for user '%s', URI= '%s', method= '%s'", key, project, user, r.RequestURI, r.Method)
+
+	var changes []models.TaskChange
+	var err error
+	if key == "" {
+		changes, err = h.svc.GetProjectHistory(user, project)
+	} else {
+		changes, err = h.svc.GetTaskHistory(user, project, key)
+	}
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		http.Error(w, "Error retrieving history", http.StatusInternalServerError)
+		return
 	}
-	if task.Priority < 0 || task.Priority > 10 {
-		return false, fmt.Errorf("task priority must be between 0 and 10")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(changes); err != nil {
+		http.Error(w, "Error serializing history", http.StatusInternalServerError)
+		return
+	}
+}
+
+// UndoHttp reverts the mutation identified by the 'token' query parameter,
+// the value of the X-Undo-Token header returned by that mutation.
+func (h *TaskHandler) UndoHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	token := r.URL.Query().Get("token")
+	log.Printf("Undoing '%s' for user '%s', URI= '%s', method= '%s'", token, user, r.RequestURI, r.Method)
+
+	change, err := h.svc.Undo(r.Context(), user, token)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrUndoNotFound):
+			http.Error(w, fmt.Sprintf("Undo token %s not found", token), http.StatusNotFound)
+		case errors.Is(err, services.ErrUndoExpired):
+			http.Error(w, fmt.Sprintf("Undo token %s has expired", token), http.StatusGone)
+		case errors.Is(err, services.ErrUndoConflict), errors.Is(err, services.ErrProjectTrashed):
+			http.Error(w, fmt.Sprintf("Cannot undo %s: %v", token, err), http.StatusConflict)
+		case errors.Is(err, services.ErrNotUndoable):
+			http.Error(w, fmt.Sprintf("Cannot undo %s: %v", token, err), http.StatusBadRequest)
+		default:
+			http.Error(w, fmt.Sprintf("Error undoing %s: %v", token, err), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if change.TaskID == "" {
+		fmt.Fprintf(w, "undo: %s of project %s reverted", change.Action, change.Project)
+		return
+	}
+	fmt.Fprintf(w, "undo: %s of task %s reverted", change.Action, change.TaskID)
+}
+
+// setUndoToken exposes the token of a successful mutation to the client. It
+// must be called before the status is written.
+func setUndoToken(w http.ResponseWriter, token string) {
+	if token != "" {
+		w.Header().Set("X-Undo-Token", token)
 	}
-	return true, nil
 }
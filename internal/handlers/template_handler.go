@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"todolist/internal/services"
+)
+
+// parseAnchor reads the 'anchor' query parameter, an RFC 3339 time or a plain
+// date (midnight in loc). It is the zero time, meaning now, when omitted.
+func parseAnchor(r *http.Request, loc *time.Location) (time.Time, error) {
+	v := r.URL.Query().Get("anchor")
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, v, loc)
+}
+
+// SaveTemplateHttp saves project 'pjt' as a template named 'name', with due
+// dates relative to 'anchor', and answers with the template.
+func (h *TaskHandler) SaveTemplateHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	project := r.URL.Query().Get("pjt")
+	name := r.URL.Query().Get("name")
+	log.Printf("Saving project '%s' as template '%s' for user '%s', URI= '%s', method= '%s'", project, name, user, r.RequestURI, r.Method)
+	anchor, err := parseAnchor(r, h.svc.Location(user))
+	if err != nil {
+		http.Error(w, "Invalid anchor, use YYYY-MM-DD or RFC 3339", http.StatusBadRequest)
+		return
+	}
+
+	template, err := h.svc.SaveTemplate(user, project, name, anchor)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrProjectNotFound):
+			http.Error(w, fmt.Sprintf("Project %s not found", project), http.StatusNotFound)
+		case errors.Is(err, services.ErrTemplateExists):
+			http.Error(w, "A template with that name already exists", http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Error saving template: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// GetTemplatesHttp lists the user's templates sorted by name.
+func (h *TaskHandler) GetTemplatesHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	log.Printf("Retrieving templates for user '%s', URI = '%s', method = '%s'", user, r.RequestURI, r.Method)
+	templates, err := h.svc.GetTemplates(user)
+	if err != nil {
+		http.Error(w, "Error retrieving templates", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(templates); err != nil {
+		http.Error(w, "Error serializing templates", http.StatusInternalServerError)
+		return
+	}
+}
+
+// InstantiateTemplateHttp creates project 'name' from template 'template',
+// nested in 'parent' when given, with due dates relative to 'anchor'.
+func (h *TaskHandler) InstantiateTemplateHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	template := r.URL.Query().Get("template")
+	name := r.URL.Query().Get("name")
+	parent := r.URL.Query().Get("parent")
+	log.Printf("Creating project '%s' from template '%s' for user '%s', URI= '%s', method= '%s'", name, template, user, r.RequestURI, r.Method)
+	anchor, err := parseAnchor(r, h.svc.Location(user))
+	if err != nil {
+		http.Error(w, "Invalid anchor, use YYYY-MM-DD or RFC 3339", http.StatusBadRequest)
+		return
+	}
+
+	project, err := h.svc.InstantiateTemplate(r.Context(), user, template, name, parent, anchor)
+	if err != nil {
+		writeCopyError(w, err, name)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+// CloneProjectHttp copies project 'pjt' with all of its tasks into a new
+// project 'name'.
+func (h *TaskHandler) CloneProjectHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	project := r.URL.Query().Get("pjt")
+	name := r.URL.Query().Get("name")
+	log.Printf("Cloning project '%s' to '%s' for user '%s', URI= '%s', method= '%s'", project, name, user, r.RequestURI, r.Method)
+
+	clone, err := h.svc.CloneProject(r.Context(), user, project, name)
+	if err != nil {
+		writeCopyError(w, err, name)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clone)
+}
+
+// writeCopyError answers a failed template instantiation or clone.
+func writeCopyError(w http.ResponseWriter, err error, name string) {
+	if writeValidationError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrTemplateNotFound):
+		http.Error(w, "Template not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrProjectNotFound):
+		http.Error(w, "Project not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrProjectExists):
+		http.Error(w, fmt.Sprintf("A project named '%s' already exists", name), http.StatusConflict)
+	case errors.Is(err, services.ErrProjectTrashed):
+		http.Error(w, fmt.Sprintf("Project '%s' is in the trash, restore or purge it first", name), http.StatusConflict)
+	default:
+		http.Error(w, fmt.Sprintf("Error creating project: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// RemoveTemplateHttp deletes template 'template'.
+func (h *TaskHandler) RemoveTemplateHttp(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	template := r.URL.Query().Get("template")
+	log.Printf("Removing template '%s' for user '%s', URI= '%s', method= '%s'", template, user, r.RequestURI, r.Method)
+	if err := h.svc.RemoveTemplate(user, template); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrTemplateNotFound) {
+			http.Error(w, fmt.Sprintf("Template %s not found", template), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error removing template: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "template: %s removed", template)
+}
@@ -2,8 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
+	"todolist/internal/models"
 	"todolist/internal/services"
 )
 
@@ -19,24 +24,28 @@ func NewUserHandler(userSvc *services.UserService, taskSvc *services.TaskService
 	}
 }
 
-func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-	}
+// RegisterRequest is the body of /register.
+type RegisterRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
 
+func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if req.Username == "" || req.Password == "" {
-		http.Error(w, "Username and password required", http.StatusBadRequest)
-		return
-	}
-
 	if err := h.userSvc.RegisterUser(req.Username, req.Password); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrUserExists) {
+			http.Error(w, fmt.Sprintf("User %s already exists", req.Username), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	log.Printf("User %s registered successfully", req.Username)
@@ -46,6 +55,59 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// LoginResponse is the answer of /login.
+type LoginResponse struct {
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+// Login issues a bearer token for the Basic credentials of the request.
+func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="Todo App"`)
+		http.Error(w, "Unauthorized: Basic auth required", http.StatusUnauthorized)
+		return
+	}
+
+	token, expires, err := h.userSvc.Login(username, password)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Todo App"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("User %s logged in, token expires %s", username, expires.Format(time.RFC3339))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(LoginResponse{Token: token, Expires: expires})
+}
+
+// Logout revokes the bearer token the request carries.
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		http.Error(w, "Unauthorized: Bearer token required", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.userSvc.Logout(strings.TrimSpace(token)); err != nil {
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Token revoked",
+	})
+}
+
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	username, _, ok := r.BasicAuth()
 	if !ok {
@@ -53,19 +115,80 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.userSvc.DeactivateUser(username, h.taskSvc)
+	purgeAfter, err := h.userSvc.DeactivateUser(username)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	errClearAllTasks := h.taskSvc.RemoveUserTasks(username)
-	if errClearAllTasks != nil {
-		http.Error(w, errClearAllTasks.Error(), http.StatusInternalServerError)
+	log.Printf("User %s deactivated, purge scheduled after %s", username, purgeAfter.Format(time.RFC3339))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":    "User deactivated successfully",
+		"purgeAfter": purgeAfter.Format(time.RFC3339),
+	})
+}
+
+func (h *UserHandler) Reactivate(w http.ResponseWriter, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="Todo App"`)
+		http.Error(w, "Unauthorized: Basic auth required", http.StatusUnauthorized)
+		return
+	}
+
+	err := h.userSvc.ReactivateUser(username, password)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		case errors.Is(err, services.ErrUserNotDeactivated):
+			http.Error(w, fmt.Sprintf("User %s is not deactivated", username), http.StatusConflict)
+		case errors.Is(err, services.ErrGracePeriodExpired):
+			http.Error(w, fmt.Sprintf("User %s can no longer be reactivated", username), http.StatusGone)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
-	log.Printf("User %s deleted successfully", username)
+	log.Printf("User %s reactivated successfully", username)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "User deleted successfully",
+		"message": "User reactivated successfully",
 	})
 }
+
+// GetSettings answers with the user's settings, defaults included.
+func (h *UserHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	username, _, _ := r.BasicAuth()
+	log.Printf("Retrieving settings for user '%s', URI = '%s', method = '%s'", username, r.RequestURI, r.Method)
+	settings, err := h.userSvc.GetSettings(username)
+	if err != nil {
+		http.Error(w, "Error retrieving settings", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// UpdateSettings changes the settings present in the JSON body and answers
+// with the settings now in effect.
+func (h *UserHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	username, _, _ := r.BasicAuth()
+	var update models.SettingsUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	log.Printf("Updating settings for user '%s', URI= '%s', method= '%s'", username, r.RequestURI, r.Method)
+
+	settings, err := h.userSvc.UpdateSettings(username, update)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error updating settings: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
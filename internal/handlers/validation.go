@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"todolist/internal/services"
+)
+
+// writeValidationError answers 400 with the field violations as JSON when err
+// is a *services.ValidationError, and reports whether it did.
+func writeValidationError(w http.ResponseWriter, err error) bool {
+	var verr *services.ValidationError
+	if !errors.As(err, &verr) {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(verr)
+	return true
+}
@@ -1,11 +1,17 @@
 package middleware
 
 import (
+	"encoding/base64"
+	"errors"
 	"log"
 	"net/http"
+	"strings"
 	"todolist/internal/services"
 )
 
+// ErrUnauthenticated is returned by Check when credentials are missing or wrong.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
 type AuthMiddleware struct {
 	userService *services.UserService
 }
@@ -18,21 +24,71 @@ func NewAuthMiddleware(userService *services.UserService) *AuthMiddleware {
 
 func (m *AuthMiddleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		username, password, ok := r.BasicAuth()
-		if !ok {
-			w.Header().Set("WWW-Authenticate", `Basic realm="Todo App"`)
+		authorization := r.Header.Get("Authorization")
+		if authorization == "" {
+			challenge(w, r)
 			http.Error(w, "Unauthorized: Basic auth required", http.StatusUnauthorized)
 			return
 		}
+		username, err := m.Check(authorization)
+		if err != nil {
+			challenge(w, r)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		// Handlers read the user from the Basic credentials, so a bearer
+		// token is rewritten into that form, without a password.
+		r.SetBasicAuth(username, "")
+		next(w, r)
+	}
+}
+
+// Check verifies an Authorization value and returns the user it belongs to.
+// It accepts "Basic <base64 user:password>" and "Bearer <token>" with a
+// token issued by /login. The HTTP middleware and the gRPC interceptor share
+// this logic.
+func (m *AuthMiddleware) Check(authorization string) (string, error) {
+	scheme, credentials, found := strings.Cut(authorization, " ")
+	if !found {
+		return "", ErrUnauthenticated
+	}
+	credentials = strings.TrimSpace(credentials)
+	switch {
+	case strings.EqualFold(scheme, "Basic"):
+		decoded, err := base64.StdEncoding.DecodeString(credentials)
+		if err != nil {
+			return "", ErrUnauthenticated
+		}
+		username, password, found := strings.Cut(string(decoded), ":")
+		if !found {
+			return "", ErrUnauthenticated
+		}
 		log.Printf("Authenticating user %s ...", username)
 		if !m.userService.AuthenticateUser(username, password) {
 			// Authentication failed
 			log.Printf("User %s authentication failed", username)
-			w.Header().Set("WWW-Authenticate", `Basic realm="Todo App"`)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
+			return "", ErrUnauthenticated
 		}
 		log.Printf("User %s authenticated successfully", username)
-		next(w, r)
+		return username, nil
+	case strings.EqualFold(scheme, "Bearer"):
+		username, ok := m.userService.AuthenticateToken(credentials)
+		if !ok {
+			log.Printf("Token authentication failed")
+			return "", ErrUnauthenticated
+		}
+		log.Printf("User %s authenticated with a token", username)
+		return username, nil
+	}
+	return "", ErrUnauthenticated
+}
+
+// challenge asks the client for Basic credentials. Script requests (marked
+// with X-Requested-With, as the web UI does) get no challenge so the browser
+// doesn't pop up its own login dialog over the page's form.
+func challenge(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Requested-With") == "XMLHttpRequest" {
+		return
 	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="Todo App"`)
 }
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	"todolist/internal/models"
+	"todolist/internal/services"
+)
+
+const (
+	// IdempotencyKeyHeader lets clients retry a write safely: the first
+	// response for a key is stored and replayed for later requests with it.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// ReplayedHeader marks a replayed response.
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// replayedHeaders are the response headers stored with the body.
+var replayedHeaders = []string{"Content-Type", "X-Undo-Token"}
+
+type IdempotencyMiddleware struct {
+	svc *services.IdempotencyService
+}
+
+func NewIdempotencyMiddleware(svc *services.IdempotencyService) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{svc: svc}
+}
+
+// Idempotent replays the stored response of requests that repeat an
+// Idempotency-Key. Requests without the header run as usual. It must wrap a
+// handler after authentication, as keys are scoped to the user.
+func (m *IdempotencyMiddleware) Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		user, _, _ := r.BasicAuth()
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		fp := fingerprint(r, body)
+		lease, stored, err := m.svc.Begin(user, key, fp)
+		var verr *services.ValidationError
+		switch {
+		case errors.As(err, &verr):
+			// The same JSON 400 the handlers answer invalid input with.
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(verr)
+			return
+		case errors.Is(err, services.ErrIdempotencyKeyReused):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		case errors.Is(err, services.ErrIdempotencyInProgress):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			log.Printf("Error checking idempotency key '%s': %v", key, err)
+			http.Error(w, "Error checking idempotency key", http.StatusInternalServerError)
+			return
+		case stored != nil:
+			log.Printf("Replaying response for idempotency key '%s', URI= '%s', method= '%s'", key, r.RequestURI, r.Method)
+			for name, value := range stored.Header {
+				w.Header().Set(name, value)
+			}
+			w.Header().Set(ReplayedHeader, "true")
+			w.WriteHeader(stored.Status)
+			w.Write(stored.Body)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		// Server errors may be transient, so the key is freed for a retry.
+		if rec.status >= 500 {
+			m.svc.Abort(user, key, lease)
+			return
+		}
+		header := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := w.Header().Get(name); value != "" {
+				header[name] = value
+			}
+		}
+		m.svc.Finish(user, lease, models.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: fp,
+			Status:      rec.status,
+			Header:      header,
+			Body:        rec.body.Bytes(),
+			CreatedAt:   time.Now(),
+		})
+	}
+}
+
+// fingerprint identifies a request by its method, URI and body.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder passes a response through while keeping a copy of it.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status, rec.wroteHeader = status, true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+	"todolist/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID that ties log lines and task history entries
+// to a single request. Clients may supply their own; otherwise one is generated.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID makes sure every request has an ID, echoes it in the response and
+// stores it in the request context.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = uuid.New().String()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(services.ContextWithRequestID(r.Context(), id)))
+	})
+}
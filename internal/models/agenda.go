@@ -0,0 +1,19 @@
+package models
+
+// Agenda lists the open tasks with a due date across all of a user's
+// projects, grouped by the day they are due in the agenda's time zone.
+type Agenda struct {
+	TimeZone string `json:"timeZone"` // IANA name, such as "Europe/Paris"
+	// Overdue are the tasks due before the first day.
+	Overdue []ProjectTask `json:"overdue"`
+	// Days has an entry for every day of the agenda, starting today, even
+	// when nothing is due that day.
+	Days []AgendaDay `json:"days"`
+}
+
+// AgendaDay is a day of an agenda.
+type AgendaDay struct {
+	Date  string        `json:"date"`  // YYYY-MM-DD
+	Label string        `json:"label"` // the date in the user's date format
+	Tasks []ProjectTask `json:"tasks"`
+}
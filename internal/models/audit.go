@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// AuditRecord is an append-only entry describing an account-level event,
+// such as a deactivated user being permanently purged.
+type AuditRecord struct {
+	Username string    `json:"username"`
+	Action   string    `json:"action"`
+	Detail   string    `json:"detail"`
+	Time     time.Time `json:"time"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+const (
+	ChangeCreate   = "create"
+	ChangeUpdate   = "update"
+	ChangeComplete = "complete"
+	ChangeTrash    = "trash"
+	ChangeRestore  = "restore"
+	ChangePurge    = "purge"
+	ChangeUndo     = "undo"
+	ChangeMove     = "move"
+)
+
+// ActorSystem is the Actor of changes the server makes on its own, such as
+// purges of expired trash.
+const ActorSystem = "system"
+
+// FieldChange is the before/after value of a single task field, rendered as text.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// TaskChange is an immutable history entry for one mutation of a task, or of
+// a whole project when TaskID is empty.
+type TaskChange struct {
+	ID        string        `json:"id"`
+	Project   string        `json:"project"`
+	TaskID    string        `json:"taskId,omitempty"`
+	Actor     string        `json:"actor"`
+	Action    string        `json:"action"`
+	Time      time.Time     `json:"time"`
+	RequestID string        `json:"requestId,omitempty"`
+	Changes   []FieldChange `json:"changes,omitempty"`
+	// Lifted lists the sub-projects moved up to the project's parent when
+	// it was trashed with its children lifted, so that undoing the trash
+	// nests them again.
+	Lifted []string `json:"lifted,omitempty"`
+
+	// Before and After are full snapshots of the task around the change,
+	// nil where it did not exist. They are kept so the change can be undone.
+	Before *Task `json:"-"`
+	After  *Task `json:"-"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// IdempotencyRecord is the outcome of a write sent with an Idempotency-Key,
+// replayed when the request is retried with the same key.
+type IdempotencyRecord struct {
+	Key string
+	// Fingerprint identifies the request payload, so that reusing the key
+	// for a different request can be refused.
+	Fingerprint string
+	// Status is zero while the first request is still being processed.
+	Status    int
+	Header    map[string]string
+	Body      []byte
+	CreatedAt time.Time
+	// LeaseUntil is when the request processing the key stops holding it, if
+	// it has not stored a response by then; a retry may then take the key
+	// over. It is zero once the response is stored.
+	LeaseUntil time.Time
+}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Project groups a user's tasks. Tasks are stored under the project's ID,
+// which never changes, so renaming a project does not touch its tasks.
+// Projects created before IDs were introduced use their name as ID.
+type Project struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Color       string    `json:"color"` // "#rrggbb", or empty for none
+	Created     time.Time `json:"created"`
+	// ParentID is the ID of the project this one is nested in, or empty for
+	// a top-level project.
+	ParentID string `json:"parentId"`
+	// Archived projects are left out of project listings unless asked for,
+	// but can still be opened and searched by name or ID.
+	Archived bool `json:"archived"`
+}
+
+// ProjectUpdate lists the fields of a project to change; nil fields are left
+// as they are.
+type ProjectUpdate struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Color       *string `json:"color,omitempty"`
+	Archived    *bool   `json:"archived,omitempty"`
+	// Parent moves the project under another one, given by name or ID, or
+	// to the top level when empty.
+	Parent *string `json:"parent,omitempty"`
+}
+
+// IsEmpty reports whether the update changes nothing.
+func (u ProjectUpdate) IsEmpty() bool {
+	return u.Name == nil && u.Description == nil && u.Color == nil && u.Archived == nil && u.Parent == nil
+}
+
+// Apply returns p with the update's fields set. Parent must already be
+// resolved to a project ID.
+func (u ProjectUpdate) Apply(p Project) Project {
+	if u.Name != nil {
+		p.Name = *u.Name
+	}
+	if u.Description != nil {
+		p.Description = *u.Description
+	}
+	if u.Color != nil {
+		p.Color = *u.Color
+	}
+	if u.Archived != nil {
+		p.Archived = *u.Archived
+	}
+	if u.Parent != nil {
+		p.ParentID = *u.Parent
+	}
+	return p
+}
+
+// ProjectNode is a project in the project tree, with the task counts of the
+// project itself and totals that include every project nested below it.
+type ProjectNode struct {
+	Project            Project       `json:"project"`
+	TaskCount          int           `json:"taskCount"`
+	OpenTaskCount      int           `json:"openTaskCount"`
+	TotalTaskCount     int           `json:"totalTaskCount"`
+	TotalOpenTaskCount int           `json:"totalOpenTaskCount"`
+	Children           []ProjectNode `json:"children"`
+}
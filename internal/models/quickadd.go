@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// QuickAdd is what was recognized in a quick-add string, such as "Pay rent
+// tomorrow 9am p1 #home every month". Fields not found in the string are
+// left empty.
+type QuickAdd struct {
+	Input      string      `json:"input"`
+	Content    string      `json:"content"` // the input without the recognized parts
+	Due        *time.Time  `json:"due,omitempty"`
+	Priority   *int        `json:"priority,omitempty"`
+	Project    string      `json:"project,omitempty"`
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+	Parts      []QuickPart `json:"parts"` // in input order
+}
+
+// Kinds of QuickPart.
+const (
+	QuickPartDue        = "due"
+	QuickPartPriority   = "priority"
+	QuickPartProject    = "project"
+	QuickPartRecurrence = "recurrence"
+)
+
+// QuickPart is a recognized part of a quick-add string, as a byte range of
+// the input.
+type QuickPart struct {
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+	TextRange
+}
+
+// QuickAddResult is the task created from a quick-add string.
+type QuickAddResult struct {
+	Parsed      QuickAdd `json:"parsed"`
+	Project     string   `json:"project"`     // the project's ID
+	ProjectName string   `json:"projectName"` // the project's name
+	Task        Task     `json:"task"`
+}
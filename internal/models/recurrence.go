@@ -0,0 +1,96 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Units of a Recurrence.
+const (
+	RecurDay     = "day"
+	RecurWeekday = "weekday" // Monday to Friday
+	RecurWeek    = "week"
+	RecurMonth   = "month"
+	RecurYear    = "year"
+)
+
+// Recurrence repeats a task every Interval units, on Weekday (lowercase,
+// such as "monday") for weekly recurrences given one.
+type Recurrence struct {
+	Interval int    `json:"interval"`
+	Unit     string `json:"unit"`
+	Weekday  string `json:"weekday,omitempty"`
+}
+
+func (r Recurrence) String() string {
+	switch {
+	case r.Weekday != "" && r.Interval == 1:
+		return "every " + r.Weekday
+	case r.Weekday != "":
+		return fmt.Sprintf("every %d weeks on %s", r.Interval, r.Weekday)
+	case r.Interval == 1:
+		return "every " + r.Unit
+	}
+	return fmt.Sprintf("every %d %ss", r.Interval, r.Unit)
+}
+
+// Valid reports whether r has a known unit, an interval of at least 1, and a
+// weekday only when it repeats weekly.
+func (r Recurrence) Valid() bool {
+	switch r.Unit {
+	case RecurDay, RecurWeekday, RecurMonth, RecurYear:
+		return r.Interval >= 1 && r.Weekday == ""
+	case RecurWeek:
+		_, ok := r.weekday()
+		return r.Interval >= 1 && (r.Weekday == "" || ok)
+	}
+	return false
+}
+
+// Next returns the occurrence after from, at the same time of day in from's
+// location. A weekly recurrence on a weekday moves to that day. Monthly and
+// yearly ones keep to the last day of shorter months, and stay on that day
+// afterwards: a month after January 31 is February 28, and a month after
+// that March 28.
+func (r Recurrence) Next(from time.Time) time.Time {
+	n := max(r.Interval, 1)
+	switch r.Unit {
+	case RecurWeekday:
+		next := from
+		for n > 0 {
+			next = next.AddDate(0, 0, 1)
+			if d := next.Weekday(); d != time.Saturday && d != time.Sunday {
+				n--
+			}
+		}
+		return next
+	case RecurWeek:
+		next := from.AddDate(0, 0, 7*n)
+		if d, ok := r.weekday(); ok {
+			next = next.AddDate(0, 0, (7+int(d)-int(next.Weekday()))%7)
+		}
+		return next
+	case RecurMonth:
+		return addMonths(from, n)
+	case RecurYear:
+		return addMonths(from, 12*n)
+	}
+	return from.AddDate(0, 0, n)
+}
+
+func (r Recurrence) weekday() (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.ToLower(d.String()) == r.Weekday {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// addMonths adds n months to t, keeping to the last day of shorter months.
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
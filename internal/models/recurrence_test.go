@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecurrenceNext(t *testing.T) {
+	at := func(date string) time.Time {
+		d, err := time.Parse(time.DateTime, date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		recurrence Recurrence
+		from, want string
+	}{
+		{Recurrence{Interval: 1, Unit: RecurDay}, "2025-05-07 09:30:00", "2025-05-08 09:30:00"},
+		{Recurrence{Interval: 3, Unit: RecurDay}, "2025-05-30 09:30:00", "2025-06-02 09:30:00"},
+		{Recurrence{Interval: 1, Unit: RecurWeekday}, "2025-05-07 09:30:00", "2025-05-08 09:30:00"}, // Wednesday
+		{Recurrence{Interval: 1, Unit: RecurWeekday}, "2025-05-09 09:30:00", "2025-05-12 09:30:00"}, // Friday
+		{Recurrence{Interval: 1, Unit: RecurWeekday}, "2025-05-10 09:30:00", "2025-05-12 09:30:00"}, // Saturday
+		{Recurrence{Interval: 3, Unit: RecurWeekday}, "2025-05-08 09:30:00", "2025-05-13 09:30:00"},
+		{Recurrence{Interval: 1, Unit: RecurWeek}, "2025-05-07 09:30:00", "2025-05-14 09:30:00"},
+		{Recurrence{Interval: 2, Unit: RecurWeek, Weekday: "monday"}, "2025-05-12 09:30:00", "2025-05-26 09:30:00"},
+		{Recurrence{Interval: 1, Unit: RecurWeek, Weekday: "monday"}, "2025-05-07 09:30:00", "2025-05-19 09:30:00"},
+		{Recurrence{Interval: 1, Unit: RecurMonth}, "2025-05-07 09:30:00", "2025-06-07 09:30:00"},
+		{Recurrence{Interval: 1, Unit: RecurMonth}, "2025-01-31 09:30:00", "2025-02-28 09:30:00"},
+		{Recurrence{Interval: 2, Unit: RecurMonth}, "2025-12-31 09:30:00", "2026-02-28 09:30:00"},
+		{Recurrence{Interval: 1, Unit: RecurYear}, "2024-02-29 09:30:00", "2025-02-28 09:30:00"},
+		{Recurrence{Interval: 4, Unit: RecurYear}, "2024-02-29 09:30:00", "2028-02-29 09:30:00"},
+	}
+	for _, tt := range tests {
+		if got := tt.recurrence.Next(at(tt.from)); !got.Equal(at(tt.want)) {
+			t.Errorf("%s after %s = %s, want %s", tt.recurrence, tt.from, got.Format(time.DateTime), tt.want)
+		}
+	}
+}
+
+func TestRecurrenceNextKeepsWallClock(t *testing.T) {
+	// DST starts in New York on 2025-03-09.
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	from := time.Date(2025, 3, 8, 9, 0, 0, 0, loc)
+	got := Recurrence{Interval: 1, Unit: RecurDay}.Next(from)
+	if want := time.Date(2025, 3, 9, 9, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("next = %s, want %s", got, want)
+	}
+}
+
+func TestRecurrenceValid(t *testing.T) {
+	tests := []struct {
+		recurrence Recurrence
+		valid      bool
+	}{
+		{Recurrence{Interval: 1, Unit: RecurDay}, true},
+		{Recurrence{Interval: 1, Unit: RecurWeekday}, true},
+		{Recurrence{Interval: 2, Unit: RecurWeek, Weekday: "friday"}, true},
+		{Recurrence{Interval: 12, Unit: RecurMonth}, true},
+		{Recurrence{Interval: 1, Unit: RecurYear}, true},
+		{Recurrence{Interval: 0, Unit: RecurDay}, false},
+		{Recurrence{Interval: 1, Unit: "fortnight"}, false},
+		{Recurrence{Interval: 1, Unit: RecurWeek, Weekday: "fri"}, false},
+		{Recurrence{Interval: 1, Unit: RecurMonth, Weekday: "monday"}, false},
+		{Recurrence{}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.recurrence.Valid(); got != tt.valid {
+			t.Errorf("%+v valid = %v, want %v", tt.recurrence, got, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+// SearchHit is a task matching a search query.
+type SearchHit struct {
+	Project     string  `json:"project"`     // the project's ID
+	ProjectName string  `json:"projectName"` // the project's name
+	Task        Task    `json:"task"`
+	Score       float64 `json:"score"`
+	// Matches are the byte ranges of the task's content that matched.
+	Matches []TextRange `json:"matches"`
+	// Highlight is the HTML-escaped content with the matches wrapped in
+	// <mark> elements.
+	Highlight string `json:"highlight"`
+}
+
+// TextRange is the byte range [Start, End) of a string.
+type TextRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// SmartList is a filter query saved under a name. Running it lists the tasks
+// that match the query at that moment, so relative dates such as "today"
+// move along with the clock.
+type SmartList struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Query   string    `json:"query"`
+	Created time.Time `json:"created"`
+}
@@ -6,6 +6,9 @@ import (
 	"time"
 )
 
+// NoDue is the due date of a task without one.
+var NoDue = time.Date(2099, 12, 31, 23, 59, 59, 0, time.UTC)
+
 type Task struct {
 	ID          string    `json:"id"`
 	Content     string    `json:"content"`
@@ -13,12 +16,71 @@ type Task struct {
 	UpdatedTime time.Time `json:"updatedTime"`
 	Due         time.Time `json:"due"`
 	Completed   bool      `json:"completed"`
+	// Recurrence, when set, makes completing the task move it to its next
+	// due date instead.
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+}
+
+// Equal compares tasks at the millisecond precision of stored times.
+func (t Task) Equal(u Task) bool {
+	return t.ID == u.ID && t.Content == u.Content && t.Priority == u.Priority && t.Completed == u.Completed &&
+		t.Due.Truncate(time.Millisecond).Equal(u.Due.Truncate(time.Millisecond)) &&
+		t.UpdatedTime.Truncate(time.Millisecond).Equal(u.UpdatedTime.Truncate(time.Millisecond)) &&
+		(t.Recurrence == nil) == (u.Recurrence == nil) && (t.Recurrence == nil || *t.Recurrence == *u.Recurrence)
 }
 
 func (t Task) String() string {
 	b, err := json.Marshal(t)
 	if err != nil {
-		return fmt.Sprintf("%+v", t)
+		// plain drops the String method so %+v does not recurse.
+		type plain Task
+		return fmt.Sprintf("%+v", plain(t))
 	}
 	return string(b)
 }
+
+// TaskUpdate lists the fields of a task to change; nil fields are left as
+// they are. A zero Recurrence removes the task's recurrence.
+type TaskUpdate struct {
+	Content    *string
+	Priority   *int
+	Due        *time.Time
+	Completed  *bool
+	Recurrence *Recurrence
+}
+
+// IsEmpty reports whether the update changes nothing.
+func (u TaskUpdate) IsEmpty() bool {
+	return u.Content == nil && u.Priority == nil && u.Due == nil && u.Completed == nil && u.Recurrence == nil
+}
+
+// Apply returns t with the update's fields set.
+func (u TaskUpdate) Apply(t Task) Task {
+	if u.Content != nil {
+		t.Content = *u.Content
+	}
+	if u.Priority != nil {
+		t.Priority = *u.Priority
+	}
+	if u.Due != nil {
+		t.Due = *u.Due
+	}
+	if u.Completed != nil {
+		t.Completed = *u.Completed
+	}
+	if u.Recurrence != nil {
+		t.Recurrence = nil
+		if r := *u.Recurrence; r != (Recurrence{}) {
+			t.Recurrence = &r
+		}
+	}
+	return t
+}
+
+// ProjectTask is a task listed together with the project it is in, in
+// listings that span projects.
+type ProjectTask struct {
+	Project     string `json:"project"`     // the project's ID
+	ProjectName string `json:"projectName"` // the project's name
+	Task        Task   `json:"task"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskStringWhenMarshallingFails(t *testing.T) {
+	// encoding/json refuses years past 9999, so String falls back to %+v,
+	// which must not call String again.
+	task := Task{ID: "task_1", Content: "far off", Due: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if s := task.String(); !strings.Contains(s, "task_1") || !strings.Contains(s, "far off") {
+		t.Errorf("String() = %q, want the task's fields", s)
+	}
+}
+
+func TestTaskStringIsJSON(t *testing.T) {
+	task := Task{ID: "task_1", Content: "milk", Priority: 2}
+	if s := task.String(); !strings.HasPrefix(s, `{"id":"task_1","content":"milk","priority":2,`) {
+		t.Errorf("String() = %q, want the task's JSON", s)
+	}
+}
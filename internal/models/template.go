@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Template is a saved copy of a project's tasks from which new projects are
+// created. Due dates are kept relative to an anchor date, so instantiating
+// the template at a new anchor shifts every due date along.
+type Template struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Color       string         `json:"color"`
+	Created     time.Time      `json:"created"`
+	Tasks       []TemplateTask `json:"tasks"`
+}
+
+// TemplateTask is a task of a template.
+type TemplateTask struct {
+	Content  string `json:"content"`
+	Priority int    `json:"priority"`
+	// DueOffset is the due date in seconds after the anchor date, negative
+	// for before it, or nil when the task has no due date.
+	DueOffset  *int64      `json:"dueOffset,omitempty"`
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+const (
+	TrashKindTask    = "task"
+	TrashKindProject = "project"
+)
+
+// TrashItem is a soft-deleted task or project waiting in a user's trash.
+type TrashItem struct {
+	Kind        string    `json:"kind"`                // TrashKindTask or TrashKindProject
+	Project     string    `json:"project"`             // the project's ID
+	ProjectName string    `json:"projectName"`         // the project's name
+	Parent      string    `json:"parent,omitempty"`    // for TrashKindProject, the ID of the project it was nested in
+	Task        *Task     `json:"task,omitempty"`      // set for TrashKindTask
+	TaskCount   int       `json:"taskCount,omitempty"` // tasks trashed together with a project
+	DeletedAt   time.Time `json:"deletedAt"`
+}
@@ -1,7 +1,99 @@
 package models
 
+import "time"
+
 type User struct {
-	Username string `json:"username"`
-	Password string `json:"password"` // store hashed passwords in real scenarios
-	Active   bool   `json:"active"`
+	Username      string       `json:"username"`
+	Password      string       `json:"password"` // store hashed passwords in real scenarios
+	Active        bool         `json:"active"`
+	DeactivatedAt time.Time    `json:"deactivatedAt"` // zero while the account is active
+	Settings      UserSettings `json:"settings"`
+}
+
+// AuthToken is a bearer token issued to a user. Only the SHA-256 hash of the
+// token is stored, so a leaked store does not hand out working tokens.
+type AuthToken struct {
+	Hash    string    `json:"-"` // hex
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"`
+}
+
+// UserSettings are a user's preferences for dates. Empty fields take the
+// values of DefaultSettings.
+type UserSettings struct {
+	// TimeZone is an IANA name such as "Europe/Paris". Days, such as the
+	// "today" of filters and agenda views, run from midnight to midnight in
+	// it.
+	TimeZone string `json:"timeZone"`
+	// WeekStart is the first day of the week: "monday", "sunday" or
+	// "saturday".
+	WeekStart string `json:"weekStart"`
+	// Locale is a BCP 47 language tag such as "en-US", for clients to format
+	// dates and numbers with.
+	Locale string `json:"locale"`
+	// DateFormat is one of the keys of DateLayouts. It only sets the labels
+	// of agenda days and how quick add reads numeric dates; every other date
+	// is RFC 3339.
+	DateFormat string `json:"dateFormat"`
+}
+
+// DefaultSettings apply to users who have not changed their settings.
+var DefaultSettings = UserSettings{TimeZone: "UTC", WeekStart: "monday", Locale: "en-US", DateFormat: "YYYY-MM-DD"}
+
+// DateLayouts maps the supported date formats to their time layouts.
+var DateLayouts = map[string]string{
+	"YYYY-MM-DD": "2006-01-02",
+	"DD/MM/YYYY": "02/01/2006",
+	"MM/DD/YYYY": "01/02/2006",
+	"DD.MM.YYYY": "02.01.2006",
+}
+
+// WeekStarts maps the supported first days of the week to their weekday.
+var WeekStarts = map[string]time.Weekday{
+	"monday":   time.Monday,
+	"sunday":   time.Sunday,
+	"saturday": time.Saturday,
+}
+
+// WithDefaults returns s with its empty fields set from DefaultSettings.
+func (s UserSettings) WithDefaults() UserSettings {
+	if s.TimeZone == "" {
+		s.TimeZone = DefaultSettings.TimeZone
+	}
+	if s.WeekStart == "" {
+		s.WeekStart = DefaultSettings.WeekStart
+	}
+	if s.Locale == "" {
+		s.Locale = DefaultSettings.Locale
+	}
+	if s.DateFormat == "" {
+		s.DateFormat = DefaultSettings.DateFormat
+	}
+	return s
+}
+
+// SettingsUpdate lists the settings to change; nil fields are left as they
+// are and empty ones are reset to the default.
+type SettingsUpdate struct {
+	TimeZone   *string `json:"timeZone,omitempty"`
+	WeekStart  *string `json:"weekStart,omitempty"`
+	Locale     *string `json:"locale,omitempty"`
+	DateFormat *string `json:"dateFormat,omitempty"`
+}
+
+// Apply returns s with the update's fields set.
+func (u SettingsUpdate) Apply(s UserSettings) UserSettings {
+	if u.TimeZone != nil {
+		s.TimeZone = *u.TimeZone
+	}
+	if u.WeekStart != nil {
+		s.WeekStart = *u.WeekStart
+	}
+	if u.Locale != nil {
+		s.Locale = *u.Locale
+	}
+	if u.DateFormat != nil {
+		s.DateFormat = *u.DateFormat
+	}
+	return s
 }
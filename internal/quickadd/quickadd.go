@@ -0,0 +1,557 @@
+// Package quickadd parses quick-add strings, such as "Pay rent tomorrow 9am
+// p1 #home every month", into the fields of a task.
+package quickadd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"todolist/internal/models"
+)
+
+// Options are the context a string is parsed in.
+type Options struct {
+	// Now is the time relative dates are taken from; its location is the
+	// time zone days are counted in.
+	Now time.Time
+	// WeekStart is the first day of the week, for "next week".
+	WeekStart time.Weekday
+	// DateLayout is a Go layout numeric dates may be written in, such as
+	// "02/01/2006", besides YYYY-MM-DD. It is ignored when empty.
+	DateLayout string
+	// MinPriority and MaxPriority bound the priorities recognized.
+	MinPriority, MaxPriority int
+}
+
+// Parse picks out of input, word by word:
+//
+//	#home                 the project, by name
+//	p1                    the priority, within Options.MinPriority and
+//	                      Options.MaxPriority
+//	today, tomorrow       a day; weekday names (monday or mon) stand for the
+//	                      next one after today, "next monday" for the monday
+//	                      of next week; "next week", "next month" and "next
+//	                      year" for their first day
+//	jan 5, 5th january    a date, optionally followed by its year; without one
+//	                      the next such date, today included; also YYYY-MM-DD
+//	                      and the numeric dates of Options.DateLayout
+//	in 3 days             days, weeks, months or years from today, or minutes
+//	                      and hours from now ("in 2 hours"); "a" or "an" for 1
+//	9am, 9:30 pm, 21:00   a time of day, also "noon"; "at" or "by" may come
+//	                      before it, and "on" or "by" before a day
+//	every month           a recurrence: every day, week, month or year, every
+//	                      N of them, every other one, every monday, every
+//	                      weekday (monday to friday), or daily, weekly,
+//	                      monthly and yearly
+//
+// Each kind is taken once; later occurrences are left in the content, as is
+// text in double quotes. Numbers have at most four digits.
+//
+// The due date combines the day and time found. A day without a time is due
+// at the end of the day, 23:59:59, and a time without a day is due today, or
+// tomorrow once the time has passed. A weekly recurrence on a weekday with no
+// day given is first due on the next one after today, and a recurrence every
+// weekday due on a weekend moves to the monday after.
+//
+// Words not recognized make up the content, joined by single spaces.
+func Parse(input string, opts Options) models.QuickAdd {
+	p := &parser{input: input, opts: opts, words: split(input)}
+	p.result = models.QuickAdd{Input: input, Parts: []models.QuickPart{}}
+	var content []string
+	for i := 0; i < len(p.words); {
+		if n := p.match(i); n > 0 {
+			i += n
+			continue
+		}
+		content = append(content, p.words[i].text)
+		i++
+	}
+	p.result.Content = strings.Join(content, " ")
+	p.resolveDue()
+	return p.result
+}
+
+type word struct {
+	text       string // with the quotes of a quoted word removed
+	lower      string
+	start, end int // byte offsets in the input
+	quoted     bool
+}
+
+// split cuts s into words at white space. Text in double quotes is one
+// word, even with spaces in it; an unterminated quote is an ordinary
+// character.
+func split(s string) []word {
+	var words []word
+	i := 0
+	for i < len(s) {
+		if isSpace(s[i]) {
+			i++
+			continue
+		}
+		if s[i] == '"' {
+			if end := strings.IndexByte(s[i+1:], '"'); end >= 0 {
+				text := s[i+1 : i+1+end]
+				words = append(words, word{text: text, lower: strings.ToLower(text), start: i, end: i + end + 2, quoted: true})
+				i += end + 2
+				continue
+			}
+		}
+		start := i
+		for i < len(s) && !isSpace(s[i]) {
+			i++
+		}
+		text := s[start:i]
+		words = append(words, word{text: text, lower: strings.ToLower(text), start: start, end: i})
+	}
+	return words
+}
+
+type parser struct {
+	input  string
+	opts   Options
+	words  []word
+	result models.QuickAdd
+
+	day      time.Time // midnight of the due day, when hasDay
+	hasDay   bool
+	clock    time.Duration // time of day, when hasClock
+	hasClock bool
+	instant  bool // the due date was given to the minute, as in "in 2 hours"
+	dueAt    time.Time
+	weekday  *time.Weekday // of a weekly recurrence
+}
+
+// match tries every kind of part not found yet at word i and returns the
+// number of words it took, 0 when none matched.
+func (p *parser) match(i int) int {
+	if p.words[i].quoted {
+		return 0
+	}
+	if p.result.Recurrence == nil {
+		if n := p.recurrence(i); n > 0 {
+			return p.take(models.QuickPartRecurrence, i, n)
+		}
+	}
+	if !p.hasDay && !p.instant {
+		if n := p.date(i); n > 0 {
+			return p.take(models.QuickPartDue, i, n)
+		}
+	}
+	if !p.hasClock && !p.instant {
+		if n := p.timeOfDay(i); n > 0 {
+			return p.take(models.QuickPartDue, i, n)
+		}
+	}
+	w := p.words[i]
+	if p.result.Priority == nil && len(w.lower) > 1 && w.lower[0] == 'p' {
+		if n, ok := number(w.lower[1:]); ok && n >= p.opts.MinPriority && n <= p.opts.MaxPriority {
+			p.result.Priority = &n
+			return p.take(models.QuickPartPriority, i, 1)
+		}
+	}
+	if p.result.Project == "" && len(w.text) > 1 && w.text[0] == '#' {
+		p.result.Project = w.text[1:]
+		return p.take(models.QuickPartProject, i, 1)
+	}
+	return 0
+}
+
+// take records words i to i+n-1 as a part of kind.
+func (p *parser) take(kind string, i, n int) int {
+	start, end := p.words[i].start, p.words[i+n-1].end
+	p.result.Parts = append(p.result.Parts, models.QuickPart{
+		Kind:      kind,
+		Text:      p.input[start:end],
+		TextRange: models.TextRange{Start: start, End: end},
+	})
+	return n
+}
+
+// lower returns the lowercased word i, or "" past the end or for a quoted
+// word.
+func (p *parser) lower(i int) string {
+	if i >= len(p.words) || p.words[i].quoted {
+		return ""
+	}
+	return p.words[i].lower
+}
+
+func (p *parser) recurrence(i int) int {
+	set := func(interval int, unit string, n int) int {
+		p.result.Recurrence = &models.Recurrence{Interval: interval, Unit: unit}
+		return n
+	}
+	switch p.lower(i) {
+	case "daily":
+		return set(1, models.RecurDay, 1)
+	case "weekly":
+		return set(1, models.RecurWeek, 1)
+	case "monthly":
+		return set(1, models.RecurMonth, 1)
+	case "yearly", "annually":
+		return set(1, models.RecurYear, 1)
+	case "every":
+	default:
+		return 0
+	}
+	if unit, ok := singularUnits[p.lower(i+1)]; ok {
+		return set(1, unit, 2)
+	}
+	if p.lower(i+1) == "weekday" {
+		return set(1, models.RecurWeekday, 2)
+	}
+	if d, ok := weekdays[p.lower(i+1)]; ok {
+		p.weekday = &d
+		p.result.Recurrence = &models.Recurrence{Interval: 1, Unit: models.RecurWeek, Weekday: weekdayName(d)}
+		return 2
+	}
+	if p.lower(i+1) == "other" {
+		if unit, ok := singularUnits[p.lower(i+2)]; ok {
+			return set(2, unit, 3)
+		}
+		return 0
+	}
+	if n, ok := number(p.lower(i + 1)); ok && n > 0 {
+		if unit, ok := pluralUnits[p.lower(i+2)]; ok {
+			return set(n, unit, 3)
+		}
+	}
+	return 0
+}
+
+func (p *parser) date(i int) int {
+	if w := p.lower(i); w == "on" || w == "by" {
+		if n := p.date(i + 1); n > 0 {
+			return n + 1
+		}
+		return 0
+	}
+	today := midnight(p.opts.Now)
+	setDay := func(day time.Time, n int) int {
+		p.day, p.hasDay = day, true
+		return n
+	}
+	w := p.lower(i)
+	switch w {
+	case "today":
+		return setDay(today, 1)
+	case "tomorrow":
+		return setDay(today.AddDate(0, 0, 1), 1)
+	case "next":
+		next := p.lower(i + 1)
+		weekStart := today.AddDate(0, 0, -((7 + int(today.Weekday()) - int(p.opts.WeekStart)) % 7))
+		switch next {
+		case "week":
+			return setDay(weekStart.AddDate(0, 0, 7), 2)
+		case "month":
+			return setDay(time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, today.Location()), 2)
+		case "year":
+			return setDay(time.Date(today.Year()+1, time.January, 1, 0, 0, 0, 0, today.Location()), 2)
+		}
+		if d, ok := weekdays[next]; ok {
+			into := (7 + int(d) - int(p.opts.WeekStart)) % 7
+			return setDay(weekStart.AddDate(0, 0, 7+into), 2)
+		}
+		return 0
+	case "in":
+		return p.offset(i)
+	}
+	if d, ok := weekdays[w]; ok {
+		return setDay(nextWeekday(today, d), 1)
+	}
+	if t, ok := p.numericDate(w); ok {
+		return setDay(t, 1)
+	}
+	if n := p.monthDay(i); n > 0 {
+		return n
+	}
+	return 0
+}
+
+// offset parses "in N units" at word i.
+func (p *parser) offset(i int) int {
+	count := p.lower(i + 1)
+	n, ok := number(count)
+	if count == "a" || count == "an" {
+		n, ok = 1, true
+	}
+	if !ok {
+		return 0
+	}
+	unit := p.lower(i + 2)
+	if n == 1 && !strings.HasSuffix(unit, "s") {
+		unit += "s"
+	}
+	switch unit {
+	case "minutes", "mins", "hours":
+		if p.hasClock {
+			return 0 // a time of day was given already
+		}
+	}
+	switch unit {
+	case "minutes", "mins":
+		p.dueAt, p.instant = p.opts.Now.Add(time.Duration(n)*time.Minute).Truncate(time.Minute), true
+		return 3
+	case "hours":
+		p.dueAt, p.instant = p.opts.Now.Add(time.Duration(n)*time.Hour).Truncate(time.Minute), true
+		return 3
+	}
+	today := midnight(p.opts.Now)
+	switch pluralUnits[unit] {
+	case models.RecurDay:
+		p.day = today.AddDate(0, 0, n)
+	case models.RecurWeek:
+		p.day = today.AddDate(0, 0, 7*n)
+	case models.RecurMonth:
+		p.day = addMonths(today, n)
+	case models.RecurYear:
+		p.day = addMonths(today, 12*n)
+	default:
+		return 0
+	}
+	p.hasDay = true
+	return 3
+}
+
+// numericDate parses YYYY-MM-DD or a date in Options.DateLayout.
+func (p *parser) numericDate(w string) (time.Time, bool) {
+	loc := p.opts.Now.Location()
+	if t, err := time.ParseInLocation(time.DateOnly, w, loc); err == nil {
+		return t, true
+	}
+	if p.opts.DateLayout != "" {
+		if t, err := time.ParseInLocation(p.opts.DateLayout, w, loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// monthDay parses "jan 5" or "5 jan" at word i, with an optional year after
+// it.
+func (p *parser) monthDay(i int) int {
+	month, okMonth := months[p.lower(i)]
+	day, okDay := dayOfMonth(p.lower(i + 1))
+	if !okMonth || !okDay {
+		day, okDay = dayOfMonth(p.lower(i))
+		month, okMonth = months[p.lower(i+1)]
+	}
+	if !okMonth || !okDay {
+		return 0
+	}
+	today := midnight(p.opts.Now)
+	n := 2
+	year := today.Year()
+	if y, ok := number(p.lower(i + 2)); ok && len(p.lower(i+2)) == 4 {
+		year, n = y, 3
+	}
+	t := time.Date(year, month, day, 0, 0, 0, 0, today.Location())
+	if t.Day() != day {
+		return 0 // such as feb 30
+	}
+	if n == 2 && t.Before(today) {
+		t = time.Date(year+1, month, day, 0, 0, 0, 0, today.Location())
+		if t.Day() != day {
+			return 0 // feb 29, passed this year and missing from the next
+		}
+	}
+	p.day, p.hasDay = t, true
+	return n
+}
+
+var (
+	clock12 = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)$`)
+	clock24 = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+	hourMin = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?$`)
+)
+
+// timeOfDay parses a time of day at word i.
+func (p *parser) timeOfDay(i int) int {
+	if w := p.lower(i); w == "at" || w == "by" {
+		if n := p.timeOfDay(i + 1); n > 0 {
+			return n + 1
+		}
+		return 0
+	}
+	w := p.lower(i)
+	set := func(h, m, n int) int {
+		p.clock, p.hasClock = time.Duration(h)*time.Hour+time.Duration(m)*time.Minute, true
+		return n
+	}
+	if w == "noon" {
+		return set(12, 0, 1)
+	}
+	if m := clock12.FindStringSubmatch(w); m != nil {
+		if h, minute, ok := hour12(m[1], m[2], m[3]); ok {
+			return set(h, minute, 1)
+		}
+		return 0
+	}
+	if next := p.lower(i + 1); next == "am" || next == "pm" {
+		if m := hourMin.FindStringSubmatch(w); m != nil {
+			if h, minute, ok := hour12(m[1], m[2], next); ok {
+				return set(h, minute, 2)
+			}
+		}
+	}
+	if m := clock24.FindStringSubmatch(w); m != nil {
+		h, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		if h < 24 && minute < 60 {
+			return set(h, minute, 1)
+		}
+		return 0
+	}
+	return 0
+}
+
+// hour12 converts a 12-hour clock time to hours and minutes.
+func hour12(hour, minute, meridiem string) (int, int, bool) {
+	h, _ := strconv.Atoi(hour)
+	m := 0
+	if minute != "" {
+		m, _ = strconv.Atoi(minute)
+	}
+	if h < 1 || h > 12 || m > 59 {
+		return 0, 0, false
+	}
+	h %= 12
+	if meridiem == "pm" {
+		h += 12
+	}
+	return h, m, true
+}
+
+// resolveDue combines the day and time found into the due date.
+func (p *parser) resolveDue() {
+	if p.instant {
+		p.result.Due = &p.dueAt
+		return
+	}
+	today := midnight(p.opts.Now)
+	if !p.hasDay && p.weekday != nil {
+		p.day, p.hasDay = nextWeekday(today, *p.weekday), true
+	}
+	var due time.Time
+	switch {
+	case p.hasDay && p.hasClock:
+		due = atClock(p.day, p.clock)
+	case p.hasDay:
+		due = time.Date(p.day.Year(), p.day.Month(), p.day.Day(), 23, 59, 59, 0, p.day.Location())
+	case p.hasClock:
+		if due = atClock(today, p.clock); !due.After(p.opts.Now) {
+			due = atClock(today.AddDate(0, 0, 1), p.clock)
+		}
+	default:
+		return
+	}
+	if r := p.result.Recurrence; r != nil && r.Unit == models.RecurWeekday {
+		switch due.Weekday() {
+		case time.Saturday:
+			due = due.AddDate(0, 0, 2)
+		case time.Sunday:
+			due = due.AddDate(0, 0, 1)
+		}
+	}
+	p.result.Due = &due
+}
+
+// atClock returns the time of day clock on day, which must be a midnight.
+// It goes by the wall clock, so days that DST makes shorter or longer keep
+// 9am at 9am.
+func atClock(day time.Time, clock time.Duration) time.Time {
+	h, m := int(clock/time.Hour), int(clock%time.Hour/time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// nextWeekday returns the first day after today falling on d.
+func nextWeekday(today time.Time, d time.Weekday) time.Time {
+	ahead := (7 + int(d) - int(today.Weekday())) % 7
+	if ahead == 0 {
+		ahead = 7
+	}
+	return today.AddDate(0, 0, ahead)
+}
+
+// addMonths adds n months to t, keeping to the last day of shorter months:
+// a month after January 31 is the end of February, not early March.
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
+
+// number parses a whole number of up to four digits.
+func number(s string) (int, bool) {
+	if len(s) == 0 || len(s) > 4 {
+		return 0, false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	n, _ := strconv.Atoi(s)
+	return n, true
+}
+
+// dayOfMonth parses a day of the month, as 5 or 5th.
+func dayOfMonth(s string) (int, bool) {
+	for _, suffix := range []string{"st", "nd", "rd", "th"} {
+		if strings.HasSuffix(s, suffix) {
+			s = strings.TrimSuffix(s, suffix)
+			break
+		}
+	}
+	n, ok := number(s)
+	return n, ok && len(s) <= 2 && n >= 1 && n <= 31
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func weekdayName(d time.Weekday) string {
+	return strings.ToLower(d.String())
+}
+
+var singularUnits = map[string]string{
+	"day": models.RecurDay, "week": models.RecurWeek, "month": models.RecurMonth, "year": models.RecurYear,
+}
+
+var pluralUnits = map[string]string{
+	"days": models.RecurDay, "weeks": models.RecurWeek, "months": models.RecurMonth, "years": models.RecurYear,
+}
+
+// weekdays leaves out sun, wed and sat, which are more often words of the
+// content than days.
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday,
+}
+
+var months = map[string]time.Month{
+	"january": time.January, "jan": time.January,
+	"february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April,
+	"may":  time.May,
+	"june": time.June, "jun": time.June,
+	"july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September,
+	"october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November,
+	"december": time.December, "dec": time.December,
+}
@@ -0,0 +1,213 @@
+package quickadd
+
+import (
+	"testing"
+	"time"
+	"todolist/internal/models"
+)
+
+// now is a Wednesday.
+var now = time.Date(2025, 5, 7, 10, 0, 0, 0, time.UTC)
+
+func options() Options {
+	return Options{Now: now, WeekStart: time.Monday, DateLayout: "02/01/2006", MinPriority: 0, MaxPriority: 10}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input      string
+		content    string
+		due        string // "2006-01-02 15:04:05" in UTC, empty for none
+		priority   int    // -1 for none
+		project    string
+		recurrence string // as Recurrence.String, empty for none
+	}{
+		{"Pay rent tomorrow 9am p1 #home every month", "Pay rent", "2025-05-08 09:00:00", 1, "home", "every month"},
+		{"Buy milk", "Buy milk", "", -1, "", ""},
+
+		// Days.
+		{"Call mom today", "Call mom", "2025-05-07 23:59:59", -1, "", ""},
+		{"Call mom tomorrow", "Call mom", "2025-05-08 23:59:59", -1, "", ""},
+		{"Call mom friday", "Call mom", "2025-05-09 23:59:59", -1, "", ""},
+		{"Call mom fri", "Call mom", "2025-05-09 23:59:59", -1, "", ""},
+		{"Call mom wednesday", "Call mom", "2025-05-14 23:59:59", -1, "", ""},
+		{"Call mom next monday", "Call mom", "2025-05-12 23:59:59", -1, "", ""},
+		{"Call mom next friday", "Call mom", "2025-05-16 23:59:59", -1, "", ""},
+		{"Plan next week", "Plan", "2025-05-12 23:59:59", -1, "", ""},
+		{"Plan next month", "Plan", "2025-06-01 23:59:59", -1, "", ""},
+		{"Plan next year", "Plan", "2026-01-01 23:59:59", -1, "", ""},
+		{"Renew in 3 days", "Renew", "2025-05-10 23:59:59", -1, "", ""},
+		{"Renew in a week", "Renew", "2025-05-14 23:59:59", -1, "", ""},
+		{"Renew in 2 months", "Renew", "2025-07-07 23:59:59", -1, "", ""},
+		{"Renew in 1 year", "Renew", "2026-05-07 23:59:59", -1, "", ""},
+		{"Tea in 30 minutes", "Tea", "2025-05-07 10:30:00", -1, "", ""},
+		{"Tea in an hour", "Tea", "2025-05-07 11:00:00", -1, "", ""},
+		{"Tea in 2 hours", "Tea", "2025-05-07 12:00:00", -1, "", ""},
+		{"Taxes jan 5", "Taxes", "2026-01-05 23:59:59", -1, "", ""},
+		{"Taxes 5th january 2027", "Taxes", "2027-01-05 23:59:59", -1, "", ""},
+		{"Party may 7", "Party", "2025-05-07 23:59:59", -1, "", ""},
+		{"Launch 2025-06-01", "Launch", "2025-06-01 23:59:59", -1, "", ""},
+		{"Launch 09/06/2025", "Launch", "2025-06-09 23:59:59", -1, "", ""},
+		{"Launch on friday", "Launch", "2025-05-09 23:59:59", -1, "", ""},
+		{"Submit form by dec 31", "Submit form", "2025-12-31 23:59:59", -1, "", ""},
+		{"Submit form by tomorrow", "Submit form", "2025-05-08 23:59:59", -1, "", ""},
+		{"Stand by me", "Stand by me", "", -1, "", ""},
+		{"Leap feb 30", "Leap feb 30", "", -1, "", ""},
+
+		// Times.
+		{"Lunch at noon", "Lunch", "2025-05-07 12:00:00", -1, "", ""},
+		{"Gym 9am", "Gym", "2025-05-08 09:00:00", -1, "", ""},
+		{"Gym 9 am", "Gym", "2025-05-08 09:00:00", -1, "", ""},
+		{"Gym 9:30 pm", "Gym", "2025-05-07 21:30:00", -1, "", ""},
+		{"Gym 21:00", "Gym", "2025-05-07 21:00:00", -1, "", ""},
+		{"Gym at 6pm", "Gym", "2025-05-07 18:00:00", -1, "", ""},
+		{"Submit form by 5pm", "Submit form", "2025-05-07 17:00:00", -1, "", ""},
+		{"Call bank fri at 10am", "Call bank", "2025-05-09 10:00:00", -1, "", ""},
+		{"Submit form by dec 31 by 5pm", "Submit form", "2025-12-31 17:00:00", -1, "", ""},
+		{"Gym 13pm", "Gym 13pm", "", -1, "", ""},
+		{"Gym 24:00", "Gym 24:00", "", -1, "", ""},
+
+		// Priorities.
+		{"Fix bug p0", "Fix bug", "", 0, "", ""},
+		{"Fix bug p9", "Fix bug", "", 9, "", ""},
+		{"Fix bug p10", "Fix bug", "", 10, "", ""},
+		{"Fix bug p11", "Fix bug p11", "", -1, "", ""},
+		{"Fix bug p99999", "Fix bug p99999", "", -1, "", ""},
+		{"Fix bug P2", "Fix bug", "", 2, "", ""},
+		{"Fix bug p1 p2", "Fix bug p2", "", 1, "", ""},
+
+		// Projects.
+		{"Fix bug #work", "Fix bug", "", -1, "work", ""},
+		{"Fix bug #work #home", "Fix bug #home", "", -1, "work", ""},
+		{"Fix bug #", "Fix bug #", "", -1, "", ""},
+
+		// Recurrences.
+		{"Water plants daily", "Water plants", "", -1, "", "every day"},
+		{"Water plants every day", "Water plants", "", -1, "", "every day"},
+		{"Review weekly", "Review", "", -1, "", "every week"},
+		{"Rent monthly", "Rent", "", -1, "", "every month"},
+		{"Checkup yearly", "Checkup", "", -1, "", "every year"},
+		{"Checkup annually", "Checkup", "", -1, "", "every year"},
+		{"Backup every 3 days", "Backup", "", -1, "", "every 3 days"},
+		{"Sprint every other week", "Sprint", "", -1, "", "every 2 weeks"},
+		{"Team call every monday", "Team call", "2025-05-12 23:59:59", -1, "", "every monday"},
+		{"Team call every monday 9am", "Team call", "2025-05-12 09:00:00", -1, "", "every monday"},
+		{"Team call every monday tomorrow", "Team call", "2025-05-08 23:59:59", -1, "", "every monday"},
+		{"Standup every weekday 9:30am", "Standup", "2025-05-08 09:30:00", -1, "", "every weekday"},
+		{"Standup every weekday saturday", "Standup", "2025-05-12 23:59:59", -1, "", "every weekday"},
+		{"Standup every weekday sunday 9am", "Standup", "2025-05-12 09:00:00", -1, "", "every weekday"},
+		{"Standup every weekday", "Standup", "", -1, "", "every weekday"},
+		{"Backup every 0 days", "Backup every 0 days", "", -1, "", ""},
+		{"Backup every now and then", "Backup every now and then", "", -1, "", ""},
+
+		// Literal text.
+		{`"Read 1984 tomorrow" tomorrow`, "Read 1984 tomorrow", "2025-05-08 23:59:59", -1, "", ""},
+		{"Call mom today tomorrow", "Call mom tomorrow", "2025-05-07 23:59:59", -1, "", ""},
+		{"Meet sat", "Meet sat", "", -1, "", ""},
+		{"  Buy   milk  ", "Buy milk", "", -1, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := Parse(tt.input, options())
+			if got.Input != tt.input {
+				t.Errorf("input = %q", got.Input)
+			}
+			if got.Content != tt.content {
+				t.Errorf("content = %q, want %q", got.Content, tt.content)
+			}
+			due := ""
+			if got.Due != nil {
+				due = got.Due.UTC().Format(time.DateTime)
+			}
+			if due != tt.due {
+				t.Errorf("due = %q, want %q", due, tt.due)
+			}
+			priority := -1
+			if got.Priority != nil {
+				priority = *got.Priority
+			}
+			if priority != tt.priority {
+				t.Errorf("priority = %d, want %d", priority, tt.priority)
+			}
+			if got.Project != tt.project {
+				t.Errorf("project = %q, want %q", got.Project, tt.project)
+			}
+			recurrence := ""
+			if got.Recurrence != nil {
+				recurrence = got.Recurrence.String()
+			}
+			if recurrence != tt.recurrence {
+				t.Errorf("recurrence = %q, want %q", recurrence, tt.recurrence)
+			}
+		})
+	}
+}
+
+func TestParsePriorityLimits(t *testing.T) {
+	opts := options()
+	opts.MinPriority, opts.MaxPriority = 1, 4
+	for _, tt := range []struct {
+		input    string
+		priority int // -1 for none
+	}{
+		{"Fix bug p0", -1},
+		{"Fix bug p1", 1},
+		{"Fix bug p4", 4},
+		{"Fix bug p5", -1},
+		{"Fix bug p5 p3", 3},
+	} {
+		got := Parse(tt.input, opts)
+		priority := -1
+		if got.Priority != nil {
+			priority = *got.Priority
+		}
+		if priority != tt.priority {
+			t.Errorf("Parse(%q) priority = %d, want %d", tt.input, priority, tt.priority)
+		}
+	}
+}
+
+func TestParseParts(t *testing.T) {
+	input := `Pay "rent" by dec 31 at 9am P1 #home every weekday`
+	want := []models.QuickPart{
+		{Kind: models.QuickPartDue, Text: "by dec 31", TextRange: models.TextRange{Start: 11, End: 20}},
+		{Kind: models.QuickPartDue, Text: "at 9am", TextRange: models.TextRange{Start: 21, End: 27}},
+		{Kind: models.QuickPartPriority, Text: "P1", TextRange: models.TextRange{Start: 28, End: 30}},
+		{Kind: models.QuickPartProject, Text: "#home", TextRange: models.TextRange{Start: 31, End: 36}},
+		{Kind: models.QuickPartRecurrence, Text: "every weekday", TextRange: models.TextRange{Start: 37, End: 50}},
+	}
+	got := Parse(input, options())
+	if got.Content != "Pay rent" {
+		t.Errorf("content = %q, want %q", got.Content, "Pay rent")
+	}
+	if len(got.Parts) != len(want) {
+		t.Fatalf("parts = %+v, want %+v", got.Parts, want)
+	}
+	for i := range want {
+		if got.Parts[i] != want[i] {
+			t.Errorf("part %d = %+v, want %+v", i, got.Parts[i], want[i])
+		}
+		if text := input[got.Parts[i].Start:got.Parts[i].End]; text != got.Parts[i].Text {
+			t.Errorf("part %d text %q does not match its range %q", i, got.Parts[i].Text, text)
+		}
+	}
+}
+
+func TestParseInTimeZone(t *testing.T) {
+	// 23:30 on May 7 in UTC is already May 8 at UTC+2.
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	opts := options()
+	opts.Now = time.Date(2025, 5, 7, 23, 30, 0, 0, time.UTC).In(loc)
+	for _, tt := range []struct {
+		input, due string // due in loc
+	}{
+		{"today", "2025-05-08 23:59:59"},
+		{"9am", "2025-05-08 09:00:00"},
+		{"in 2 hours", "2025-05-08 03:30:00"},
+	} {
+		got := Parse(tt.input, opts)
+		if got.Due == nil || got.Due.Location() != loc || got.Due.Format(time.DateTime) != tt.due {
+			t.Errorf("Parse(%q) due = %v, want %s in %s", tt.input, got.Due, tt.due, loc)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"todolist/internal/models"
+
+	"github.com/gocql/gocql"
+)
+
+type CassandraHistoryRepository struct {
+	session *gocql.Session
+	ttl     time.Duration // zero keeps entries forever
+}
+
+func NewCassandraHistoryRepository(session *gocql.Session, ttl time.Duration) *CassandraHistoryRepository {
+	return &CassandraHistoryRepository{session: session, ttl: ttl}
+}
+
+func (repo *CassandraHistoryRepository) AddChange(username string, change models.TaskChange) error {
+	changes, err := json.Marshal(change.Changes)
+	if err != nil {
+		return fmt.Errorf("error encoding field changes: %w", err)
+	}
+	before, err := encodeSnapshot(change.Before)
+	if err != nil {
+		return err
+	}
+	after, err := encodeSnapshot(change.After)
+	if err != nil {
+		return err
+	}
+	query := "INSERT INTO task_history (username, project, change_time, id, task_id, actor, action, request_id, changes, before, after, lifted) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) USING TTL ?"
+	return repo.session.Query(query, username, change.Project, change.Time, change.ID, change.TaskID,
+		change.Actor, change.Action, change.RequestID, string(changes), before, after, change.Lifted, int(repo.ttl.Seconds())).Exec()
+}
+
+func (repo *CassandraHistoryRepository) GetChange(username, changeID string) (models.TaskChange, error) {
+	query := "SELECT " + historyColumns + " FROM task_history WHERE username = ? AND id = ? ALLOW FILTERING"
+	changes, err := repo.list(repo.session.Query(query, username, changeID))
+	if err != nil {
+		return models.TaskChange{}, err
+	}
+	if len(changes) == 0 {
+		return models.TaskChange{}, ErrChangeNotFound
+	}
+	return changes[0], nil
+}
+
+func (repo *CassandraHistoryRepository) ListTaskHistory(username, project, taskID string) ([]models.TaskChange, error) {
+	query := "SELECT " + historyColumns + " FROM task_history WHERE username = ? AND project = ? AND task_id = ? ALLOW FILTERING"
+	return repo.list(repo.session.Query(query, username, project, taskID))
+}
+
+func (repo *CassandraHistoryRepository) ListProjectHistory(username, project string) ([]models.TaskChange, error) {
+	query := "SELECT " + historyColumns + " FROM task_history WHERE username = ? AND project = ?"
+	return repo.list(repo.session.Query(query, username, project))
+}
+
+// MoveTaskHistory rewrites the task's entries under the new project in one
+// logged batch; project is part of the clustering key, so each row is
+// inserted anew and the old one deleted.
+func (repo *CassandraHistoryRepository) MoveTaskHistory(username, from, to, taskID string) error {
+	changes, err := repo.ListTaskHistory(username, from, taskID)
+	if err != nil || len(changes) == 0 {
+		return err
+	}
+	batch := repo.session.NewBatch(gocql.LoggedBatch)
+	for _, change := range changes {
+		fields, err := json.Marshal(change.Changes)
+		if err != nil {
+			return fmt.Errorf("error encoding field changes: %w", err)
+		}
+		before, err := encodeSnapshot(change.Before)
+		if err != nil {
+			return err
+		}
+		after, err := encodeSnapshot(change.After)
+		if err != nil {
+			return err
+		}
+		batch.Query("INSERT INTO task_history (username, project, change_time, id, task_id, actor, action, request_id, changes, before, after, lifted) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) USING TTL ?",
+			username, to, change.Time, change.ID, change.TaskID, change.Actor, change.Action, change.RequestID, string(fields), before, after, change.Lifted, int(repo.ttl.Seconds()))
+		batch.Query("DELETE FROM task_history WHERE username = ? AND project = ? AND change_time = ? AND id = ?", username, from, change.Time, change.ID)
+	}
+	return repo.session.ExecuteBatch(batch)
+}
+
+func (repo *CassandraHistoryRepository) DeleteUserHistory(username string) error {
+	query := "DELETE FROM task_history WHERE username = ?"
+	return repo.session.Query(query, username).Exec()
+}
+
+const historyColumns = "project, change_time, id, task_id, actor, action, request_id, changes, before, after, lifted"
+
+func (repo *CassandraHistoryRepository) list(q *gocql.Query) ([]models.TaskChange, error) {
+	changes := []models.TaskChange{}
+	iter := q.Iter()
+	var change models.TaskChange
+	var fields, before, after string
+	for iter.Scan(&change.Project, &change.Time, &change.ID, &change.TaskID, &change.Actor, &change.Action, &change.RequestID, &fields, &before, &after, &change.Lifted) {
+		change.Changes = nil
+		if fields != "" {
+			if err := json.Unmarshal([]byte(fields), &change.Changes); err != nil {
+				iter.Close()
+				return nil, fmt.Errorf("error decoding field changes of %s: %w", change.ID, err)
+			}
+		}
+		var err error
+		if change.Before, err = decodeSnapshot(before); err != nil {
+			iter.Close()
+			return nil, err
+		}
+		if change.After, err = decodeSnapshot(after); err != nil {
+			iter.Close()
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error listing task history: %w", err)
+	}
+	return changes, nil
+}
+
+// encodeSnapshot stores a task snapshot as JSON, or "" for a missing task.
+func encodeSnapshot(task *models.Task) (string, error) {
+	if task == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("error encoding task snapshot: %w", err)
+	}
+	return string(b), nil
+}
+
+func decodeSnapshot(s string) (*models.Task, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var task models.Task
+	if err := json.Unmarshal([]byte(s), &task); err != nil {
+		return nil, fmt.Errorf("error decoding task snapshot: %w", err)
+	}
+	return &task, nil
+}
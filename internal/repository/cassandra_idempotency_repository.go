@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"todolist/internal/models"
+
+	"github.com/gocql/gocql"
+)
+
+type CassandraIdempotencyRepository struct {
+	session *gocql.Session
+	ttl     time.Duration
+}
+
+func NewCassandraIdempotencyRepository(session *gocql.Session, ttl time.Duration) *CassandraIdempotencyRepository {
+	return &CassandraIdempotencyRepository{session: session, ttl: ttl}
+}
+
+// ReserveKey inserts with IF NOT EXISTS, so of two concurrent requests with
+// the same key only one proceeds.
+func (repo *CassandraIdempotencyRepository) ReserveKey(username string, rec models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	query := "INSERT INTO idempotency_keys (username, idem_key, fingerprint, status, created_at, lease_until) VALUES (?, ?, ?, ?, ?, ?) IF NOT EXISTS USING TTL ?"
+	existing := map[string]interface{}{}
+	applied, err := repo.session.Query(query, username, rec.Key, rec.Fingerprint, rec.Status, rec.CreatedAt, rec.LeaseUntil,
+		int(repo.ttl.Seconds())).MapScanCAS(existing)
+	if err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+	if applied {
+		return rec, true, nil
+	}
+	stored := models.IdempotencyRecord{Key: rec.Key}
+	stored.Fingerprint, _ = existing["fingerprint"].(string)
+	stored.Status, _ = existing["status"].(int)
+	stored.Body, _ = existing["body"].([]byte)
+	stored.CreatedAt, _ = existing["created_at"].(time.Time)
+	stored.LeaseUntil, _ = existing["lease_until"].(time.Time)
+	if header, _ := existing["header"].(string); header != "" {
+		if err := json.Unmarshal([]byte(header), &stored.Header); err != nil {
+			return models.IdempotencyRecord{}, false, fmt.Errorf("error decoding stored headers: %w", err)
+		}
+	}
+	return stored, false, nil
+}
+
+// TakeOverKey rewrites every column, restarting the TTL, with a condition on
+// the lapsed lease, so of two retries taking a key over only one proceeds.
+func (repo *CassandraIdempotencyRepository) TakeOverKey(username string, rec models.IdempotencyRecord, lapsed time.Time) (bool, error) {
+	query := "UPDATE idempotency_keys USING TTL ? SET fingerprint = ?, status = ?, header = null, body = null, created_at = ?, lease_until = ? " +
+		"WHERE username = ? AND idem_key = ? IF lease_until = ?"
+	return repo.session.Query(query, int(repo.ttl.Seconds()), rec.Fingerprint, rec.Status, rec.CreatedAt, rec.LeaseUntil,
+		username, rec.Key, lapsed).MapScanCAS(map[string]interface{}{})
+}
+
+// SaveResponse restarts the TTL, so a response is replayed for the full
+// window after it was sent. Clearing lease_until ends the lease.
+func (repo *CassandraIdempotencyRepository) SaveResponse(username string, rec models.IdempotencyRecord) error {
+	header, err := json.Marshal(rec.Header)
+	if err != nil {
+		return fmt.Errorf("error encoding headers: %w", err)
+	}
+	query := "UPDATE idempotency_keys USING TTL ? SET fingerprint = ?, status = ?, header = ?, body = ?, created_at = ?, lease_until = null " +
+		"WHERE username = ? AND idem_key = ? IF lease_until = ?"
+	applied, err := repo.session.Query(query, int(repo.ttl.Seconds()), rec.Fingerprint, rec.Status, string(header), rec.Body, rec.CreatedAt,
+		username, rec.Key, rec.LeaseUntil).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (repo *CassandraIdempotencyRepository) ReleaseKey(username, key string, lease time.Time) error {
+	query := "DELETE FROM idempotency_keys WHERE username = ? AND idem_key = ? IF lease_until = ?"
+	applied, err := repo.session.Query(query, username, key, lease).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// DeleteUserKeys drops the user's partition. It runs once the account is
+// claimed by the purger, when no request of the user can be using a key.
+func (repo *CassandraIdempotencyRepository) DeleteUserKeys(username string) error {
+	return repo.session.Query("DELETE FROM idempotency_keys WHERE username = ?", username).Exec()
+}
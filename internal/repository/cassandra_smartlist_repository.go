@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+	"todolist/internal/models"
+
+	"github.com/gocql/gocql"
+)
+
+type CassandraSmartListRepository struct {
+	session *gocql.Session
+}
+
+func NewCassandraSmartListRepository(session *gocql.Session) *CassandraSmartListRepository {
+	return &CassandraSmartListRepository{session: session}
+}
+
+func (repo *CassandraSmartListRepository) SaveSmartList(username string, list models.SmartList) error {
+	query := "INSERT INTO smart_lists (username, id, name, query, created) VALUES (?, ?, ?, ?, ?)"
+	return repo.session.Query(query, username, list.ID, list.Name, list.Query, list.Created).Exec()
+}
+
+func (repo *CassandraSmartListRepository) ListSmartLists(username string) ([]models.SmartList, error) {
+	lists := []models.SmartList{}
+	query := "SELECT id, name, query, created FROM smart_lists WHERE username = ?"
+	iter := repo.session.Query(query, username).Iter()
+
+	var list models.SmartList
+	for iter.Scan(&list.ID, &list.Name, &list.Query, &list.Created) {
+		lists = append(lists, list)
+	}
+	if err := iter.Close(); err != nil {
+		log.Printf("Error iterating over smart lists for user %s: %v", username, err)
+		return nil, fmt.Errorf("error listing smart lists for user %s: %w", username, err)
+	}
+	return lists, nil
+}
+
+func (repo *CassandraSmartListRepository) DeleteSmartList(username, id string) error {
+	query := "DELETE FROM smart_lists WHERE username = ? AND id = ?"
+	return repo.session.Query(query, username, id).Exec()
+}
+
+func (repo *CassandraSmartListRepository) DeleteUserSmartLists(username string) error {
+	query := "DELETE FROM smart_lists WHERE username = ?"
+	return repo.session.Query(query, username).Exec()
+}
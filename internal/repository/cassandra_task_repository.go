@@ -2,9 +2,13 @@
 package repository
 
 import (
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"log"
+	"strings"
 	"time"
+	"todolist/internal/filter"
 	"todolist/internal/models"
 
 	"github.com/gocql/gocql"
@@ -18,40 +22,74 @@ func NewCassandraTaskRepository(session *gocql.Session) *CassandraTaskRepository
 	return &CassandraTaskRepository{session: session}
 }
 
-func (repo *CassandraTaskRepository) CreateProject(username, project string) error {
-	query := "INSERT INTO projects (username, project) VALUES (?, ?)"
-	err := repo.session.Query(query, username, project).Exec()
+func (repo *CassandraTaskRepository) CreateProject(username string, project models.Project) error {
+	_, trashed, err := repo.projectState(username, project.ID)
+	if err != nil {
+		return fmt.Errorf("error creating project %s for user %s: %w", project.ID, username, err)
+	}
+	if trashed {
+		return ErrProjectTrashed
+	}
+	query := "INSERT INTO projects (username, project, name, description, color, created, parent, archived) VALUES (?, ?, ?, ?, ?, ?, ?, ?) IF NOT EXISTS"
+	applied, err := repo.session.Query(query, username, project.ID, project.Name, project.Description, project.Color, project.Created, project.ParentID, project.Archived).
+		MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return fmt.Errorf("error creating project %s for user %s: %w", project.ID, username, err)
+	}
+	if !applied {
+		return ErrProjectExists
+	}
+	return nil
+}
+
+func (repo *CassandraTaskRepository) UpdateProject(username string, project models.Project) error {
+	query := "UPDATE projects SET name = ?, description = ?, color = ?, parent = ?, archived = ? WHERE username = ? AND project = ? IF deleted_at = null"
+	applied, err := repo.session.Query(query, project.Name, project.Description, project.Color, project.ParentID, project.Archived, username, project.ID).
+		MapScanCAS(map[string]interface{}{})
 	if err != nil {
-		return fmt.Errorf("error creating project %s for user %s: %w", project, username, err)
+		return fmt.Errorf("error updating project %s for user %s: %w", project.ID, username, err)
+	}
+	if !applied {
+		return fmt.Errorf("project %s does not exist for user %s", project.ID, username)
 	}
 	return nil
 }
 
 func (repo *CassandraTaskRepository) CreateTask(username, project string, task models.Task) error {
-	var existing string
-	if err := repo.session.Query(
-		`SELECT project FROM todolist.projects WHERE project = ? ALLOW FILTERING`,
-		project,
-	).Scan(&existing); err != nil {
-		if err == gocql.ErrNotFound {
-			return fmt.Errorf("project %q does not exist", project)
-		}
+	exists, trashed, err := repo.projectState(username, project)
+	if err != nil {
 		return fmt.Errorf("failed to verify project existence: %w", err)
 	}
-	query := "INSERT INTO tasks (username, project, id, content, priority, updated_time, due, completed) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
-	err := repo.session.Query(query, username, project, task.ID, task.Content, task.Priority, time.Now(), task.Due, task.Completed).Exec()
+	if !exists || trashed {
+		return fmt.Errorf("project %q does not exist", project)
+	}
+	// deleted_at is cleared explicitly: an INSERT over a trashed row would otherwise keep it.
+	query := "INSERT INTO tasks (username, project, id, content, priority, updated_time, due, completed, recurrence, deleted_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, null)"
+	err = repo.session.Query(query, username, project, task.ID, task.Content, task.Priority, time.Now(), task.Due, task.Completed, recurrenceColumn(task.Recurrence)).Exec()
 	return err
 }
 
 func (repo *CassandraTaskRepository) ListTasks(username, project string) ([]models.Task, error) {
 	var tasks []models.Task
-	query := "SELECT id, content, priority, updated_time, due, completed FROM tasks WHERE username = ? AND project = ?"
+	exists, trashed, err := repo.projectState(username, project)
+	if err != nil {
+		return nil, err
+	}
+	if !exists || trashed {
+		return tasks, nil
+	}
+	query := "SELECT id, content, priority, updated_time, due, completed, recurrence, deleted_at FROM tasks WHERE username = ? AND project = ?"
 	iter := repo.session.Query(query, username, project).Iter()
 	defer iter.Close()
 
 	var task models.Task
-	for iter.Scan(&task.ID, &task.Content, &task.Priority, &task.UpdatedTime, &task.Due, &task.Completed) {
-		tasks = append(tasks, task)
+	var recurrence string
+	var deletedAt time.Time
+	for iter.Scan(&task.ID, &task.Content, &task.Priority, &task.UpdatedTime, &task.Due, &task.Completed, &recurrence, &deletedAt) {
+		if deletedAt.IsZero() {
+			task.Recurrence = scanRecurrence(recurrence)
+			tasks = append(tasks, task)
+		}
 	}
 	if err := iter.Close(); err != nil {
 		return nil, err
@@ -59,12 +97,340 @@ func (repo *CassandraTaskRepository) ListTasks(username, project string) ([]mode
 	return tasks, nil
 }
 
+// ListTasksInProjects reads all requested projects with two queries, one for
+// their state and one for their tasks, instead of one pair per project.
+func (repo *CassandraTaskRepository) ListTasksInProjects(username string, projects []string) (map[string][]models.Task, error) {
+	result := make(map[string][]models.Task, len(projects))
+	for _, project := range projects {
+		result[project] = []models.Task{}
+	}
+	if len(projects) == 0 {
+		return result, nil
+	}
+
+	live := make(map[string]bool, len(projects))
+	iter := repo.session.Query("SELECT project, deleted_at FROM projects WHERE username = ? AND project IN ?", username, projects).Iter()
+	var project string
+	var deletedAt time.Time
+	for iter.Scan(&project, &deletedAt) {
+		live[project] = deletedAt.IsZero()
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error reading projects for user %s: %w", username, err)
+	}
+
+	query := "SELECT project, id, content, priority, updated_time, due, completed, recurrence, deleted_at FROM tasks WHERE username = ? AND project IN ?"
+	iter = repo.session.Query(query, username, projects).Iter()
+	var task models.Task
+	var recurrence string
+	for iter.Scan(&project, &task.ID, &task.Content, &task.Priority, &task.UpdatedTime, &task.Due, &task.Completed, &recurrence, &deletedAt) {
+		if live[project] && deletedAt.IsZero() {
+			task.Recurrence = scanRecurrence(recurrence)
+			result[project] = append(result[project], task)
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error listing tasks for user %s: %w", username, err)
+	}
+	return result, nil
+}
+
+// ListTasksMatching reads the tasks of all requested projects with one query.
+// The comparisons every match must satisfy that map onto task columns are
+// sent along with it; the full expression is evaluated on the rows returned.
+func (repo *CassandraTaskRepository) ListTasksMatching(username string, projects []models.Project, match filter.Expr) (map[string][]models.Task, error) {
+	result := make(map[string][]models.Task, len(projects))
+	byID := make(map[string]models.Project, len(projects))
+	ids := make([]string, 0, len(projects))
+	for _, project := range projects {
+		result[project.ID] = []models.Task{}
+		byID[project.ID] = project
+		ids = append(ids, project.ID)
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	query := "SELECT project, id, content, priority, updated_time, due, completed, recurrence, deleted_at FROM tasks WHERE username = ? AND project IN ?"
+	args := []interface{}{username, ids}
+	if restrictions, values := cqlRestrictions(match); len(restrictions) > 0 {
+		query += " AND " + strings.Join(restrictions, " AND ") + " ALLOW FILTERING"
+		args = append(args, values...)
+	}
+	iter := repo.session.Query(query, args...).Iter()
+	var project string
+	var task models.Task
+	var recurrence string
+	var deletedAt time.Time
+	for iter.Scan(&project, &task.ID, &task.Content, &task.Priority, &task.UpdatedTime, &task.Due, &task.Completed, &recurrence, &deletedAt) {
+		task.Recurrence = scanRecurrence(recurrence)
+		if deletedAt.IsZero() && match.Match(task, byID[project]) {
+			result[project] = append(result[project], task)
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error filtering tasks for user %s: %w", username, err)
+	}
+	return result, nil
+}
+
+// cqlRestrictions translates the top-level comparisons of match on priority,
+// completion and dates into CQL restrictions. They may let through rows that
+// do not match, never the other way round: timestamps only keep milliseconds,
+// so date bounds are made inclusive, and != is left to the expression.
+func cqlRestrictions(match filter.Expr) ([]string, []interface{}) {
+	var restrictions []string
+	var values []interface{}
+	add := func(restriction string, value interface{}) {
+		restrictions = append(restrictions, restriction)
+		values = append(values, value)
+	}
+	for _, e := range filter.Conjuncts(match) {
+		c, ok := e.(*filter.Compare)
+		if !ok {
+			continue
+		}
+		switch c.Field {
+		case filter.FieldPriority:
+			if c.Op != filter.OpNe {
+				add(fmt.Sprintf("priority %s ?", c.Op), c.Int)
+			}
+		case filter.FieldCompleted:
+			add("completed = ?", c.Bool == (c.Op == filter.OpEq))
+		case filter.FieldDue, filter.FieldUpdated:
+			if c.None {
+				continue
+			}
+			column := "due"
+			if c.Field == filter.FieldUpdated {
+				column = "updated_time"
+			}
+			switch c.Op {
+			case filter.OpEq:
+				add(column+" >= ?", c.From)
+				add(column+" <= ?", c.To)
+			case filter.OpLt:
+				add(column+" < ?", c.From)
+			case filter.OpLe:
+				add(column+" <= ?", c.To)
+			case filter.OpGt:
+				add(column+" > ?", c.From)
+			case filter.OpGe:
+				add(column+" >= ?", c.From)
+			}
+		}
+	}
+	return restrictions, values
+}
+
 func (repo *CassandraTaskRepository) UpdateTask(username, project string, task models.Task) error {
-	query := "UPDATE tasks SET content = ?, priority = ?, updated_time = ?, due = ?, completed = ? WHERE username = ? AND project = ? AND id = ?"
-	err := repo.session.Query(query, task.Content, task.Priority, time.Now(), task.Due, task.Completed, username, project, task.ID).Exec()
+	query := "UPDATE tasks SET content = ?, priority = ?, updated_time = ?, due = ?, completed = ?, recurrence = ? WHERE username = ? AND project = ? AND id = ?"
+	err := repo.session.Query(query, task.Content, task.Priority, time.Now(), task.Due, task.Completed, recurrenceColumn(task.Recurrence), username, project, task.ID).Exec()
 	return err
 }
 
+// UpdateTaskFields builds the SET clause from the fields present in update,
+// so concurrent writes to other columns are not overwritten.
+func (repo *CassandraTaskRepository) UpdateTaskFields(username, project, taskID string, update models.TaskUpdate) error {
+	sets := []string{"updated_time = ?"}
+	args := []interface{}{time.Now()}
+	if update.Content != nil {
+		sets = append(sets, "content = ?")
+		args = append(args, *update.Content)
+	}
+	if update.Priority != nil {
+		sets = append(sets, "priority = ?")
+		args = append(args, *update.Priority)
+	}
+	if update.Due != nil {
+		sets = append(sets, "due = ?")
+		args = append(args, *update.Due)
+	}
+	if update.Completed != nil {
+		sets = append(sets, "completed = ?")
+		args = append(args, *update.Completed)
+	}
+	if update.Recurrence != nil {
+		sets = append(sets, "recurrence = ?")
+		args = append(args, recurrenceColumn(update.Recurrence))
+	}
+	query := "UPDATE tasks SET " + strings.Join(sets, ", ") + " WHERE username = ? AND project = ? AND id = ? IF EXISTS"
+	args = append(args, username, project, taskID)
+	applied, err := repo.session.Query(query, args...).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return fmt.Errorf("task %s not found in project %s", taskID, project)
+	}
+	return nil
+}
+
+// ApplyTaskWrites runs the writes on tasks as one logged batch. Every task
+// row of a user lives in the same partition of tasks, so the batch is atomic
+// and isolated, and each checked write carries its precondition as an IF
+// condition on the stored row: a write in between makes the whole batch fail
+// with ErrTaskChanged rather than go undetected. Writes that depend on
+// earlier writes of the batch are checked against them in memory. Project
+// liveness is in another table and verified up front, and trashed tasks are
+// added to the trash index once the batch has been applied.
+func (repo *CassandraTaskRepository) ApplyTaskWrites(username string, writes []TaskWrite) error {
+	checked := make(map[string]bool)
+	var seen *taskRow // what the current write's check read, if anything
+	var readErr error
+	planned := newPlannedTasks(func(project, id string) (models.Task, bool) {
+		row, err := repo.taskRow(username, project, id)
+		if err != nil {
+			readErr = err
+			return models.Task{}, false
+		}
+		seen = &row
+		if !row.live() {
+			return models.Task{}, false
+		}
+		if exists, trashed, err := repo.projectState(username, project); err != nil || !exists || trashed {
+			return models.Task{}, false
+		}
+		return row.task, true
+	})
+	batch := repo.session.NewBatch(gocql.LoggedBatch)
+	conditional := false
+	var trashed []TaskWrite
+	for _, w := range writes {
+		seen, readErr = nil, nil
+		err := planned.apply(w)
+		if readErr != nil {
+			return fmt.Errorf("error reading task %s: %w", w.Task.ID, readErr)
+		}
+		if err != nil {
+			return err
+		}
+		if w.Kind == WritePut && !checked[w.Project] {
+			exists, trashed, err := repo.projectState(username, w.Project)
+			if err != nil {
+				return fmt.Errorf("failed to verify project existence: %w", err)
+			}
+			if !exists || trashed {
+				return fmt.Errorf("project %q does not exist", w.Project)
+			}
+			checked[w.Project] = true
+		}
+		if seen == nil {
+			repo.addTaskWrite(batch, username, w)
+		} else {
+			if err := repo.addConditionalTaskWrite(batch, username, w, *seen); err != nil {
+				return err
+			}
+			conditional = true
+		}
+		if w.Kind == WriteTrash {
+			trashed = append(trashed, w)
+		}
+	}
+
+	if conditional {
+		applied, iter, err := repo.session.MapExecuteBatchCAS(batch, map[string]interface{}{})
+		if err != nil {
+			return err
+		}
+		iter.Close()
+		if !applied {
+			return fmt.Errorf("%w: a checked task was written concurrently", ErrTaskChanged)
+		}
+	} else if err := repo.session.ExecuteBatch(batch); err != nil {
+		return err
+	}
+
+	for _, w := range trashed {
+		index := "INSERT INTO trash (shard, deleted_at, username, project, id) VALUES (?, ?, ?, ?, ?)"
+		if err := repo.session.Query(index, trashShard(username), w.DeletedAt, username, w.Project, w.Task.ID).Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// taskRow is a stored task row, trashed or not.
+type taskRow struct {
+	exists     bool
+	task       models.Task
+	recurrence string // the column as stored
+	deletedAt  time.Time
+}
+
+func (row taskRow) live() bool {
+	return row.exists && row.deletedAt.IsZero()
+}
+
+func (repo *CassandraTaskRepository) taskRow(username, project, taskID string) (taskRow, error) {
+	row := taskRow{exists: true}
+	query := "SELECT id, content, priority, updated_time, due, completed, recurrence, deleted_at FROM tasks WHERE username = ? AND project = ? AND id = ?"
+	err := repo.session.Query(query, username, project, taskID).Scan(&row.task.ID, &row.task.Content, &row.task.Priority,
+		&row.task.UpdatedTime, &row.task.Due, &row.task.Completed, &row.recurrence, &row.deletedAt)
+	if err == gocql.ErrNotFound {
+		return taskRow{}, nil
+	}
+	if err != nil {
+		return taskRow{}, err
+	}
+	row.task.Recurrence = scanRecurrence(row.recurrence)
+	return row, nil
+}
+
+// addTaskWrite adds w to batch without a condition.
+func (repo *CassandraTaskRepository) addTaskWrite(batch *gocql.Batch, username string, w TaskWrite) {
+	switch w.Kind {
+	case WritePut:
+		batch.Query("INSERT INTO tasks (username, project, id, content, priority, updated_time, due, completed, recurrence, deleted_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, null)",
+			username, w.Project, w.Task.ID, w.Task.Content, w.Task.Priority, w.Task.UpdatedTime, w.Task.Due, w.Task.Completed, recurrenceColumn(w.Task.Recurrence))
+	case WriteTrash:
+		batch.Query("UPDATE tasks SET deleted_at = ? WHERE username = ? AND project = ? AND id = ?",
+			w.DeletedAt, username, w.Project, w.Task.ID)
+	case WriteDelete:
+		batch.Query("DELETE FROM tasks WHERE username = ? AND project = ? AND id = ?", username, w.Project, w.Task.ID)
+	}
+}
+
+// addConditionalTaskWrite adds w to batch on the condition that its row is
+// still as seen when w was checked: absent, or with the same columns.
+func (repo *CassandraTaskRepository) addConditionalTaskWrite(batch *gocql.Batch, username string, w TaskWrite, seen taskRow) error {
+	if !seen.exists {
+		if w.Kind != WritePut {
+			return fmt.Errorf("cannot check that task %s of project %s is absent before removing it", w.Task.ID, w.Project)
+		}
+		batch.Query("INSERT INTO tasks (username, project, id, content, priority, updated_time, due, completed, recurrence, deleted_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, null) IF NOT EXISTS",
+			username, w.Project, w.Task.ID, w.Task.Content, w.Task.Priority, w.Task.UpdatedTime, w.Task.Due, w.Task.Completed, recurrenceColumn(w.Task.Recurrence))
+		return nil
+	}
+
+	var recurrence interface{}
+	if seen.recurrence != "" {
+		recurrence = seen.recurrence
+	}
+	var deletedAt interface{}
+	if !seen.deletedAt.IsZero() {
+		deletedAt = seen.deletedAt
+	}
+	condition := " IF content = ? AND priority = ? AND updated_time = ? AND due = ? AND completed = ? AND recurrence = ? AND deleted_at = ?"
+	conditionArgs := []interface{}{seen.task.Content, seen.task.Priority, seen.task.UpdatedTime, seen.task.Due, seen.task.Completed, recurrence, deletedAt}
+	key := []interface{}{username, w.Project, w.Task.ID}
+
+	switch w.Kind {
+	case WritePut:
+		// INSERT takes no IF conditions other than IF NOT EXISTS.
+		args := []interface{}{w.Task.Content, w.Task.Priority, w.Task.UpdatedTime, w.Task.Due, w.Task.Completed, recurrenceColumn(w.Task.Recurrence)}
+		batch.Query("UPDATE tasks SET content = ?, priority = ?, updated_time = ?, due = ?, completed = ?, recurrence = ?, deleted_at = null WHERE username = ? AND project = ? AND id = ?"+condition,
+			append(append(args, key...), conditionArgs...)...)
+	case WriteTrash:
+		batch.Query("UPDATE tasks SET deleted_at = ? WHERE username = ? AND project = ? AND id = ?"+condition,
+			append(append([]interface{}{w.DeletedAt}, key...), conditionArgs...)...)
+	case WriteDelete:
+		batch.Query("DELETE FROM tasks WHERE username = ? AND project = ? AND id = ?"+condition,
+			append(key, conditionArgs...)...)
+	}
+	return nil
+}
+
 func (repo *CassandraTaskRepository) DeleteTask(username, project, taskID string) error {
 	query := "DELETE FROM tasks WHERE username = ? AND project = ? AND id = ?"
 	err := repo.session.Query(query, username, project, taskID).Exec()
@@ -72,15 +438,17 @@ func (repo *CassandraTaskRepository) DeleteTask(username, project, taskID string
 }
 
 func (repo *CassandraTaskRepository) CompleteTask(username, project, taskID string) error {
-	query := "UPDATE tasks SET completed = true WHERE username = ? AND project = ? AND id = ?"
-	err := repo.session.Query(query, username, project, taskID).Exec()
+	query := "UPDATE tasks SET completed = true, updated_time = ? WHERE username = ? AND project = ? AND id = ?"
+	err := repo.session.Query(query, time.Now(), username, project, taskID).Exec()
 	return err
 }
 
 func (repo *CassandraTaskRepository) GetTask(username, project, taskID string) (models.Task, bool) {
 	var task models.Task
-	query := "SELECT id, content, priority, updated_time, due, completed FROM tasks WHERE username = ? AND project = ? AND id = ? ALLOW FILTERING"
-	err := repo.session.Query(query, username, project, taskID).Scan(&task.ID, &task.Content, &task.Priority, &task.UpdatedTime, &task.Due, &task.Completed)
+	var recurrence string
+	var deletedAt time.Time
+	query := "SELECT id, content, priority, updated_time, due, completed, recurrence, deleted_at FROM tasks WHERE username = ? AND project = ? AND id = ? ALLOW FILTERING"
+	err := repo.session.Query(query, username, project, taskID).Scan(&task.ID, &task.Content, &task.Priority, &task.UpdatedTime, &task.Due, &task.Completed, &recurrence, &deletedAt)
 	if err != nil {
 		if err == gocql.ErrNotFound {
 			return task, false
@@ -88,18 +456,28 @@ func (repo *CassandraTaskRepository) GetTask(username, project, taskID string) (
 		log.Println("Error retrieving task:", err)
 		return task, false
 	}
+	if !deletedAt.IsZero() {
+		return models.Task{}, false
+	}
+	if exists, trashed, err := repo.projectState(username, project); err != nil || !exists || trashed {
+		return models.Task{}, false
+	}
+	task.Recurrence = scanRecurrence(recurrence)
 	return task, true
 }
 
-func (repo *CassandraTaskRepository) ListProjects(username string) ([]string, error) {
-	var projects []string
+func (repo *CassandraTaskRepository) ListProjects(username string) ([]models.Project, error) {
+	projects := []models.Project{}
 
-	query := "SELECT project FROM projects WHERE username = ?"
+	query := "SELECT project, name, description, color, created, parent, archived, deleted_at FROM projects WHERE username = ?"
 	iter := repo.session.Query(query, username).Iter()
 
-	var projectName string
-	for iter.Scan(&projectName) {
-		projects = append(projects, projectName)
+	var project models.Project
+	var deletedAt time.Time
+	for iter.Scan(&project.ID, &project.Name, &project.Description, &project.Color, &project.Created, &project.ParentID, &project.Archived, &deletedAt) {
+		if deletedAt.IsZero() {
+			projects = append(projects, withLegacyName(project))
+		}
 	}
 
 	if err := iter.Close(); err != nil {
@@ -110,8 +488,23 @@ func (repo *CassandraTaskRepository) ListProjects(username string) ([]string, er
 	return projects, nil
 }
 
+// withLegacyName names a project stored before projects had names after its
+// ID, which was its name then.
+func withLegacyName(project models.Project) models.Project {
+	if project.Name == "" {
+		project.Name = project.ID
+	}
+	return project
+}
+
 func (repo *CassandraTaskRepository) DeleteProject(username, project string) error {
-	query := "DELETE FROM tasks WHERE username = ? AND project = ?"
+	var name string
+	var deletedAt time.Time
+	query := "SELECT name, deleted_at FROM projects WHERE username = ? AND project = ?"
+	if err := repo.session.Query(query, username, project).Scan(&name, &deletedAt); err != nil && err != gocql.ErrNotFound {
+		return fmt.Errorf("error reading project %s for user %s: %w", project, username, err)
+	}
+	query = "DELETE FROM tasks WHERE username = ? AND project = ?"
 	err := repo.session.Query(query, username, project).Exec()
 	if err != nil {
 		log.Printf("Error deleting tasks in project %s for user %s: %v", project, username, err)
@@ -123,6 +516,9 @@ func (repo *CassandraTaskRepository) DeleteProject(username, project string) err
 		log.Printf("Error deleting project entry %s for user %s: %v", project, username, err)
 		return fmt.Errorf("error deleting project entry %s for user %s: %w", project, username, err)
 	}
+	if !deletedAt.IsZero() {
+		return repo.unindexTrashedProject(username, project, name, deletedAt)
+	}
 	return nil
 }
 
@@ -131,3 +527,310 @@ func (repo *CassandraTaskRepository) DeleteUserTasks(username string) error {
 	err := repo.session.Query(query, username).Exec()
 	return err
 }
+
+// DeleteUserProjects leaves the user's trash index rows to the sweeper,
+// which drops them once it finds their rows gone.
+func (repo *CassandraTaskRepository) DeleteUserProjects(username string) error {
+	query := "DELETE FROM projects WHERE username = ?"
+	if err := repo.session.Query(query, username).Exec(); err != nil {
+		return err
+	}
+	return repo.session.Query("DELETE FROM trashed_projects WHERE username = ?", username).Exec()
+}
+
+// recurrenceColumn encodes a task's recurrence for the recurrence column, as
+// JSON. No recurrence, nil or the zero Recurrence of an update clearing it,
+// is stored as null.
+func recurrenceColumn(r *models.Recurrence) interface{} {
+	if r == nil || *r == (models.Recurrence{}) {
+		return nil
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil
+	}
+	return string(b)
+}
+
+// scanRecurrence decodes the recurrence column, empty when null.
+func scanRecurrence(column string) *models.Recurrence {
+	if column == "" {
+		return nil
+	}
+	var r models.Recurrence
+	if err := json.Unmarshal([]byte(column), &r); err != nil {
+		log.Printf("Error decoding recurrence %q: %v", column, err)
+		return nil
+	}
+	return &r
+}
+
+// trashShards is the number of partitions of the trash index, which the
+// sweeper reads in full instead of filtering the tasks and projects tables.
+const trashShards = 16
+
+func trashShard(username string) int {
+	h := fnv.New32a()
+	h.Write([]byte(username))
+	return int(h.Sum32() % trashShards)
+}
+
+func (repo *CassandraTaskRepository) TrashTask(username, project, taskID string, deletedAt time.Time) error {
+	query := "UPDATE tasks SET deleted_at = ? WHERE username = ? AND project = ? AND id = ? IF EXISTS"
+	applied, err := repo.session.Query(query, deletedAt, username, project, taskID).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return fmt.Errorf("task %s not found in project %s", taskID, project)
+	}
+	index := "INSERT INTO trash (shard, deleted_at, username, project, id) VALUES (?, ?, ?, ?, ?)"
+	return repo.session.Query(index, trashShard(username), deletedAt, username, project, taskID).Exec()
+}
+
+func (repo *CassandraTaskRepository) TrashProject(username, project string, deletedAt time.Time) error {
+	var name string
+	query := "SELECT name FROM projects WHERE username = ? AND project = ?"
+	if err := repo.session.Query(query, username, project).Scan(&name); err != nil {
+		if err == gocql.ErrNotFound {
+			return fmt.Errorf("project %s does not exist for user %s", project, username)
+		}
+		return err
+	}
+	query = "UPDATE projects SET deleted_at = ? WHERE username = ? AND project = ? IF EXISTS"
+	applied, err := repo.session.Query(query, deletedAt, username, project).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return fmt.Errorf("project %s does not exist for user %s", project, username)
+	}
+	name = withLegacyName(models.Project{ID: project, Name: name}).Name
+	batch := repo.session.NewBatch(gocql.LoggedBatch)
+	batch.Query("INSERT INTO trash (shard, deleted_at, username, project, id) VALUES (?, ?, ?, ?, '')",
+		trashShard(username), deletedAt, username, project)
+	batch.Query("INSERT INTO trashed_projects (username, name, project) VALUES (?, ?, ?)", username, name, project)
+	return repo.session.ExecuteBatch(batch)
+}
+
+// unindexTrashedProject removes a project that left the trash from the
+// trash index and the trashed_projects lookup.
+func (repo *CassandraTaskRepository) unindexTrashedProject(username, project, name string, deletedAt time.Time) error {
+	name = withLegacyName(models.Project{ID: project, Name: name}).Name
+	batch := repo.session.NewBatch(gocql.LoggedBatch)
+	batch.Query("DELETE FROM trash WHERE shard = ? AND deleted_at = ? AND username = ? AND project = ? AND id = ''",
+		trashShard(username), deletedAt, username, project)
+	batch.Query("DELETE FROM trashed_projects WHERE username = ? AND name = ? AND project = ?", username, name, project)
+	return repo.session.ExecuteBatch(batch)
+}
+
+func (repo *CassandraTaskRepository) RestoreTask(username, project, taskID string) error {
+	var deletedAt time.Time
+	query := "SELECT deleted_at FROM tasks WHERE username = ? AND project = ? AND id = ?"
+	if err := repo.session.Query(query, username, project, taskID).Scan(&deletedAt); err != nil {
+		if err == gocql.ErrNotFound {
+			return ErrNotInTrash
+		}
+		return err
+	}
+	if deletedAt.IsZero() {
+		return ErrNotInTrash
+	}
+	if _, trashed, err := repo.projectState(username, project); err != nil {
+		return err
+	} else if trashed {
+		return ErrProjectTrashed
+	}
+	query = "UPDATE tasks SET deleted_at = null WHERE username = ? AND project = ? AND id = ?"
+	if err := repo.session.Query(query, username, project, taskID).Exec(); err != nil {
+		return err
+	}
+	index := "DELETE FROM trash WHERE shard = ? AND deleted_at = ? AND username = ? AND project = ? AND id = ?"
+	return repo.session.Query(index, trashShard(username), deletedAt, username, project, taskID).Exec()
+}
+
+func (repo *CassandraTaskRepository) RestoreProject(username, project string) error {
+	var name string
+	var deletedAt time.Time
+	query := "SELECT name, deleted_at FROM projects WHERE username = ? AND project = ?"
+	if err := repo.session.Query(query, username, project).Scan(&name, &deletedAt); err != nil {
+		if err == gocql.ErrNotFound {
+			return ErrNotInTrash
+		}
+		return err
+	}
+	if deletedAt.IsZero() {
+		return ErrNotInTrash
+	}
+	query = "UPDATE projects SET deleted_at = null WHERE username = ? AND project = ?"
+	if err := repo.session.Query(query, username, project).Exec(); err != nil {
+		return err
+	}
+	return repo.unindexTrashedProject(username, project, name, deletedAt)
+}
+
+func (repo *CassandraTaskRepository) FindTrashedProjects(username, name string) ([]string, error) {
+	var ids []string
+	iter := repo.session.Query("SELECT project FROM trashed_projects WHERE username = ? AND name = ?", username, name).Iter()
+	var project string
+	for iter.Scan(&project) {
+		ids = append(ids, project)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error looking up trashed project %s for user %s: %w", name, username, err)
+	}
+	return ids, nil
+}
+
+func (repo *CassandraTaskRepository) ListTrash(username string) ([]models.TrashItem, error) {
+	items := []models.TrashItem{}
+	trashedProjects := make(map[string]int)
+	names := make(map[string]string)
+
+	query := "SELECT project, name, parent, deleted_at FROM projects WHERE username = ?"
+	iter := repo.session.Query(query, username).Iter()
+	var project, name, parent string
+	var deletedAt time.Time
+	for iter.Scan(&project, &name, &parent, &deletedAt) {
+		names[project] = withLegacyName(models.Project{ID: project, Name: name}).Name
+		if !deletedAt.IsZero() {
+			trashedProjects[project] = len(items)
+			items = append(items, models.TrashItem{Kind: models.TrashKindProject, Project: project, ProjectName: names[project], Parent: parent, DeletedAt: deletedAt})
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error listing trashed projects for user %s: %w", username, err)
+	}
+
+	query = "SELECT project, id, content, priority, updated_time, due, completed, recurrence, deleted_at FROM tasks WHERE username = ?"
+	iter = repo.session.Query(query, username).Iter()
+	var task models.Task
+	var recurrence string
+	for iter.Scan(&project, &task.ID, &task.Content, &task.Priority, &task.UpdatedTime, &task.Due, &task.Completed, &recurrence, &deletedAt) {
+		if !deletedAt.IsZero() {
+			trashedTask := task
+			trashedTask.Recurrence = scanRecurrence(recurrence)
+			items = append(items, models.TrashItem{Kind: models.TrashKindTask, Project: project, ProjectName: names[project], Task: &trashedTask, DeletedAt: deletedAt})
+		} else if i, ok := trashedProjects[project]; ok {
+			items[i].TaskCount++
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error listing trashed tasks for user %s: %w", username, err)
+	}
+	return items, nil
+}
+
+func (repo *CassandraTaskRepository) EmptyTrash(username string, before time.Time) (int, error) {
+	items, err := repo.ListTrash(username)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, item := range items {
+		if !item.DeletedAt.Before(before) {
+			continue
+		}
+		if item.Kind == models.TrashKindProject {
+			err = repo.DeleteProject(username, item.Project)
+		} else {
+			err = repo.DeleteTask(username, item.Project, item.Task.ID)
+		}
+		if err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// EmptyAllTrash reads the trash index. Its entries may be stale, left by
+// deletes and by puts of trashed tasks; each purge only applies while the
+// row is still trashed at the indexed time, and stale entries are dropped.
+func (repo *CassandraTaskRepository) EmptyAllTrash(before time.Time) ([]PurgedItem, error) {
+	shards := make([]int, trashShards)
+	for i := range shards {
+		shards[i] = i
+	}
+	type entry struct {
+		deletedAt time.Time
+		item      PurgedItem
+	}
+	var entries []entry
+	query := "SELECT deleted_at, username, project, id FROM trash WHERE shard IN ? AND deleted_at < ?"
+	iter := repo.session.Query(query, shards, before).Iter()
+	var e entry
+	for iter.Scan(&e.deletedAt, &e.item.Username, &e.item.Project, &e.item.TaskID) {
+		entries = append(entries, e)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error scanning the trash index: %w", err)
+	}
+
+	var purged []PurgedItem
+	for _, e := range entries {
+		var applied bool
+		var err error
+		if e.item.TaskID == "" {
+			applied, err = repo.purgeTrashedProject(e.item.Username, e.item.Project, e.deletedAt)
+		} else {
+			applied, err = repo.purgeTrashedTask(e.item.Username, e.item.Project, e.item.TaskID, e.deletedAt)
+		}
+		if err != nil {
+			return purged, err
+		}
+		if applied {
+			purged = append(purged, e.item)
+		}
+	}
+	return purged, nil
+}
+
+// purgeTrashedTask deletes the task if it is still trashed at deletedAt, and
+// its trash index entry either way.
+func (repo *CassandraTaskRepository) purgeTrashedTask(username, project, taskID string, deletedAt time.Time) (bool, error) {
+	query := "DELETE FROM tasks WHERE username = ? AND project = ? AND id = ? IF deleted_at = ?"
+	applied, err := repo.session.Query(query, username, project, taskID, deletedAt).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return false, fmt.Errorf("error purging task %s in project %s for user %s: %w", taskID, project, username, err)
+	}
+	index := "DELETE FROM trash WHERE shard = ? AND deleted_at = ? AND username = ? AND project = ? AND id = ?"
+	return applied, repo.session.Query(index, trashShard(username), deletedAt, username, project, taskID).Exec()
+}
+
+// purgeTrashedProject deletes the project and its tasks if it is still
+// trashed at deletedAt, and its trash index entry either way.
+func (repo *CassandraTaskRepository) purgeTrashedProject(username, project string, deletedAt time.Time) (bool, error) {
+	var name string
+	query := "SELECT name FROM projects WHERE username = ? AND project = ?"
+	if err := repo.session.Query(query, username, project).Scan(&name); err != nil && err != gocql.ErrNotFound {
+		return false, fmt.Errorf("error reading project %s for user %s: %w", project, username, err)
+	}
+	query = "DELETE FROM projects WHERE username = ? AND project = ? IF deleted_at = ?"
+	applied, err := repo.session.Query(query, username, project, deletedAt).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return false, fmt.Errorf("error purging project %s for user %s: %w", project, username, err)
+	}
+	if !applied {
+		index := "DELETE FROM trash WHERE shard = ? AND deleted_at = ? AND username = ? AND project = ? AND id = ''"
+		return false, repo.session.Query(index, trashShard(username), deletedAt, username, project).Exec()
+	}
+	query = "DELETE FROM tasks WHERE username = ? AND project = ?"
+	if err := repo.session.Query(query, username, project).Exec(); err != nil {
+		return true, fmt.Errorf("error deleting tasks in project %s for user %s: %w", project, username, err)
+	}
+	return true, repo.unindexTrashedProject(username, project, name, deletedAt)
+}
+
+// projectState reports whether the project row exists and whether it is in the trash.
+func (repo *CassandraTaskRepository) projectState(username, project string) (exists, trashed bool, err error) {
+	var deletedAt time.Time
+	query := "SELECT deleted_at FROM projects WHERE username = ? AND project = ?"
+	if err := repo.session.Query(query, username, project).Scan(&deletedAt); err != nil {
+		if err == gocql.ErrNotFound {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	return true, !deletedAt.IsZero(), nil
+}
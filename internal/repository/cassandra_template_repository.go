@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"todolist/internal/models"
+
+	"github.com/gocql/gocql"
+)
+
+type CassandraTemplateRepository struct {
+	session *gocql.Session
+}
+
+func NewCassandraTemplateRepository(session *gocql.Session) *CassandraTemplateRepository {
+	return &CassandraTemplateRepository{session: session}
+}
+
+// CreateTemplate stores the template's tasks as one JSON document; they are
+// only ever read and written together.
+func (repo *CassandraTemplateRepository) CreateTemplate(username string, template models.Template) error {
+	tasks, err := json.Marshal(template.Tasks)
+	if err != nil {
+		return fmt.Errorf("error encoding template tasks: %w", err)
+	}
+	query := "INSERT INTO templates (username, id, name, description, color, created, tasks) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	return repo.session.Query(query, username, template.ID, template.Name, template.Description, template.Color,
+		template.Created, string(tasks)).Exec()
+}
+
+func (repo *CassandraTemplateRepository) ListTemplates(username string) ([]models.Template, error) {
+	templates := []models.Template{}
+	query := "SELECT id, name, description, color, created, tasks FROM templates WHERE username = ?"
+	iter := repo.session.Query(query, username).Iter()
+
+	var template models.Template
+	var tasks string
+	for iter.Scan(&template.ID, &template.Name, &template.Description, &template.Color, &template.Created, &tasks) {
+		template.Tasks = nil
+		if err := json.Unmarshal([]byte(tasks), &template.Tasks); err != nil {
+			iter.Close()
+			return nil, fmt.Errorf("error decoding tasks of template %s: %w", template.ID, err)
+		}
+		templates = append(templates, template)
+	}
+	if err := iter.Close(); err != nil {
+		log.Printf("Error iterating over templates for user %s: %v", username, err)
+		return nil, fmt.Errorf("error listing templates for user %s: %w", username, err)
+	}
+	return templates, nil
+}
+
+func (repo *CassandraTemplateRepository) DeleteTemplate(username, id string) error {
+	query := "DELETE FROM templates WHERE username = ? AND id = ?"
+	return repo.session.Query(query, username, id).Exec()
+}
+
+func (repo *CassandraTemplateRepository) DeleteUserTemplates(username string) error {
+	query := "DELETE FROM templates WHERE username = ?"
+	return repo.session.Query(query, username).Exec()
+}
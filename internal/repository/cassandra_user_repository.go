@@ -4,6 +4,8 @@ package repository
 import (
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"time"
 	"todolist/internal/models"
 
 	"github.com/gocql/gocql"
@@ -26,17 +28,25 @@ func (repo *CassandraUserRepository) AddUser(user models.User) error {
 		return errors.New("user must be active upon creation")
 	}
 
-	query := "INSERT INTO users (username, password, active) VALUES (?, ?, ?)"
-	if err := repo.session.Query(query, user.Username, user.Password, user.Active).Exec(); err != nil {
+	// IF NOT EXISTS turns the insert into a lightweight transaction so that a
+	// second registration of the same name cannot overwrite the first.
+	query := "INSERT INTO users (username, password, active) VALUES (?, ?, ?) IF NOT EXISTS"
+	applied, err := repo.session.Query(query, user.Username, user.Password, user.Active).MapScanCAS(map[string]interface{}{})
+	if err != nil {
 		return err
 	}
+	if !applied {
+		return ErrUserExists
+	}
 	return nil
 }
 
 func (repo *CassandraUserRepository) GetUser(username string) (models.User, error) {
 	var user models.User
-	query := "SELECT username, password, active FROM users WHERE username = ?"
-	if err := repo.session.Query(query, username).Scan(&user.Username, &user.Password, &user.Active); err != nil {
+	query := "SELECT username, password, active, deactivated_at, time_zone, week_start, locale, date_format FROM users WHERE username = ?"
+	s := &user.Settings
+	if err := repo.session.Query(query, username).Scan(&user.Username, &user.Password, &user.Active, &user.DeactivatedAt,
+		&s.TimeZone, &s.WeekStart, &s.Locale, &s.DateFormat); err != nil {
 		if err == gocql.ErrNotFound {
 			return models.User{}, errors.New("user not found")
 		}
@@ -65,10 +75,189 @@ func (repo *CassandraUserRepository) Authenticate(username, password string) boo
 	return user.Password == password
 }
 
+// deactivationShards is the number of partitions of the deactivated_users
+// index, which the purger reads in full instead of filtering the users table.
+const deactivationShards = 16
+
+func deactivationShard(username string) int {
+	h := fnv.New32a()
+	h.Write([]byte(username))
+	return int(h.Sum32() % deactivationShards)
+}
+
 func (repo *CassandraUserRepository) DeactivateUser(username string) error {
-	query := "UPDATE users SET active = false WHERE username = ?"
-	if err := repo.session.Query(query, username).Exec(); err != nil {
+	now := time.Now().Truncate(time.Millisecond)
+	query := "UPDATE users SET active = false, deactivated_at = ? WHERE username = ? IF EXISTS"
+	applied, err := repo.session.Query(query, now, username).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return errors.New("user not found")
+	}
+	index := "INSERT INTO deactivated_users (shard, deactivated_at, username) VALUES (?, ?, ?)"
+	return repo.session.Query(index, deactivationShard(username), now, username).Exec()
+}
+
+// ReactivateUser only applies while the account is deactivated at the time
+// read and not claimed by the purger, so that it cannot race a purge.
+func (repo *CassandraUserRepository) ReactivateUser(username string) error {
+	var deactivatedAt time.Time
+	if err := repo.session.Query("SELECT deactivated_at FROM users WHERE username = ?", username).Scan(&deactivatedAt); err != nil {
+		if err == gocql.ErrNotFound {
+			return errors.New("user not found")
+		}
+		return err
+	}
+	query := "UPDATE users SET active = true, deactivated_at = null WHERE username = ? IF deactivated_at = ? AND purge_started = null"
+	existing := map[string]interface{}{}
+	applied, err := repo.session.Query(query, username, deactivatedAt).MapScanCAS(existing)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		if started, _ := existing["purge_started"].(time.Time); !started.IsZero() {
+			return ErrUserPurging
+		}
+		return errors.New("user changed while reactivating, try again")
+	}
+	return repo.deleteDeactivation(username, deactivatedAt)
+}
+
+func (repo *CassandraUserRepository) deleteDeactivation(username string, deactivatedAt time.Time) error {
+	if deactivatedAt.IsZero() {
+		return nil
+	}
+	query := "DELETE FROM deactivated_users WHERE shard = ? AND deactivated_at = ? AND username = ?"
+	return repo.session.Query(query, deactivationShard(username), deactivatedAt, username).Exec()
+}
+
+func (repo *CassandraUserRepository) UpdateSettings(username string, settings models.UserSettings) error {
+	query := "UPDATE users SET time_zone = ?, week_start = ?, locale = ?, date_format = ? WHERE username = ? IF EXISTS"
+	applied, err := repo.session.Query(query, settings.TimeZone, settings.WeekStart, settings.Locale, settings.DateFormat,
+		username).MapScanCAS(map[string]interface{}{})
+	if err != nil {
 		return err
 	}
+	if !applied {
+		return errors.New("user not found")
+	}
 	return nil
 }
+
+// ListDeactivatedUsers reads the deactivated_users index. Its entries may be
+// stale; ClaimForPurge checks them against the users table.
+func (repo *CassandraUserRepository) ListDeactivatedUsers(before time.Time) ([]models.User, error) {
+	shards := make([]int, deactivationShards)
+	for i := range shards {
+		shards[i] = i
+	}
+	var users []models.User
+	query := "SELECT username, deactivated_at FROM deactivated_users WHERE shard IN ? AND deactivated_at < ?"
+	iter := repo.session.Query(query, shards, before).Iter()
+
+	user := models.User{Active: false}
+	for iter.Scan(&user.Username, &user.DeactivatedAt) {
+		users = append(users, user)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error listing deactivated users: %w", err)
+	}
+	return users, nil
+}
+
+// ClaimForPurge sets purge_started with a lightweight transaction that only
+// applies while the account is still deactivated at deactivatedAt. An index
+// entry that no longer matches the account is dropped.
+func (repo *CassandraUserRepository) ClaimForPurge(username string, deactivatedAt time.Time) (bool, error) {
+	query := "UPDATE users SET purge_started = ? WHERE username = ? IF active = false AND deactivated_at = ?"
+	applied, err := repo.session.Query(query, time.Now(), username, deactivatedAt).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return false, err
+	}
+	if !applied {
+		return false, repo.deleteDeactivation(username, deactivatedAt)
+	}
+	return true, nil
+}
+
+// AddToken writes the token with a TTL, so Cassandra drops it once it has
+// expired.
+func (repo *CassandraUserRepository) AddToken(username string, token models.AuthToken) error {
+	ttl := int(time.Until(token.Expires) / time.Second)
+	if ttl <= 0 {
+		return nil
+	}
+	query := "INSERT INTO auth_tokens (username, token_hash, created, expires) VALUES (?, ?, ?, ?) USING TTL ?"
+	return repo.session.Query(query, username, token.Hash, token.Created, token.Expires, ttl).Exec()
+}
+
+func (repo *CassandraUserRepository) GetToken(username, hash string) (models.AuthToken, bool, error) {
+	token := models.AuthToken{Hash: hash}
+	query := "SELECT created, expires FROM auth_tokens WHERE username = ? AND token_hash = ?"
+	if err := repo.session.Query(query, username, hash).Scan(&token.Created, &token.Expires); err != nil {
+		if err == gocql.ErrNotFound {
+			return models.AuthToken{}, false, nil
+		}
+		return models.AuthToken{}, false, err
+	}
+	if !time.Now().Before(token.Expires) {
+		return models.AuthToken{}, false, nil
+	}
+	return token, true, nil
+}
+
+func (repo *CassandraUserRepository) DeleteToken(username, hash string) error {
+	return repo.session.Query("DELETE FROM auth_tokens WHERE username = ? AND token_hash = ?", username, hash).Exec()
+}
+
+func (repo *CassandraUserRepository) DeleteUserTokens(username string) error {
+	return repo.session.Query("DELETE FROM auth_tokens WHERE username = ?", username).Exec()
+}
+
+func (repo *CassandraUserRepository) ListUsers() ([]models.User, error) {
+	var users []models.User
+	iter := repo.session.Query("SELECT username, password, active, deactivated_at FROM users").Iter()
+
+	var user models.User
+	for iter.Scan(&user.Username, &user.Password, &user.Active, &user.DeactivatedAt) {
+		users = append(users, user)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error listing users: %w", err)
+	}
+	return users, nil
+}
+
+func (repo *CassandraUserRepository) DeleteUser(username string) error {
+	var deactivatedAt time.Time
+	if err := repo.session.Query("SELECT deactivated_at FROM users WHERE username = ?", username).Scan(&deactivatedAt); err != nil {
+		if err == gocql.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	if _, err := repo.session.Query("DELETE FROM users WHERE username = ? IF EXISTS", username).MapScanCAS(map[string]interface{}{}); err != nil {
+		return err
+	}
+	return repo.deleteDeactivation(username, deactivatedAt)
+}
+
+func (repo *CassandraUserRepository) AddAuditRecord(record models.AuditRecord) error {
+	query := "INSERT INTO audit_log (username, event_time, action, detail) VALUES (?, ?, ?, ?)"
+	return repo.session.Query(query, record.Username, record.Time, record.Action, record.Detail).Exec()
+}
+
+func (repo *CassandraUserRepository) ListAuditRecords(username string) ([]models.AuditRecord, error) {
+	var records []models.AuditRecord
+	iter := repo.session.Query("SELECT username, event_time, action, detail FROM audit_log WHERE username = ?", username).Iter()
+
+	var record models.AuditRecord
+	for iter.Scan(&record.Username, &record.Time, &record.Action, &record.Detail) {
+		records = append(records, record)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error listing audit records: %w", err)
+	}
+	return records, nil
+}
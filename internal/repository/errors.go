@@ -0,0 +1,32 @@
+package repository
+
+import "errors"
+
+// ErrUserExists is returned when registering a username that is already taken.
+var ErrUserExists = errors.New("user already exists")
+
+// ErrUserPurging is returned when reactivating an account the purger has
+// started deleting.
+var ErrUserPurging = errors.New("user is being purged")
+
+// ErrProjectExists is returned when creating a project under an ID that is
+// already taken.
+var ErrProjectExists = errors.New("project already exists")
+
+// ErrProjectTrashed is returned when a project is in the trash and the
+// operation needs it to be live (creating it again, restoring a task in it).
+var ErrProjectTrashed = errors.New("project is in the trash")
+
+// ErrNotInTrash is returned when restoring or purging an item that is not in the trash.
+var ErrNotInTrash = errors.New("item is not in the trash")
+
+// ErrLeaseLost is returned when storing the response for an idempotency key,
+// or releasing it, after another request took the key over.
+var ErrLeaseLost = errors.New("idempotency key lease was lost")
+
+// ErrTaskChanged is returned by ApplyTaskWrites when a checked task is no
+// longer as the writes were planned against.
+var ErrTaskChanged = errors.New("task changed since the writes were planned")
+
+// ErrChangeNotFound is returned when a history entry does not exist or has expired.
+var ErrChangeNotFound = errors.New("change not found")
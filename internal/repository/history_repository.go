@@ -0,0 +1,16 @@
+package repository
+
+import "todolist/internal/models"
+
+// HistoryRepository stores the append-only change history of a user's tasks.
+// Entries are returned oldest first.
+type HistoryRepository interface {
+	AddChange(username string, change models.TaskChange) error
+	GetChange(username, changeID string) (models.TaskChange, error)
+	ListTaskHistory(username, project, taskID string) ([]models.TaskChange, error)
+	ListProjectHistory(username, project string) ([]models.TaskChange, error)
+	// MoveTaskHistory moves the entries of a task to another project, for
+	// when the task itself moves there.
+	MoveTaskHistory(username, from, to, taskID string) error
+	DeleteUserHistory(username string) error
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"time"
+	"todolist/internal/models"
+)
+
+// IdempotencyRepository stores the responses of writes made with an
+// idempotency key. Records expire after the repository's TTL.
+type IdempotencyRepository interface {
+	// ReserveKey stores rec unless its key is already taken by the user; it
+	// then returns the stored record and false.
+	ReserveKey(username string, rec models.IdempotencyRecord) (models.IdempotencyRecord, bool, error)
+	// TakeOverKey replaces the record of rec's key with rec if its request
+	// still holds the lease ending at lapsed, and reports whether it did.
+	TakeOverKey(username string, rec models.IdempotencyRecord, lapsed time.Time) (bool, error)
+	// SaveResponse stores the response in rec if the key is still held with
+	// the lease rec.LeaseUntil, and ErrLeaseLost otherwise.
+	SaveResponse(username string, rec models.IdempotencyRecord) error
+	// ReleaseKey deletes the key if it is still held with the lease ending at
+	// lease, and returns ErrLeaseLost otherwise.
+	ReleaseKey(username, key string, lease time.Time) error
+	DeleteUserKeys(username string) error
+}
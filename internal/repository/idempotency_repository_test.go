@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"todolist/internal/models"
+)
+
+func TestIdempotencyKeyLease(t *testing.T) {
+	repos := map[string]func(t *testing.T) IdempotencyRepository{
+		"inmem": func(*testing.T) IdempotencyRepository { return NewInMemIdempotencyRepository(time.Hour) },
+		"cassandra": func(t *testing.T) IdempotencyRepository {
+			return NewCassandraIdempotencyRepository(cassandraSession(t), time.Hour)
+		},
+	}
+	for name, newRepo := range repos {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			username := fmt.Sprintf("idem_%d", time.Now().UnixNano())
+			t.Cleanup(func() { repo.DeleteUserKeys(username) })
+
+			now := time.Now().Truncate(time.Millisecond)
+			first := models.IdempotencyRecord{Key: "k1", Fingerprint: "fp", CreatedAt: now, LeaseUntil: now.Add(-time.Second)}
+			if _, reserved, err := repo.ReserveKey(username, first); err != nil || !reserved {
+				t.Fatalf("ReserveKey = %v, %v, want reserved", reserved, err)
+			}
+			retry := first
+			retry.LeaseUntil = now.Add(time.Minute)
+			stored, reserved, err := repo.ReserveKey(username, retry)
+			if err != nil || reserved {
+				t.Fatalf("ReserveKey of a taken key = %v, %v, want not reserved", reserved, err)
+			}
+			if !stored.LeaseUntil.Equal(first.LeaseUntil) || stored.Fingerprint != "fp" || stored.Status != 0 {
+				t.Errorf("stored record = %+v, want the first one in progress", stored)
+			}
+
+			if ok, err := repo.TakeOverKey(username, retry, now); err != nil || ok {
+				t.Errorf("TakeOverKey with the wrong lease = %v, %v, want refused", ok, err)
+			}
+			if ok, err := repo.TakeOverKey(username, retry, first.LeaseUntil); err != nil || !ok {
+				t.Fatalf("TakeOverKey = %v, %v, want taken over", ok, err)
+			}
+			if ok, _ := repo.TakeOverKey(username, retry, first.LeaseUntil); ok {
+				t.Error("a second TakeOverKey with the lapsed lease succeeded")
+			}
+
+			// The first request no longer holds the key.
+			late := first
+			late.Status, late.Body = 201, []byte("late")
+			if err := repo.SaveResponse(username, late); !errors.Is(err, ErrLeaseLost) {
+				t.Errorf("SaveResponse with a lost lease = %v, want ErrLeaseLost", err)
+			}
+			if err := repo.ReleaseKey(username, "k1", first.LeaseUntil); !errors.Is(err, ErrLeaseLost) {
+				t.Errorf("ReleaseKey with a lost lease = %v, want ErrLeaseLost", err)
+			}
+
+			done := retry
+			done.Status, done.Body = 201, []byte("ok")
+			mustNil(t, repo.SaveResponse(username, done))
+			stored, _, err = repo.ReserveKey(username, first)
+			if err != nil || stored.Status != 201 || string(stored.Body) != "ok" || !stored.LeaseUntil.IsZero() {
+				t.Fatalf("stored record after SaveResponse = %+v, %v", stored, err)
+			}
+			if ok, _ := repo.TakeOverKey(username, first, retry.LeaseUntil); ok {
+				t.Error("TakeOverKey took over a key with a stored response")
+			}
+			if err := repo.ReleaseKey(username, "k1", retry.LeaseUntil); !errors.Is(err, ErrLeaseLost) {
+				t.Errorf("ReleaseKey of a key with a stored response = %v, want ErrLeaseLost", err)
+			}
+
+			second := models.IdempotencyRecord{Key: "k2", Fingerprint: "fp", CreatedAt: now, LeaseUntil: now.Add(time.Minute)}
+			repo.ReserveKey(username, second)
+			mustNil(t, repo.ReleaseKey(username, "k2", second.LeaseUntil))
+			if _, reserved, _ := repo.ReserveKey(username, second); !reserved {
+				t.Error("a released key could not be reserved again")
+			}
+		})
+	}
+}
+
+func TestInMemIdempotencySweepsEveryUser(t *testing.T) {
+	repo := NewInMemIdempotencyRepository(time.Hour)
+	old := time.Now().Add(-2 * time.Hour)
+	for _, username := range []string{"alice", "bob"} {
+		repo.ReserveKey(username, models.IdempotencyRecord{Key: "old", CreatedAt: old})
+	}
+	repo.ReserveKey("bob", models.IdempotencyRecord{Key: "new", CreatedAt: time.Now()})
+
+	// A reservation by anyone drops the expired records of every user.
+	repo.swept = time.Time{}
+	repo.ReserveKey("carol", models.IdempotencyRecord{Key: "k", CreatedAt: time.Now()})
+	if _, exists := repo.records["alice"]; exists {
+		t.Errorf("alice's expired records were kept: %v", repo.records["alice"])
+	}
+	if _, exists := repo.records["bob"]["old"]; exists {
+		t.Error("bob's expired record was kept")
+	}
+	if _, exists := repo.records["bob"]["new"]; !exists {
+		t.Error("bob's live record was dropped")
+	}
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+	"todolist/internal/models"
+)
+
+type InMemHistoryRepository struct {
+	mu      sync.RWMutex
+	ttl     time.Duration                             // zero keeps entries forever
+	changes map[string]map[string][]models.TaskChange // username -> project -> changes
+}
+
+func NewInMemHistoryRepository(ttl time.Duration) *InMemHistoryRepository {
+	return &InMemHistoryRepository{
+		ttl:     ttl,
+		changes: make(map[string]map[string][]models.TaskChange),
+	}
+}
+
+func (repo *InMemHistoryRepository) AddChange(username string, change models.TaskChange) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, exists := repo.changes[username]; !exists {
+		repo.changes[username] = make(map[string][]models.TaskChange)
+	}
+	repo.changes[username][change.Project] = append(repo.live(repo.changes[username][change.Project]), change)
+	return nil
+}
+
+func (repo *InMemHistoryRepository) GetChange(username, changeID string) (models.TaskChange, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	for _, changes := range repo.changes[username] {
+		for _, change := range repo.live(changes) {
+			if change.ID == changeID {
+				return change, nil
+			}
+		}
+	}
+	return models.TaskChange{}, ErrChangeNotFound
+}
+
+func (repo *InMemHistoryRepository) ListTaskHistory(username, project, taskID string) ([]models.TaskChange, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	changes := []models.TaskChange{}
+	for _, change := range repo.live(repo.changes[username][project]) {
+		if change.TaskID == taskID {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (repo *InMemHistoryRepository) ListProjectHistory(username, project string) ([]models.TaskChange, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return append([]models.TaskChange{}, repo.live(repo.changes[username][project])...), nil
+}
+
+func (repo *InMemHistoryRepository) MoveTaskHistory(username, from, to, taskID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	var kept, moved []models.TaskChange
+	for _, change := range repo.live(repo.changes[username][from]) {
+		if change.TaskID == taskID {
+			change.Project = to
+			moved = append(moved, change)
+		} else {
+			kept = append(kept, change)
+		}
+	}
+	if len(moved) == 0 {
+		return nil
+	}
+	if _, exists := repo.changes[username]; !exists {
+		repo.changes[username] = make(map[string][]models.TaskChange)
+	}
+	repo.changes[username][from] = kept
+	merged := append(repo.live(repo.changes[username][to]), moved...)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Time.Before(merged[j].Time) })
+	repo.changes[username][to] = merged
+	return nil
+}
+
+func (repo *InMemHistoryRepository) DeleteUserHistory(username string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	delete(repo.changes, username)
+	return nil
+}
+
+// live drops the entries that have outlived the TTL. Entries are kept in
+// insertion order, so the expired ones are always a prefix.
+func (repo *InMemHistoryRepository) live(changes []models.TaskChange) []models.TaskChange {
+	if repo.ttl == 0 {
+		return changes
+	}
+	cutoff := time.Now().Add(-repo.ttl)
+	for i, change := range changes {
+		if change.Time.After(cutoff) {
+			return changes[i:]
+		}
+	}
+	return nil
+}
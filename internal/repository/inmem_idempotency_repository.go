@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"sync"
+	"time"
+	"todolist/internal/models"
+)
+
+// idempotencySweepInterval is how often ReserveKey drops the expired records
+// of every user.
+const idempotencySweepInterval = time.Minute
+
+type InMemIdempotencyRepository struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	records map[string]map[string]models.IdempotencyRecord // username -> key -> record
+	swept   time.Time
+}
+
+func NewInMemIdempotencyRepository(ttl time.Duration) *InMemIdempotencyRepository {
+	return &InMemIdempotencyRepository{
+		ttl:     ttl,
+		records: make(map[string]map[string]models.IdempotencyRecord),
+	}
+}
+
+func (repo *InMemIdempotencyRepository) ReserveKey(username string, rec models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.sweep()
+	if stored, exists := repo.lookup(username, rec.Key); exists {
+		return stored, false, nil
+	}
+	repo.store(username, rec)
+	return rec, true, nil
+}
+
+func (repo *InMemIdempotencyRepository) TakeOverKey(username string, rec models.IdempotencyRecord, lapsed time.Time) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	stored, exists := repo.lookup(username, rec.Key)
+	if !exists || stored.Status != 0 || !stored.LeaseUntil.Equal(lapsed) {
+		return false, nil
+	}
+	repo.store(username, rec)
+	return true, nil
+}
+
+func (repo *InMemIdempotencyRepository) SaveResponse(username string, rec models.IdempotencyRecord) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	stored, exists := repo.lookup(username, rec.Key)
+	if !exists || stored.Status != 0 || !stored.LeaseUntil.Equal(rec.LeaseUntil) {
+		return ErrLeaseLost
+	}
+	rec.LeaseUntil = time.Time{}
+	repo.store(username, rec)
+	return nil
+}
+
+func (repo *InMemIdempotencyRepository) ReleaseKey(username, key string, lease time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	stored, exists := repo.lookup(username, key)
+	if !exists || stored.Status != 0 || !stored.LeaseUntil.Equal(lease) {
+		return ErrLeaseLost
+	}
+	delete(repo.records[username], key)
+	return nil
+}
+
+func (repo *InMemIdempotencyRepository) DeleteUserKeys(username string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	delete(repo.records, username)
+	return nil
+}
+
+// lookup returns the user's record for key unless it has outlived the TTL.
+func (repo *InMemIdempotencyRepository) lookup(username, key string) (models.IdempotencyRecord, bool) {
+	rec, exists := repo.records[username][key]
+	if !exists || repo.expired(rec, time.Now()) {
+		return models.IdempotencyRecord{}, false
+	}
+	return rec, true
+}
+
+func (repo *InMemIdempotencyRepository) store(username string, rec models.IdempotencyRecord) {
+	if _, exists := repo.records[username]; !exists {
+		repo.records[username] = make(map[string]models.IdempotencyRecord)
+	}
+	repo.records[username][rec.Key] = rec
+}
+
+func (repo *InMemIdempotencyRepository) expired(rec models.IdempotencyRecord, now time.Time) bool {
+	return rec.CreatedAt.Before(now.Add(-repo.ttl))
+}
+
+// sweep drops the records of every user that have outlived the TTL, at most
+// once per idempotencySweepInterval.
+func (repo *InMemIdempotencyRepository) sweep() {
+	now := time.Now()
+	if now.Sub(repo.swept) < idempotencySweepInterval {
+		return
+	}
+	repo.swept = now
+	for username, records := range repo.records {
+		for key, rec := range records {
+			if repo.expired(rec, now) {
+				delete(records, key)
+			}
+		}
+		if len(records) == 0 {
+			delete(repo.records, username)
+		}
+	}
+}
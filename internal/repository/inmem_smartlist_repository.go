@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"sync"
+	"todolist/internal/models"
+)
+
+type InMemSmartListRepository struct {
+	mu    sync.RWMutex
+	lists map[string]map[string]models.SmartList // username -> smart list ID -> smart list
+}
+
+func NewInMemSmartListRepository() *InMemSmartListRepository {
+	return &InMemSmartListRepository{lists: make(map[string]map[string]models.SmartList)}
+}
+
+func (repo *InMemSmartListRepository) SaveSmartList(username string, list models.SmartList) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, exists := repo.lists[username]; !exists {
+		repo.lists[username] = make(map[string]models.SmartList)
+	}
+	repo.lists[username][list.ID] = list
+	return nil
+}
+
+func (repo *InMemSmartListRepository) ListSmartLists(username string) ([]models.SmartList, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	lists := make([]models.SmartList, 0, len(repo.lists[username]))
+	for _, list := range repo.lists[username] {
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+func (repo *InMemSmartListRepository) DeleteSmartList(username, id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	delete(repo.lists[username], id)
+	return nil
+}
+
+func (repo *InMemSmartListRepository) DeleteUserSmartLists(username string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	delete(repo.lists, username)
+	return nil
+}
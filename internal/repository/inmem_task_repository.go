@@ -5,31 +5,57 @@ import (
 	"fmt"
 	"sync"
 	"time"
+	"todolist/internal/filter"
 	"todolist/internal/models"
 )
 
 type InMemTaskRepository struct {
 	mu       sync.RWMutex
 	tasks    map[string]map[string]map[string]models.Task
-	projects map[string]map[string]struct{} // username -> project -> struct{} for existence check
+	projects map[string]map[string]models.Project // username -> project ID -> project
+
+	trashedTasks    map[string]map[string]map[string]time.Time // username -> project -> task ID -> deleted at
+	trashedProjects map[string]map[string]time.Time            // username -> project -> deleted at
 }
 
 func NewInMemTaskRepository() *InMemTaskRepository {
 	return &InMemTaskRepository{
-		tasks:    make(map[string]map[string]map[string]models.Task),
-		projects: make(map[string]map[string]struct{}), // Initialize projects map
+		tasks:           make(map[string]map[string]map[string]models.Task),
+		projects:        make(map[string]map[string]models.Project),
+		trashedTasks:    make(map[string]map[string]map[string]time.Time),
+		trashedProjects: make(map[string]map[string]time.Time),
 	}
 }
 
-func (repo *InMemTaskRepository) CreateProject(username, project string) error {
+func (repo *InMemTaskRepository) CreateProject(username string, project models.Project) error {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
+	if _, trashed := repo.trashedProjects[username][project.ID]; trashed {
+		return ErrProjectTrashed
+	}
 	if _, exists := repo.projects[username]; !exists {
-		repo.projects[username] = make(map[string]struct{})
+		repo.projects[username] = make(map[string]models.Project)
 	}
-	if _, exists := repo.projects[username][project]; !exists {
-		repo.projects[username][project] = struct{}{}
+	if _, exists := repo.projects[username][project.ID]; exists {
+		return ErrProjectExists
+	}
+	repo.projects[username][project.ID] = project
+	return nil
+}
+
+func (repo *InMemTaskRepository) UpdateProject(username string, project models.Project) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	existing, exists := repo.projects[username][project.ID]
+	if !exists || !repo.projectLive(username, project.ID) {
+		return fmt.Errorf("project %s does not exist for user %s", project.ID, username)
 	}
+	existing.Name = project.Name
+	existing.Description = project.Description
+	existing.Color = project.Color
+	existing.Archived = project.Archived
+	existing.ParentID = project.ParentID
+	repo.projects[username][project.ID] = existing
 	return nil
 }
 
@@ -37,7 +63,7 @@ func (repo *InMemTaskRepository) CreateTask(username, project string, task model
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
-	if _, exists := repo.projects[username][project]; !exists {
+	if !repo.projectLive(username, project) {
 		return fmt.Errorf("project %s does not exist for user %s", project, username)
 	}
 
@@ -50,20 +76,20 @@ func (repo *InMemTaskRepository) CreateTask(username, project string, task model
 	}
 	task.UpdatedTime = time.Now()
 	repo.tasks[username][project][task.ID] = task
+	delete(repo.trashedTasks[username][project], task.ID)
 
 	return nil
 }
 
-func (repo *InMemTaskRepository) ListProjects(username string) ([]string, error) {
+func (repo *InMemTaskRepository) ListProjects(username string) ([]models.Project, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
-	if _, exists := repo.tasks[username]; !exists {
-		fmt.Println("User not found")
-		return []string{}, nil
-	}
-	projects := make([]string, 0, len(repo.projects[username]))
-	for project := range repo.projects[username] {
+	projects := make([]models.Project, 0, len(repo.projects[username]))
+	for id, project := range repo.projects[username] {
+		if _, trashed := repo.trashedProjects[username][id]; trashed {
+			continue
+		}
 		projects = append(projects, project)
 	}
 	return projects, nil
@@ -72,20 +98,55 @@ func (repo *InMemTaskRepository) ListProjects(username string) ([]string, error)
 func (repo *InMemTaskRepository) ListTasks(username, project string) ([]models.Task, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
+	return repo.liveTasks(username, project), nil
+}
+
+func (repo *InMemTaskRepository) ListTasksInProjects(username string, projects []string) (map[string][]models.Task, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	result := make(map[string][]models.Task, len(projects))
+	for _, project := range projects {
+		result[project] = repo.liveTasks(username, project)
+	}
+	return result, nil
+}
+
+func (repo *InMemTaskRepository) ListTasksMatching(username string, projects []models.Project, match filter.Expr) (map[string][]models.Task, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	result := make(map[string][]models.Task, len(projects))
+	for _, project := range projects {
+		matched := []models.Task{}
+		for _, task := range repo.liveTasks(username, project.ID) {
+			if match.Match(task, project) {
+				matched = append(matched, task)
+			}
+		}
+		result[project.ID] = matched
+	}
+	return result, nil
+}
+
+// liveTasks returns the tasks of a live project that are not in the trash.
+// The caller must hold the lock.
+func (repo *InMemTaskRepository) liveTasks(username, project string) []models.Task {
 	taskMap, exists := repo.tasks[username][project]
-	if !exists {
-		return []models.Task{}, nil
+	if !exists || !repo.projectLive(username, project) {
+		return []models.Task{}
 	}
 	tasks := make([]models.Task, 0, len(taskMap))
-	for _, task := range taskMap {
+	for id, task := range taskMap {
+		if _, trashed := repo.trashedTasks[username][project][id]; trashed {
+			continue
+		}
 		tasks = append(tasks, task)
 	}
-	return tasks, nil
+	return tasks
 }
 
 func (repo *InMemTaskRepository) UpdateTask(username, project string, task models.Task) error {
-	repo.mu.RLock()
-	defer repo.mu.RUnlock()
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
 	// Check if the task exists
 	if _, exists := repo.tasks[username][project][task.ID]; !exists {
 		return errors.New("task not found")
@@ -95,33 +156,122 @@ func (repo *InMemTaskRepository) UpdateTask(username, project string, task model
 	return nil
 }
 
+func (repo *InMemTaskRepository) UpdateTaskFields(username, project, taskID string, update models.TaskUpdate) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	task, exists := repo.tasks[username][project][taskID]
+	if !exists {
+		return errors.New("task not found")
+	}
+	task = update.Apply(task)
+	task.UpdatedTime = time.Now()
+	repo.tasks[username][project][taskID] = task
+	return nil
+}
+
+// ApplyTaskWrites checks every write before applying any, holding the lock
+// throughout, so the batch is applied as a whole or not at all and checked
+// writes see no change between their check and their write.
+func (repo *InMemTaskRepository) ApplyTaskWrites(username string, writes []TaskWrite) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	planned := newPlannedTasks(func(project, id string) (models.Task, bool) { return repo.liveTask(username, project, id) })
+	put := make(map[[2]string]bool) // tasks stored by earlier writes of the batch
+	for _, w := range writes {
+		if err := planned.apply(w); err != nil {
+			return err
+		}
+		key := [2]string{w.Project, w.Task.ID}
+		switch w.Kind {
+		case WritePut:
+			if !repo.projectLive(username, w.Project) {
+				return fmt.Errorf("project %s does not exist for user %s", w.Project, username)
+			}
+			put[key] = true
+		case WriteTrash:
+			if _, exists := repo.tasks[username][w.Project][w.Task.ID]; !exists && !put[key] {
+				return errors.New("task not found")
+			}
+		}
+	}
+
+	for _, w := range writes {
+		switch w.Kind {
+		case WritePut:
+			if _, exists := repo.tasks[username]; !exists {
+				repo.tasks[username] = make(map[string]map[string]models.Task)
+			}
+			if _, exists := repo.tasks[username][w.Project]; !exists {
+				repo.tasks[username][w.Project] = make(map[string]models.Task)
+			}
+			repo.tasks[username][w.Project][w.Task.ID] = w.Task
+			delete(repo.trashedTasks[username][w.Project], w.Task.ID)
+		case WriteTrash:
+			if _, exists := repo.trashedTasks[username]; !exists {
+				repo.trashedTasks[username] = make(map[string]map[string]time.Time)
+			}
+			if _, exists := repo.trashedTasks[username][w.Project]; !exists {
+				repo.trashedTasks[username][w.Project] = make(map[string]time.Time)
+			}
+			repo.trashedTasks[username][w.Project][w.Task.ID] = w.DeletedAt
+		case WriteDelete:
+			delete(repo.tasks[username][w.Project], w.Task.ID)
+			delete(repo.trashedTasks[username][w.Project], w.Task.ID)
+		}
+	}
+	return nil
+}
+
 func (repo *InMemTaskRepository) DeleteTask(username, project, taskID string) error {
-	repo.mu.RLock()
-	defer repo.mu.RUnlock()
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
 	delete(repo.tasks[username][project], taskID)
+	delete(repo.trashedTasks[username][project], taskID)
 	return nil
 }
 
 func (repo *InMemTaskRepository) DeleteProject(username, project string) error {
-	repo.mu.RLock()
-	defer repo.mu.RUnlock()
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
 	delete(repo.tasks[username], project)
 	delete(repo.projects[username], project)
+	delete(repo.trashedTasks[username], project)
+	delete(repo.trashedProjects[username], project)
 	return nil
 }
 
 func (repo *InMemTaskRepository) DeleteUserTasks(username string) error {
-	repo.mu.RLock()
-	defer repo.mu.RUnlock()
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
 	delete(repo.tasks, username)
+	delete(repo.trashedTasks, username)
+	return nil
+}
+
+func (repo *InMemTaskRepository) DeleteUserProjects(username string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	delete(repo.projects, username)
+	delete(repo.trashedProjects, username)
 	return nil
 }
 
 func (repo *InMemTaskRepository) GetTask(username, project, taskID string) (models.Task, bool) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
+	return repo.liveTask(username, project, taskID)
+}
+
+func (repo *InMemTaskRepository) liveTask(username, project, taskID string) (models.Task, bool) {
 	task, exists := repo.tasks[username][project][taskID]
-	return task, exists
+	if !exists || !repo.projectLive(username, project) {
+		return models.Task{}, false
+	}
+	if _, trashed := repo.trashedTasks[username][project][taskID]; trashed {
+		return models.Task{}, false
+	}
+	return task, true
 }
 
 func (repo *InMemTaskRepository) CompleteTask(username, project, taskID string) error {
@@ -134,6 +284,171 @@ func (repo *InMemTaskRepository) CompleteTask(username, project, taskID string)
 	}
 
 	task.Completed = true
+	task.UpdatedTime = time.Now()
 	repo.tasks[username][project][taskID] = task
 	return nil
 }
+
+func (repo *InMemTaskRepository) TrashTask(username, project, taskID string, deletedAt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.tasks[username][project][taskID]; !exists {
+		return errors.New("task not found")
+	}
+	if _, exists := repo.trashedTasks[username]; !exists {
+		repo.trashedTasks[username] = make(map[string]map[string]time.Time)
+	}
+	if _, exists := repo.trashedTasks[username][project]; !exists {
+		repo.trashedTasks[username][project] = make(map[string]time.Time)
+	}
+	repo.trashedTasks[username][project][taskID] = deletedAt
+	return nil
+}
+
+func (repo *InMemTaskRepository) TrashProject(username, project string, deletedAt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.projects[username][project]; !exists {
+		return fmt.Errorf("project %s does not exist for user %s", project, username)
+	}
+	if _, exists := repo.trashedProjects[username]; !exists {
+		repo.trashedProjects[username] = make(map[string]time.Time)
+	}
+	repo.trashedProjects[username][project] = deletedAt
+	return nil
+}
+
+func (repo *InMemTaskRepository) RestoreTask(username, project, taskID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, trashed := repo.trashedTasks[username][project][taskID]; !trashed {
+		return ErrNotInTrash
+	}
+	if _, trashed := repo.trashedProjects[username][project]; trashed {
+		return ErrProjectTrashed
+	}
+	delete(repo.trashedTasks[username][project], taskID)
+	return nil
+}
+
+func (repo *InMemTaskRepository) RestoreProject(username, project string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, trashed := repo.trashedProjects[username][project]; !trashed {
+		return ErrNotInTrash
+	}
+	delete(repo.trashedProjects[username], project)
+	return nil
+}
+
+func (repo *InMemTaskRepository) ListTrash(username string) ([]models.TrashItem, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	items := []models.TrashItem{}
+	for project, deletedAt := range repo.trashedProjects[username] {
+		count := 0
+		for id := range repo.tasks[username][project] {
+			if _, trashed := repo.trashedTasks[username][project][id]; !trashed {
+				count++
+			}
+		}
+		items = append(items, models.TrashItem{
+			Kind:        models.TrashKindProject,
+			Project:     project,
+			ProjectName: repo.projects[username][project].Name,
+			Parent:      repo.projects[username][project].ParentID,
+			TaskCount:   count,
+			DeletedAt:   deletedAt,
+		})
+	}
+	for project, trashed := range repo.trashedTasks[username] {
+		for id, deletedAt := range trashed {
+			task := repo.tasks[username][project][id]
+			items = append(items, models.TrashItem{
+				Kind:        models.TrashKindTask,
+				Project:     project,
+				ProjectName: repo.projects[username][project].Name,
+				Task:        &task,
+				DeletedAt:   deletedAt,
+			})
+		}
+	}
+	return items, nil
+}
+
+func (repo *InMemTaskRepository) FindTrashedProjects(username, name string) ([]string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var ids []string
+	for project := range repo.trashedProjects[username] {
+		if repo.projects[username][project].Name == name {
+			ids = append(ids, project)
+		}
+	}
+	return ids, nil
+}
+
+func (repo *InMemTaskRepository) EmptyTrash(username string, before time.Time) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return len(repo.emptyUserTrash(username, before)), nil
+}
+
+func (repo *InMemTaskRepository) EmptyAllTrash(before time.Time) ([]PurgedItem, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	users := make(map[string]struct{})
+	for username := range repo.trashedTasks {
+		users[username] = struct{}{}
+	}
+	for username := range repo.trashedProjects {
+		users[username] = struct{}{}
+	}
+	var purged []PurgedItem
+	for username := range users {
+		purged = append(purged, repo.emptyUserTrash(username, before)...)
+	}
+	return purged, nil
+}
+
+// emptyUserTrash permanently removes the user's trash items deleted before
+// the cutoff and returns them. The caller must hold the write lock.
+func (repo *InMemTaskRepository) emptyUserTrash(username string, before time.Time) []PurgedItem {
+	var purged []PurgedItem
+	for project, deletedAt := range repo.trashedProjects[username] {
+		if deletedAt.Before(before) {
+			delete(repo.tasks[username], project)
+			delete(repo.projects[username], project)
+			delete(repo.trashedTasks[username], project)
+			delete(repo.trashedProjects[username], project)
+			purged = append(purged, PurgedItem{Username: username, Project: project})
+		}
+	}
+	for project, trashed := range repo.trashedTasks[username] {
+		for id, deletedAt := range trashed {
+			if deletedAt.Before(before) {
+				delete(repo.tasks[username][project], id)
+				delete(trashed, id)
+				purged = append(purged, PurgedItem{Username: username, Project: project, TaskID: id})
+			}
+		}
+	}
+	return purged
+}
+
+// projectLive reports whether the project exists and is not in the trash.
+// The caller must hold the lock.
+func (repo *InMemTaskRepository) projectLive(username, project string) bool {
+	if _, exists := repo.projects[username][project]; !exists {
+		return false
+	}
+	_, trashed := repo.trashedProjects[username][project]
+	return !trashed
+}
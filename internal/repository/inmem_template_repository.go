@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"slices"
+	"sync"
+	"todolist/internal/models"
+)
+
+type InMemTemplateRepository struct {
+	mu        sync.RWMutex
+	templates map[string]map[string]models.Template // username -> template ID -> template
+}
+
+func NewInMemTemplateRepository() *InMemTemplateRepository {
+	return &InMemTemplateRepository{templates: make(map[string]map[string]models.Template)}
+}
+
+func (repo *InMemTemplateRepository) CreateTemplate(username string, template models.Template) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, exists := repo.templates[username]; !exists {
+		repo.templates[username] = make(map[string]models.Template)
+	}
+	template.Tasks = slices.Clone(template.Tasks)
+	repo.templates[username][template.ID] = template
+	return nil
+}
+
+func (repo *InMemTemplateRepository) ListTemplates(username string) ([]models.Template, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	templates := make([]models.Template, 0, len(repo.templates[username]))
+	for _, template := range repo.templates[username] {
+		template.Tasks = slices.Clone(template.Tasks)
+		templates = append(templates, template)
+	}
+	return templates, nil
+}
+
+func (repo *InMemTemplateRepository) DeleteTemplate(username, id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	delete(repo.templates[username], id)
+	return nil
+}
+
+func (repo *InMemTemplateRepository) DeleteUserTemplates(username string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	delete(repo.templates, username)
+	return nil
+}
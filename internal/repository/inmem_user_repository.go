@@ -3,17 +3,23 @@ package repository
 import (
 	"errors"
 	"sync"
+	"time"
 	"todolist/internal/models"
 )
 
 type InMemUserRepository struct {
-	mu    sync.RWMutex
-	users map[string]models.User
+	mu      sync.RWMutex
+	users   map[string]models.User
+	purging map[string]bool                        // usernames claimed by the purger
+	tokens  map[string]map[string]models.AuthToken // username -> hash -> token
+	audit   []models.AuditRecord
 }
 
 func NewInMemUserRepository() *InMemUserRepository {
 	return &InMemUserRepository{
-		users: make(map[string]models.User),
+		users:   make(map[string]models.User),
+		purging: make(map[string]bool),
+		tokens:  make(map[string]map[string]models.AuthToken),
 	}
 }
 
@@ -22,7 +28,7 @@ func (repo *InMemUserRepository) AddUser(user models.User) error {
 	defer repo.mu.Unlock()
 
 	if _, exists := repo.users[user.Username]; exists {
-		return errors.New("user already exists")
+		return ErrUserExists
 	}
 
 	repo.users[user.Username] = user
@@ -59,6 +65,147 @@ func (repo *InMemUserRepository) DeactivateUser(username string) error {
 	}
 
 	user.Active = false
+	user.DeactivatedAt = time.Now()
 	repo.users[username] = user
 	return nil
 }
+
+func (repo *InMemUserRepository) ReactivateUser(username string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.users[username]
+	if !exists {
+		return errors.New("user not found")
+	}
+	if repo.purging[username] {
+		return ErrUserPurging
+	}
+
+	user.Active = true
+	user.DeactivatedAt = time.Time{}
+	repo.users[username] = user
+	return nil
+}
+
+func (repo *InMemUserRepository) UpdateSettings(username string, settings models.UserSettings) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.users[username]
+	if !exists {
+		return errors.New("user not found")
+	}
+
+	user.Settings = settings
+	repo.users[username] = user
+	return nil
+}
+
+func (repo *InMemUserRepository) ListDeactivatedUsers(before time.Time) ([]models.User, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var users []models.User
+	for _, user := range repo.users {
+		if !user.Active && user.DeactivatedAt.Before(before) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (repo *InMemUserRepository) ClaimForPurge(username string, deactivatedAt time.Time) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.users[username]
+	if !exists || user.Active || !user.DeactivatedAt.Equal(deactivatedAt) {
+		return false, nil
+	}
+	repo.purging[username] = true
+	return true, nil
+}
+
+func (repo *InMemUserRepository) AddToken(username string, token models.AuthToken) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.tokens[username]; !exists {
+		repo.tokens[username] = make(map[string]models.AuthToken)
+	}
+	repo.tokens[username][token.Hash] = token
+	return nil
+}
+
+func (repo *InMemUserRepository) GetToken(username, hash string) (models.AuthToken, bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	token, exists := repo.tokens[username][hash]
+	if !exists {
+		return models.AuthToken{}, false, nil
+	}
+	if !time.Now().Before(token.Expires) {
+		delete(repo.tokens[username], hash)
+		return models.AuthToken{}, false, nil
+	}
+	return token, true, nil
+}
+
+func (repo *InMemUserRepository) DeleteToken(username, hash string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.tokens[username], hash)
+	return nil
+}
+
+func (repo *InMemUserRepository) DeleteUserTokens(username string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.tokens, username)
+	return nil
+}
+
+func (repo *InMemUserRepository) ListUsers() ([]models.User, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	users := make([]models.User, 0, len(repo.users))
+	for _, user := range repo.users {
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (repo *InMemUserRepository) DeleteUser(username string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.users, username)
+	delete(repo.purging, username)
+	return nil
+}
+
+func (repo *InMemUserRepository) AddAuditRecord(record models.AuditRecord) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.audit = append(repo.audit, record)
+	return nil
+}
+
+func (repo *InMemUserRepository) ListAuditRecords(username string) ([]models.AuditRecord, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var records []models.AuditRecord
+	for i := len(repo.audit) - 1; i >= 0; i-- {
+		if repo.audit[i].Username == username {
+			records = append(records, repo.audit[i])
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,12 @@
+package repository
+
+import "todolist/internal/models"
+
+// SmartListRepository stores a user's smart lists, keyed by smart list ID.
+type SmartListRepository interface {
+	// SaveSmartList stores list, replacing the one with its ID.
+	SaveSmartList(username string, list models.SmartList) error
+	ListSmartLists(username string) ([]models.SmartList, error)
+	DeleteSmartList(username, id string) error
+	DeleteUserSmartLists(username string) error
+}
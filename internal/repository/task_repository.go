@@ -1,16 +1,126 @@
 package repository
 
-import "todolist/internal/models"
+import (
+	"fmt"
+	"time"
+	"todolist/internal/filter"
+	"todolist/internal/models"
+)
 
+// TaskRepository stores tasks under the ID of their project; every project
+// parameter below is a project ID.
 type TaskRepository interface {
 	CreateTask(username, project string, task models.Task) error
-	CreateProject(username, project string) error
+	// CreateProject stores a new project. It fails with ErrProjectExists when
+	// project.ID is taken, and ErrProjectTrashed when that project is in the
+	// trash.
+	CreateProject(username string, project models.Project) error
+	// UpdateProject replaces the name, description, color, parent and
+	// archived flag of the live project with project.ID.
+	UpdateProject(username string, project models.Project) error
 	ListTasks(username, project string) ([]models.Task, error)
-	ListProjects(username string) ([]string, error)
+	// ListTasksInProjects lists the tasks of several projects at once, keyed
+	// by project; missing and trashed projects map to no tasks.
+	ListTasksInProjects(username string, projects []string) (map[string][]models.Task, error)
+	// ListTasksMatching lists the tasks of the given live projects for which
+	// match holds, keyed by project ID.
+	ListTasksMatching(username string, projects []models.Project, match filter.Expr) (map[string][]models.Task, error)
+	// ListProjects lists the live projects, archived ones included.
+	ListProjects(username string) ([]models.Project, error)
 	GetTask(username, project, taskID string) (models.Task, bool)
 	CompleteTask(username, project, taskID string) error
 	UpdateTask(username, project string, task models.Task) error
+	// UpdateTaskFields writes only the fields set in update.
+	UpdateTaskFields(username, project, taskID string, update models.TaskUpdate) error
+	// ApplyTaskWrites applies all of writes, in order, or none of them.
+	ApplyTaskWrites(username string, writes []TaskWrite) error
 	DeleteTask(username, project, taskID string) error
 	DeleteProject(username, project string) error
 	DeleteUserTasks(username string) error
+	DeleteUserProjects(username string) error
+
+	// Trashed tasks and projects are hidden from every listing and lookup
+	// above until they are restored or purged.
+	TrashTask(username, project, taskID string, deletedAt time.Time) error
+	TrashProject(username, project string, deletedAt time.Time) error
+	RestoreTask(username, project, taskID string) error
+	RestoreProject(username, project string) error
+	ListTrash(username string) ([]models.TrashItem, error)
+	// FindTrashedProjects returns the IDs of the user's trashed projects
+	// named name.
+	FindTrashedProjects(username, name string) ([]string, error)
+	EmptyTrash(username string, before time.Time) (int, error)
+	// EmptyAllTrash purges every user's trash items deleted before the
+	// cutoff and returns them.
+	EmptyAllTrash(before time.Time) ([]PurgedItem, error)
+}
+
+// PurgedItem is a trash item EmptyAllTrash deleted: task TaskID of Project,
+// or Project itself when TaskID is empty.
+type PurgedItem struct {
+	Username string
+	Project  string
+	TaskID   string
+}
+
+// TaskWriteKind selects what a TaskWrite does.
+type TaskWriteKind int
+
+const (
+	// WritePut stores Task in Project as given, UpdatedTime included,
+	// replacing any task with its ID. The project must be live.
+	WritePut TaskWriteKind = iota
+	// WriteTrash moves task Task.ID of Project to the trash at DeletedAt.
+	WriteTrash
+	// WriteDelete removes task Task.ID from Project.
+	WriteDelete
+)
+
+// TaskWrite is one step of an ApplyTaskWrites batch.
+type TaskWrite struct {
+	Kind      TaskWriteKind
+	Project   string
+	Task      models.Task
+	DeletedAt time.Time
+	// Checked writes fail with ErrTaskChanged unless live task Task.ID of
+	// Project, as left by the writes before it, is still Before, or does not
+	// exist when Before is nil.
+	Checked bool
+	Before  *models.Task
+}
+
+// plannedTasks follows the tasks of an ApplyTaskWrites batch through its
+// writes, to check each write against the state the writes before it leave.
+type plannedTasks struct {
+	stored func(project, id string) (models.Task, bool) // live stored task
+	tasks  map[[2]string]*models.Task                   // nil once trashed or deleted
+}
+
+func newPlannedTasks(stored func(project, id string) (models.Task, bool)) *plannedTasks {
+	return &plannedTasks{stored: stored, tasks: make(map[[2]string]*models.Task)}
+}
+
+// apply checks w, if it is Checked, then records its effect.
+func (p *plannedTasks) apply(w TaskWrite) error {
+	key := [2]string{w.Project, w.Task.ID}
+	if w.Checked {
+		var current models.Task
+		t, planned := p.tasks[key]
+		live := planned && t != nil
+		if live {
+			current = *t
+		} else if !planned {
+			current, live = p.stored(w.Project, w.Task.ID)
+		}
+		if w.Before == nil && live || w.Before != nil && (!live || !current.Equal(*w.Before)) {
+			return fmt.Errorf("%w: task %s of project %s", ErrTaskChanged, w.Task.ID, w.Project)
+		}
+	}
+	if w.Kind == WritePut {
+		t := w.Task
+		p.tasks[key] = &t
+	} else {
+		p.tasks[key] = nil
+	}
+	return nil
 }
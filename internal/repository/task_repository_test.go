@@ -0,0 +1,366 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"todolist/internal/filter"
+	"todolist/internal/models"
+
+	"github.com/gocql/gocql"
+)
+
+func taskRepos() map[string]func(t *testing.T) TaskRepository {
+	return map[string]func(t *testing.T) TaskRepository{
+		"inmem":     func(*testing.T) TaskRepository { return NewInMemTaskRepository() },
+		"cassandra": func(t *testing.T) TaskRepository { return NewCassandraTaskRepository(cassandraSession(t)) },
+	}
+}
+
+func TestEmptyAllTrashPurgesOnlyExpiredItems(t *testing.T) {
+	for name, newRepo := range taskRepos() {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			username := fmt.Sprintf("sweep_%d", time.Now().UnixNano())
+			t.Cleanup(func() {
+				repo.DeleteUserTasks(username)
+				repo.DeleteUserProjects(username)
+			})
+			for _, id := range []string{"pjt_a", "pjt_b"} {
+				if err := repo.CreateProject(username, models.Project{ID: id, Name: id + " name"}); err != nil {
+					t.Fatalf("CreateProject(%s): %v", id, err)
+				}
+			}
+			for _, id := range []string{"task_old", "task_new", "task_restored"} {
+				if err := repo.CreateTask(username, "pjt_a", models.Task{ID: id, Content: id}); err != nil {
+					t.Fatalf("CreateTask(%s): %v", id, err)
+				}
+			}
+
+			now := time.Now().Truncate(time.Millisecond)
+			old := now.Add(-48 * time.Hour)
+			mustNil(t, repo.TrashTask(username, "pjt_a", "task_old", old))
+			mustNil(t, repo.TrashTask(username, "pjt_a", "task_new", now))
+			mustNil(t, repo.TrashTask(username, "pjt_a", "task_restored", old))
+			mustNil(t, repo.RestoreTask(username, "pjt_a", "task_restored"))
+			mustNil(t, repo.TrashProject(username, "pjt_b", old))
+
+			purged, err := repo.EmptyAllTrash(now.Add(-time.Hour))
+			if err != nil {
+				t.Fatalf("EmptyAllTrash: %v", err)
+			}
+			got := make(map[PurgedItem]bool)
+			for _, item := range purged {
+				if item.Username == username {
+					got[item] = true
+				}
+			}
+			want := map[PurgedItem]bool{
+				{Username: username, Project: "pjt_a", TaskID: "task_old"}: true,
+				{Username: username, Project: "pjt_b"}:                     true,
+			}
+			if len(got) != len(want) {
+				t.Fatalf("purged %v, want %v", got, want)
+			}
+			for item := range want {
+				if !got[item] {
+					t.Errorf("%v not purged", item)
+				}
+			}
+			if _, ok := repo.GetTask(username, "pjt_a", "task_restored"); !ok {
+				t.Error("restored task was purged")
+			}
+			if ids, err := repo.FindTrashedProjects(username, "pjt_b name"); err != nil || len(ids) != 0 {
+				t.Errorf("FindTrashedProjects after purge = %v, %v; want none", ids, err)
+			}
+
+			// A second sweep finds nothing left.
+			purged, err = repo.EmptyAllTrash(now.Add(-time.Hour))
+			if err != nil {
+				t.Fatalf("EmptyAllTrash: %v", err)
+			}
+			for _, item := range purged {
+				if item.Username == username {
+					t.Errorf("%v purged twice", item)
+				}
+			}
+		})
+	}
+}
+
+func TestFindTrashedProjects(t *testing.T) {
+	for name, newRepo := range taskRepos() {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			username := fmt.Sprintf("find_%d", time.Now().UnixNano())
+			t.Cleanup(func() {
+				repo.DeleteUserTasks(username)
+				repo.DeleteUserProjects(username)
+			})
+			mustNil(t, repo.CreateProject(username, models.Project{ID: "pjt_home", Name: "home"}))
+			mustNil(t, repo.CreateProject(username, models.Project{ID: "pjt_work", Name: "work"}))
+
+			if ids, err := repo.FindTrashedProjects(username, "home"); err != nil || len(ids) != 0 {
+				t.Fatalf("FindTrashedProjects of a live project = %v, %v; want none", ids, err)
+			}
+			mustNil(t, repo.TrashProject(username, "pjt_home", time.Now()))
+			if ids, err := repo.FindTrashedProjects(username, "home"); err != nil || len(ids) != 1 || ids[0] != "pjt_home" {
+				t.Fatalf("FindTrashedProjects(home) = %v, %v; want [pjt_home]", ids, err)
+			}
+			mustNil(t, repo.RestoreProject(username, "pjt_home"))
+			if ids, err := repo.FindTrashedProjects(username, "home"); err != nil || len(ids) != 0 {
+				t.Fatalf("FindTrashedProjects after restore = %v, %v; want none", ids, err)
+			}
+			mustNil(t, repo.TrashProject(username, "pjt_work", time.Now()))
+			mustNil(t, repo.DeleteProject(username, "pjt_work"))
+			if ids, err := repo.FindTrashedProjects(username, "work"); err != nil || len(ids) != 0 {
+				t.Fatalf("FindTrashedProjects after delete = %v, %v; want none", ids, err)
+			}
+		})
+	}
+}
+
+func TestApplyTaskWritesChecksPlannedTasks(t *testing.T) {
+	for name, newRepo := range taskRepos() {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			username := fmt.Sprintf("apply_%d", time.Now().UnixNano())
+			t.Cleanup(func() {
+				repo.DeleteUserTasks(username)
+				repo.DeleteUserProjects(username)
+			})
+			mustNil(t, repo.CreateProject(username, models.Project{ID: "pjt_a", Name: "a"}))
+			now := time.Now().Truncate(time.Millisecond)
+			planned := models.Task{ID: "task_1", Content: "planned", UpdatedTime: now, Due: now}
+			mustNil(t, repo.CreateTask(username, "pjt_a", planned))
+
+			edited := planned
+			edited.Content, edited.UpdatedTime = "edited", now.Add(time.Second)
+			completed := edited
+			completed.Completed = true
+			// Each write is checked against the one before it.
+			mustNil(t, repo.ApplyTaskWrites(username, []TaskWrite{
+				{Kind: WritePut, Project: "pjt_a", Task: edited, Checked: true, Before: &planned},
+				{Kind: WritePut, Project: "pjt_a", Task: completed, Checked: true, Before: &edited},
+				{Kind: WritePut, Project: "pjt_a", Task: models.Task{ID: "task_2", Content: "new", UpdatedTime: now, Due: now}, Checked: true},
+			}))
+
+			tests := []struct {
+				name   string
+				writes []TaskWrite
+			}{
+				{"stale before", []TaskWrite{{Kind: WritePut, Project: "pjt_a", Task: edited, Checked: true, Before: &planned}}},
+				{"stale trash", []TaskWrite{{Kind: WriteTrash, Project: "pjt_a", Task: edited, DeletedAt: now, Checked: true, Before: &edited}}},
+				{"taken ID", []TaskWrite{{Kind: WritePut, Project: "pjt_a", Task: models.Task{ID: "task_2", Content: "again"}, Checked: true}}},
+				{"missing task", []TaskWrite{{Kind: WriteDelete, Project: "pjt_a", Task: models.Task{ID: "task_3"}, Checked: true, Before: &planned}}},
+				{"deleted earlier in the batch", []TaskWrite{
+					{Kind: WriteDelete, Project: "pjt_a", Task: completed, Checked: true, Before: &completed},
+					{Kind: WritePut, Project: "pjt_a", Task: planned, Checked: true, Before: &completed},
+				}},
+			}
+			for _, tt := range tests {
+				if err := repo.ApplyTaskWrites(username, tt.writes); !errors.Is(err, ErrTaskChanged) {
+					t.Errorf("%s: ApplyTaskWrites = %v, want ErrTaskChanged", tt.name, err)
+				}
+			}
+			if got, ok := repo.GetTask(username, "pjt_a", "task_1"); !ok || !got.Equal(completed) {
+				t.Errorf("task_1 = %v, %v after refused writes, want %v", got, ok, completed)
+			}
+		})
+	}
+}
+
+// TestApplyTaskWritesConditions checks that the conditions of the Cassandra
+// batch catch a write between a check and the batch.
+func TestApplyTaskWritesConditions(t *testing.T) {
+	repo := NewCassandraTaskRepository(cassandraSession(t))
+	username := fmt.Sprintf("cas_%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		repo.DeleteUserTasks(username)
+		repo.DeleteUserProjects(username)
+	})
+	mustNil(t, repo.CreateProject(username, models.Project{ID: "pjt_a", Name: "a"}))
+	now := time.Now().Truncate(time.Millisecond)
+	task := models.Task{ID: "task_1", Content: "planned", UpdatedTime: now, Due: now}
+	mustNil(t, repo.CreateTask(username, "pjt_a", task))
+
+	apply := func(w TaskWrite, seen taskRow) bool {
+		t.Helper()
+		batch := repo.session.NewBatch(gocql.LoggedBatch)
+		mustNil(t, repo.addConditionalTaskWrite(batch, username, w, seen))
+		applied, iter, err := repo.session.MapExecuteBatchCAS(batch, map[string]interface{}{})
+		mustNil(t, err)
+		iter.Close()
+		return applied
+	}
+
+	seen, err := repo.taskRow(username, "pjt_a", "task_1")
+	mustNil(t, err)
+	// Another client completes the task after it was checked.
+	mustNil(t, repo.CompleteTask(username, "pjt_a", "task_1"))
+	edited := task
+	edited.Content = "edited"
+	if apply(TaskWrite{Kind: WritePut, Project: "pjt_a", Task: edited}, seen) {
+		t.Error("batch applied over a concurrent write")
+	}
+	if got, _ := repo.GetTask(username, "pjt_a", "task_1"); got.Content != "planned" || !got.Completed {
+		t.Errorf("task_1 = %v, want the concurrent write kept", got)
+	}
+
+	seen, err = repo.taskRow(username, "pjt_a", "task_1")
+	mustNil(t, err)
+	if !apply(TaskWrite{Kind: WriteTrash, Project: "pjt_a", Task: edited, DeletedAt: now}, seen) {
+		t.Error("batch with current conditions not applied")
+	}
+
+	absent, err := repo.taskRow(username, "pjt_a", "task_2")
+	mustNil(t, err)
+	mustNil(t, repo.CreateTask(username, "pjt_a", models.Task{ID: "task_2", Content: "first"}))
+	if apply(TaskWrite{Kind: WritePut, Project: "pjt_a", Task: models.Task{ID: "task_2", Content: "second"}}, absent) {
+		t.Error("batch created a task created concurrently")
+	}
+}
+
+func TestApplyTaskWritesIndexesTrash(t *testing.T) {
+	for name, newRepo := range taskRepos() {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			username := fmt.Sprintf("applytrash_%d", time.Now().UnixNano())
+			t.Cleanup(func() {
+				repo.DeleteUserTasks(username)
+				repo.DeleteUserProjects(username)
+			})
+			mustNil(t, repo.CreateProject(username, models.Project{ID: "pjt_a", Name: "a"}))
+			now := time.Now().Truncate(time.Millisecond)
+			task := models.Task{ID: "task_1", Content: "old", UpdatedTime: now, Due: now}
+			mustNil(t, repo.CreateTask(username, "pjt_a", task))
+
+			mustNil(t, repo.ApplyTaskWrites(username, []TaskWrite{
+				{Kind: WriteTrash, Project: "pjt_a", Task: task, DeletedAt: now, Checked: true, Before: &task},
+			}))
+			if _, ok := repo.GetTask(username, "pjt_a", "task_1"); ok {
+				t.Error("trashed task still live")
+			}
+			items, err := repo.ListTrash(username)
+			if err != nil || len(items) != 1 {
+				t.Errorf("ListTrash = %v, %v; want the trashed task", items, err)
+			}
+		})
+	}
+}
+
+func TestCreateProjectRefusesTakenID(t *testing.T) {
+	for name, newRepo := range taskRepos() {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			username := fmt.Sprintf("create_%d", time.Now().UnixNano())
+			t.Cleanup(func() { repo.DeleteUserProjects(username) })
+			mustNil(t, repo.CreateProject(username, models.Project{ID: "pjt_a", Name: "first"}))
+			if err := repo.CreateProject(username, models.Project{ID: "pjt_a", Name: "second"}); !errors.Is(err, ErrProjectExists) {
+				t.Errorf("CreateProject with a taken ID = %v, want ErrProjectExists", err)
+			}
+			projects, err := repo.ListProjects(username)
+			if err != nil || len(projects) != 1 || projects[0].Name != "first" {
+				t.Errorf("projects = %v, %v, want only the first", projects, err)
+			}
+			mustNil(t, repo.TrashProject(username, "pjt_a", time.Now()))
+			if err := repo.CreateProject(username, models.Project{ID: "pjt_a", Name: "third"}); !errors.Is(err, ErrProjectTrashed) {
+				t.Errorf("CreateProject with a trashed ID = %v, want ErrProjectTrashed", err)
+			}
+		})
+	}
+}
+
+func TestTaskRecurrenceRoundTrip(t *testing.T) {
+	for name, newRepo := range taskRepos() {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			username := fmt.Sprintf("recur_%d", time.Now().UnixNano())
+			t.Cleanup(func() { repo.DeleteUserTasks(username); repo.DeleteUserProjects(username) })
+			mustNil(t, repo.CreateProject(username, models.Project{ID: "pjt_a", Name: "a"}))
+			weekly := &models.Recurrence{Interval: 2, Unit: models.RecurWeek, Weekday: "monday"}
+			mustNil(t, repo.CreateTask(username, "pjt_a", models.Task{ID: "task_1", Content: "review", Recurrence: weekly}))
+			mustNil(t, repo.CreateTask(username, "pjt_a", models.Task{ID: "task_2", Content: "once"}))
+
+			if got, _ := repo.GetTask(username, "pjt_a", "task_1"); got.Recurrence == nil || *got.Recurrence != *weekly {
+				t.Errorf("task_1 recurrence = %v, want %v", got.Recurrence, weekly)
+			}
+			if got, _ := repo.GetTask(username, "pjt_a", "task_2"); got.Recurrence != nil {
+				t.Errorf("task_2 recurrence = %v, want none", got.Recurrence)
+			}
+			tasks, err := repo.ListTasks(username, "pjt_a")
+			if err != nil || len(tasks) != 2 {
+				t.Fatalf("ListTasks = %v, %v", tasks, err)
+			}
+			for _, task := range tasks {
+				if (task.ID == "task_1") != (task.Recurrence != nil) {
+					t.Errorf("listed %s with recurrence %v", task.ID, task.Recurrence)
+				}
+			}
+
+			daily := models.Recurrence{Interval: 1, Unit: models.RecurDay}
+			mustNil(t, repo.UpdateTaskFields(username, "pjt_a", "task_2", models.TaskUpdate{Recurrence: &daily}))
+			if got, _ := repo.GetTask(username, "pjt_a", "task_2"); got.Recurrence == nil || *got.Recurrence != daily {
+				t.Errorf("task_2 recurrence after an update = %v, want %v", got.Recurrence, daily)
+			}
+			mustNil(t, repo.UpdateTaskFields(username, "pjt_a", "task_1", models.TaskUpdate{Recurrence: &models.Recurrence{}}))
+			if got, _ := repo.GetTask(username, "pjt_a", "task_1"); got.Recurrence != nil || got.Content != "review" {
+				t.Errorf("task_1 after clearing its recurrence = %v", got)
+			}
+		})
+	}
+}
+
+func TestCQLRestrictions(t *testing.T) {
+	now := time.Date(2025, 5, 7, 10, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		query string
+		want  string // restrictions with their values, times in RFC 3339
+	}{
+		{"priority <= 2", "priority <= 2"},
+		{"priority != 2", ""},
+		{"completed", "completed = true"},
+		{"completed != true", "completed = false"},
+		{"completed = false", "completed = false"},
+		{"not completed", ""},
+		{"due = today", "due >= 2025-05-07T00:00:00Z, due <= 2025-05-08T00:00:00Z"},
+		{"due < +3d", "due < 2025-05-10T10:00:00Z"},
+		{"due <= 2025-06-01", "due <= 2025-06-02T00:00:00Z"},
+		{"due > 2025-06-01", "due > 2025-06-01T00:00:00Z"},
+		{"updated >= -1w", "updated_time >= 2025-04-30T10:00:00Z"},
+		{"due != today", ""},
+		{"due = none", ""},
+		{"priority = 1 or completed", ""},
+		{"content:milk project:work priority > 0", "priority > 0"},
+		{"priority = 1 and (completed or due = today) and not completed", "priority = 1"},
+	} {
+		e, err := filter.Parse(tt.query, now, time.Monday)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.query, err)
+		}
+		restrictions, values := cqlRestrictions(e)
+		if len(restrictions) != len(values) {
+			t.Fatalf("%q gives %d restrictions for %d values", tt.query, len(restrictions), len(values))
+		}
+		var got []string
+		for i, r := range restrictions {
+			v := values[i]
+			if tv, ok := v.(time.Time); ok {
+				v = tv.UTC().Format(time.RFC3339)
+			}
+			got = append(got, strings.Replace(r, "?", fmt.Sprint(v), 1))
+		}
+		if strings.Join(got, ", ") != tt.want {
+			t.Errorf("cqlRestrictions(%q) = %q, want %q", tt.query, strings.Join(got, ", "), tt.want)
+		}
+	}
+}
+
+func mustNil(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,11 @@
+package repository
+
+import "todolist/internal/models"
+
+// TemplateRepository stores a user's project templates, keyed by template ID.
+type TemplateRepository interface {
+	CreateTemplate(username string, template models.Template) error
+	ListTemplates(username string) ([]models.Template, error)
+	DeleteTemplate(username, id string) error
+	DeleteUserTemplates(username string) error
+}
@@ -1,10 +1,34 @@
 package repository
 
-import "todolist/internal/models"
+import (
+	"time"
+	"todolist/internal/models"
+)
 
 type UserRepository interface {
 	AddUser(user models.User) error
 	GetUser(username string) (models.User, error)
 	Authenticate(username, password string) bool
 	DeactivateUser(username string) error
+	ReactivateUser(username string) error
+	UpdateSettings(username string, settings models.UserSettings) error
+	ListDeactivatedUsers(before time.Time) ([]models.User, error)
+	// ClaimForPurge marks a deactivated account as being purged, unless it
+	// was reactivated, or deactivated again, since deactivatedAt. It reports
+	// whether the account was claimed; a claimed account can no longer be
+	// reactivated.
+	ClaimForPurge(username string, deactivatedAt time.Time) (bool, error)
+	// AddToken stores a token issued to the user until token.Expires.
+	AddToken(username string, token models.AuthToken) error
+	// GetToken returns the user's token with the given hash, unless it was
+	// revoked or has expired.
+	GetToken(username, hash string) (models.AuthToken, bool, error)
+	DeleteToken(username, hash string) error
+	// DeleteUserTokens revokes every token of the user.
+	DeleteUserTokens(username string) error
+	ListUsers() ([]models.User, error)
+	DeleteUser(username string) error
+	AddAuditRecord(record models.AuditRecord) error
+	// ListAuditRecords returns the user's audit records, newest first.
+	ListAuditRecords(username string) ([]models.AuditRecord, error)
 }
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"todolist/internal/models"
+
+	"github.com/gocql/gocql"
+)
+
+// cassandraSession connects to the cluster in CASSANDRA_TEST_HOSTS, with the
+// schema of cmd/cassandra/init.cql in CASSANDRA_KEYSPACE (default todolist).
+// Tests needing it are skipped when the variable is unset.
+func cassandraSession(t *testing.T) *gocql.Session {
+	t.Helper()
+	hosts := os.Getenv("CASSANDRA_TEST_HOSTS")
+	if hosts == "" {
+		t.Skip("CASSANDRA_TEST_HOSTS not set")
+	}
+	cluster := gocql.NewCluster(strings.Split(hosts, ",")...)
+	cluster.Keyspace = os.Getenv("CASSANDRA_KEYSPACE")
+	if cluster.Keyspace == "" {
+		cluster.Keyspace = "todolist"
+	}
+	cluster.Consistency = gocql.Quorum
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatalf("connecting to Cassandra: %v", err)
+	}
+	t.Cleanup(session.Close)
+	return session
+}
+
+func TestAddUserConcurrentDuplicates(t *testing.T) {
+	repos := map[string]func(t *testing.T) UserRepository{
+		"inmem":     func(*testing.T) UserRepository { return NewInMemUserRepository() },
+		"cassandra": func(t *testing.T) UserRepository { return NewCassandraUserRepository(cassandraSession(t)) },
+	}
+	for name, newRepo := range repos {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			username := fmt.Sprintf("dup_%d", time.Now().UnixNano())
+			t.Cleanup(func() { repo.DeleteUser(username) })
+
+			const n = 32
+			var wg sync.WaitGroup
+			errs := make([]error, n)
+			start := make(chan struct{})
+			for i := range n {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					errs[i] = repo.AddUser(models.User{Username: username, Password: fmt.Sprintf("secret%d", i), Active: true})
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			succeeded, existed := 0, 0
+			for i, err := range errs {
+				switch {
+				case err == nil:
+					succeeded++
+				case errors.Is(err, ErrUserExists):
+					existed++
+				default:
+					t.Errorf("AddUser %d: unexpected error %v", i, err)
+				}
+			}
+			if succeeded != 1 || existed != n-1 {
+				t.Errorf("got %d successes and %d ErrUserExists, want 1 and %d", succeeded, existed, n-1)
+			}
+		})
+	}
+}
+
+func TestTokens(t *testing.T) {
+	repos := map[string]func(t *testing.T) UserRepository{
+		"inmem":     func(*testing.T) UserRepository { return NewInMemUserRepository() },
+		"cassandra": func(t *testing.T) UserRepository { return NewCassandraUserRepository(cassandraSession(t)) },
+	}
+	for name, newRepo := range repos {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			username := fmt.Sprintf("tok_%d", time.Now().UnixNano())
+			t.Cleanup(func() { repo.DeleteUserTokens(username) })
+
+			now := time.Now().Truncate(time.Millisecond)
+			for _, hash := range []string{"a", "b"} {
+				mustNil(t, repo.AddToken(username, models.AuthToken{Hash: hash, Created: now, Expires: now.Add(time.Hour)}))
+			}
+			mustNil(t, repo.AddToken(username, models.AuthToken{Hash: "expired", Created: now.Add(-time.Hour), Expires: now.Add(-time.Minute)}))
+
+			if token, found, err := repo.GetToken(username, "a"); err != nil || !found || !token.Expires.Equal(now.Add(time.Hour)) {
+				t.Errorf("GetToken(a) = %+v, %v, %v; want the token", token, found, err)
+			}
+			if _, found, err := repo.GetToken(username, "expired"); err != nil || found {
+				t.Errorf("GetToken(expired) = %v, %v; want not found", found, err)
+			}
+			if _, found, err := repo.GetToken("someone else", "a"); err != nil || found {
+				t.Errorf("GetToken of another user = %v, %v; want not found", found, err)
+			}
+
+			mustNil(t, repo.DeleteToken(username, "a"))
+			if _, found, _ := repo.GetToken(username, "a"); found {
+				t.Error("token a found after DeleteToken")
+			}
+			if _, found, _ := repo.GetToken(username, "b"); !found {
+				t.Error("token b gone after deleting a")
+			}
+			mustNil(t, repo.DeleteUserTokens(username))
+			if _, found, _ := repo.GetToken(username, "b"); found {
+				t.Error("token b found after DeleteUserTokens")
+			}
+		})
+	}
+}
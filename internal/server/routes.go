@@ -0,0 +1,424 @@
+package server
+
+import (
+	"net/http"
+	"time"
+	"todolist/internal/api"
+	"todolist/internal/gql"
+	"todolist/internal/handlers"
+	"todolist/internal/models"
+)
+
+var (
+	pjtParam      = api.Param{Name: "pjt", Required: true, Description: "Project name or ID"}
+	newPjtParam   = api.Param{Name: "pjt", Required: true, Description: "Name of the new project"}
+	keyParam      = api.Param{Name: "key", Required: true, Description: "Task ID"}
+	toParam       = api.Param{Name: "to", Required: true, Description: "Target project name or ID"}
+	archivedFlag  = api.Param{Name: "archived", Description: "Set to true to include archived projects"}
+	parentParam   = api.Param{Name: "parent", Description: "Name or ID of the parent project; omit for the top level"}
+	templateParam = api.Param{Name: "template", Required: true, Description: "Template name or ID"}
+	copyNameParam = api.Param{Name: "name", Required: true, Description: "Name of the new project"}
+	anchorParam   = api.Param{Name: "anchor", Description: "Date due offsets are relative to, YYYY-MM-DD (midnight in the user's time zone) or RFC 3339; defaults to now"}
+	filterParam   = api.Param{Name: "q", Required: true, Description: "Filter query, e.g. priority <= 2 and due < +3d and not completed in project:work"}
+	listParam     = api.Param{Name: "list", Required: true, Description: "Smart list name or ID"}
+	quickAddParam = api.Param{Name: "q", Required: true, Description: "Quick-add text, e.g. Pay rent tomorrow 9am p1 #home every month"}
+	tzParam       = api.Param{Name: "tz", Description: "IANA time zone the days are counted in, such as Europe/Paris; defaults to the user's"}
+)
+
+// Message is the JSON body of the user endpoints.
+type Message struct {
+	Message string `json:"message"`
+}
+
+// routes is the HTTP route table: it is registered on the router and
+// describes the API in /openapi.json.
+func routes(th *handlers.TaskHandler, uh *handlers.UserHandler, wh *handlers.WelcomeHandler, gh *handlers.GraphQLHandler) []api.Route {
+	return []api.Route{
+		{
+			Method: http.MethodGet, Path: "/welcome", Handler: wh.Welcome,
+			Summary: "Check the credentials",
+		},
+		{
+			Method: http.MethodGet, Path: "/printTasks", Handler: th.GetAllTasksFromPjtHttp,
+			Summary:  "List the tasks of a project",
+			Query:    []api.Param{pjtParam},
+			Response: []models.Task{},
+		},
+		{
+			Method: http.MethodGet, Path: "/printProjects", Handler: th.GetAllProjectsHttp,
+			Summary:     "List projects",
+			Description: "Projects sorted by name. Archived projects are left out unless archived=true.",
+			Query:       []api.Param{archivedFlag},
+			Response:    []models.Project{},
+		},
+		{
+			Method: http.MethodGet, Path: "/printProjectTree", Handler: th.GetProjectTreeHttp,
+			Summary: "List projects as a tree",
+			Description: "Sub-projects are nested under their parent and sorted by name. Every node counts the tasks of its project and, in the totals, " +
+				"of every project below it. pjt limits the tree to that project; archived projects, with everything below them, are left out unless archived=true.",
+			Query:    []api.Param{{Name: "pjt", Description: "Project name or ID to list the tree below"}, archivedFlag},
+			Response: []models.ProjectNode{},
+			Errors:   []int{http.StatusNotFound},
+		},
+		{
+			Method: http.MethodGet, Path: "/search", Handler: th.SearchHttp,
+			Summary: "Search the content of tasks",
+			Description: "Finds tasks containing every word of q, as a word or the start of one, in all live projects including archived ones. " +
+				"Hits are ranked by relevance, with the matched byte ranges and an HTML-escaped highlight using <mark>.",
+			Query: []api.Param{
+				{Name: "q", Required: true, Description: "Words to search for"},
+				{Name: "pjt", Description: "Project name or ID to search in"},
+				{Name: "limit", Description: "Maximum number of hits, 1 to 100 (default 20)"},
+			},
+			Response: []models.SearchHit{},
+			Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodGet, Path: "/today", Handler: th.TodayHttp,
+			Summary: "List the open tasks overdue or due today",
+			Description: "Spans every project; archived ones are left out unless archived=true. Completed tasks and tasks without a due date are not listed. " +
+				"Overdue tasks are those due before midnight in tz; the others are grouped under today.",
+			Query:    []api.Param{tzParam, archivedFlag},
+			Response: models.Agenda{},
+			Errors:   []int{http.StatusBadRequest},
+		},
+		{
+			Method: http.MethodGet, Path: "/upcoming", Handler: th.UpcomingHttp,
+			Summary:     "List the open tasks overdue or due in the coming days",
+			Description: "Like /today, over days days starting today, with an entry for every day even when nothing is due.",
+			Query:       []api.Param{{Name: "days", Description: "Number of days, 1 to 90 (default 7)"}, tzParam, archivedFlag},
+			Response:    models.Agenda{},
+			Errors:      []int{http.StatusBadRequest},
+		},
+		{
+			Method: http.MethodGet, Path: "/filter", Handler: th.FilterTasksHttp,
+			Summary: "List the tasks matching a filter query",
+			Description: "Compares content, priority, due, updated, completed, project and archived with =, !=, <, <=, >, >= and :, combined with and, or, not " +
+				"and parentheses. Dates may be relative, such as today or +3d. Tasks of all live projects, archived ones included, are sorted by due date, then priority.",
+			Query:    []api.Param{filterParam},
+			Response: []models.ProjectTask{},
+			Errors:   []int{http.StatusBadRequest},
+		},
+		{
+			Method: http.MethodPost, Path: "/saveSmartList", Handler: th.SaveSmartListHttp,
+			Summary:     "Save a filter query as a smart list",
+			Description: "Saving under the name of an existing smart list replaces its query. Names of the form of a smart list ID (sl_ followed by a UUID) are refused.",
+			Query:       []api.Param{{Name: "name", Required: true, Description: "Smart list name"}, filterParam},
+			Response:    models.SmartList{},
+			Errors:      []int{http.StatusBadRequest},
+			Idempotent:  true,
+		},
+		{
+			Method: http.MethodGet, Path: "/printSmartLists", Handler: th.GetSmartListsHttp,
+			Summary:  "List smart lists",
+			Response: []models.SmartList{},
+		},
+		{
+			Method: http.MethodGet, Path: "/runSmartList", Handler: th.RunSmartListHttp,
+			Summary:     "List the tasks matching a smart list",
+			Description: "Runs the saved query like /filter, with relative dates taken from the current time.",
+			Query:       []api.Param{listParam},
+			Response:    []models.ProjectTask{},
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodDelete, Path: "/removeSmartList", Handler: th.RemoveSmartListHttp,
+			Summary:    "Delete a smart list",
+			Query:      []api.Param{listParam},
+			Errors:     []int{http.StatusBadRequest, http.StatusNotFound},
+			Idempotent: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/parseQuickAdd", Handler: th.ParseQuickAddHttp,
+			Summary: "Preview a quick-add string",
+			Description: "Returns the content, due date, priority, #project and recurrence recognized in the text, with the byte range of each recognized part, " +
+				"without creating anything. Dates are taken in the user's time zone.",
+			Query:    []api.Param{quickAddParam},
+			Response: models.QuickAdd{},
+			Errors:   []int{http.StatusBadRequest},
+		},
+		{
+			Method: http.MethodPost, Path: "/quickAdd", Handler: th.QuickAddHttp,
+			Summary: "Create a task from a quick-add string",
+			Description: "Parses the text like /parseQuickAdd and creates the task in the #project it names, or else in pjt. " +
+				"A recognized recurrence is stored on the task, so completing it moves it to its next due date. Returns the task with an undo token.",
+			Query:      []api.Param{quickAddParam, {Name: "pjt", Description: "Project name or ID used when the text names no #project"}},
+			Response:   models.QuickAddResult{},
+			Errors:     []int{http.StatusBadRequest, http.StatusNotFound},
+			Idempotent: true,
+		},
+		{
+			Method: http.MethodPost, Path: "/writeTask", Handler: th.WriteTaskHttp,
+			Summary:     "Create a task, or update it when the ID exists",
+			Description: "Content is required and priority must be between 0 and 10 (0 is highest). Invalid fields are reported together in a JSON 400 response.",
+			Query:       []api.Param{pjtParam},
+			Body:        models.Task{},
+			Response:    models.Task{},
+			UndoToken:   true,
+			Errors:      []int{http.StatusBadRequest},
+			Idempotent:  true,
+		},
+		{
+			Method: http.MethodPatch, Path: "/patchTask", Handler: th.PatchTaskHttp,
+			Summary: "Change some fields of a task",
+			Description: "The body is a JSON Merge Patch (RFC 7396) or, with Content-Type application/json-patch+json, a JSON Patch (RFC 6902). " +
+				"Only changed fields are written and the patched task is validated as a whole; no undo token is returned when nothing changed.",
+			Query:      []api.Param{pjtParam, keyParam},
+			Body:       map[string]any{},
+			Response:   models.Task{},
+			UndoToken:  true,
+			Errors:     []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnsupportedMediaType},
+			Idempotent: true,
+		},
+		{
+			Method: http.MethodPost, Path: "/batch", Handler: th.BatchHttp,
+			Summary: "Run several task operations in one request",
+			Description: "Operations (create, update, complete, delete, move) run in order and each gets a result with its undo token. " +
+				"An atomic batch applies all operations or none and fails with 422; otherwise failed operations are reported in their results.",
+			Body:       handlers.BatchRequest{},
+			Response:   handlers.BatchResponse{},
+			Errors:     []int{http.StatusBadRequest, http.StatusUnprocessableEntity},
+			Idempotent: true,
+		},
+		{
+			Method: http.MethodPost, Path: "/moveTask", Handler: th.MoveTaskHttp,
+			Summary:     "Move a task to another project",
+			Description: "The task keeps its ID and updatedTime, and its history moves along. Fails with 409 when the target project has a task with the same ID, or the task changes during the request.",
+			Query:       []api.Param{pjtParam, keyParam, toParam},
+			Response:    models.Task{},
+			UndoToken:   true,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+			Idempotent:  true,
+		},
+		{
+			Method: http.MethodPost, Path: "/copyTask", Handler: th.CopyTaskHttp,
+			Summary:     "Copy a task into a project under a new ID",
+			Description: "The target may be the task's own project. Fails with 409 when the task changes during the request.",
+			Query:       []api.Param{pjtParam, keyParam, toParam},
+			Status:      http.StatusCreated,
+			Response:    models.Task{},
+			UndoToken:   true,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+			Idempotent:  true,
+		},
+		{
+			Method: http.MethodGet, Path: "/completeTask", Handler: th.CompleteTaskHttp,
+			Summary:     "Mark a task completed",
+			Description: "A recurring task stays open and moves to its next due date instead.",
+			Query:       []api.Param{pjtParam, keyParam},
+			UndoToken:   true,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+			Idempotent:  true,
+		},
+		{
+			Method: http.MethodDelete, Path: "/removeTask", Handler: th.RemoveTaskHttp,
+			Summary:    "Move a task to the trash",
+			Query:      []api.Param{pjtParam, keyParam},
+			UndoToken:  true,
+			Errors:     []int{http.StatusBadRequest, http.StatusNotFound},
+			Idempotent: true,
+		},
+		{
+			Method: http.MethodDelete, Path: "/removeProject", Handler: th.RemoveProjectHttp,
+			Summary:     "Move a project and its tasks to the trash",
+			Description: "children=trash, the default, trashes its sub-projects too and restoring the project restores them; children=lift moves them up to the project's parent.",
+			Query:       []api.Param{pjtParam, {Name: "children", Description: "trash or lift"}},
+			UndoToken:   true,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+			Idempotent:  true,
+		},
+		{
+			Method: http.MethodPost, Path: "/createProject", Handler: th.CreateProjectHttp,
+			Summary:     "Create a project",
+			Description: "The project gets a new ID and is nested in parent when given. Creating a project whose name is taken changes nothing and returns the existing project.",
+			Query:       []api.Param{newPjtParam, parentParam},
+			Response:    models.Project{},
+			UndoToken:   true,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+			Idempotent:  true,
+		},
+		{
+			Method: http.MethodPost, Path: "/updateProject", Handler: th.UpdateProjectHttp,
+			Summary: "Rename, describe, color, archive or move a project",
+			Description: "Only the fields present in the body change; the project's tasks are not rewritten. Names must be unique, colors look like #1e90ff " +
+				"and a project cannot be moved below itself.",
+			Query:      []api.Param{pjtParam},
+			Body:       models.ProjectUpdate{},
+			Response:   models.Project{},
+			UndoToken:  true,
+			Errors:     []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+			Idempotent: true,
+		},
+		{
+			Method: http.MethodPost, Path: "/moveProject", Handler: th.MoveProjectHttp,
+			Summary:     "Nest a project in another one",
+			Description: "Moves the project, with everything below it, under parent, or to the top level when parent is omitted. Moving a project below itself is rejected.",
+			Query:       []api.Param{pjtParam, parentParam},
+			Response:    models.Project{},
+			UndoToken:   true,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+			Idempotent:  true,
+		},
+		{
+			Method: http.MethodPost, Path: "/cloneProject", Handler: th.CloneProjectHttp,
+			Summary:     "Copy a project with all of its tasks",
+			Description: "The clone gets the project's description, color and parent, and a copy of every task under a new ID. Remove the clone to revert.",
+			Query:       []api.Param{pjtParam, copyNameParam},
+			Response:    models.Project{},
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+			Idempotent:  true,
+		},
+		{
+			Method: http.MethodPost, Path: "/saveTemplate", Handler: th.SaveTemplateHttp,
+			Summary: "Save a project as a template",
+			Description: "Stores the project's description, color and tasks with their priorities. Due dates are kept as offsets from anchor; " +
+				"completed tasks are saved as open ones. The template is named after the project unless name is given.",
+			Query:      []api.Param{pjtParam, {Name: "name", Description: "Template name"}, anchorParam},
+			Response:   models.Template{},
+			Errors:     []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+			Idempotent: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/printTemplates", Handler: th.GetTemplatesHttp,
+			Summary:  "List templates",
+			Response: []models.Template{},
+		},
+		{
+			Method: http.MethodPost, Path: "/instantiateTemplate", Handler: th.InstantiateTemplateHttp,
+			Summary:     "Create a project from a template",
+			Description: "Creates project name, nested in parent when given, with the template's tasks due at their offsets from anchor. Remove the project to revert.",
+			Query:       []api.Param{templateParam, copyNameParam, parentParam, anchorParam},
+			Response:    models.Project{},
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+			Idempotent:  true,
+		},
+		{
+			Method: http.MethodDelete, Path: "/removeTemplate", Handler: th.RemoveTemplateHttp,
+			Summary:    "Delete a template",
+			Query:      []api.Param{templateParam},
+			Errors:     []int{http.StatusBadRequest, http.StatusNotFound},
+			Idempotent: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/printTrash", Handler: th.GetTrashHttp,
+			Summary:  "List trashed projects and tasks",
+			Response: []models.TrashItem{},
+		},
+		{
+			Method: http.MethodPost, Path: "/restoreTrash", Handler: th.RestoreTrashHttp,
+			Summary:    "Restore a trashed task, or the project when key is omitted",
+			Query:      []api.Param{pjtParam, {Name: "key", Description: "Task ID"}},
+			UndoToken:  true,
+			Errors:     []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+			Idempotent: true,
+		},
+		{
+			Method: http.MethodDelete, Path: "/purgeTrash", Handler: th.PurgeTrashHttp,
+			Summary:     "Permanently delete trashed items",
+			Description: "Purges task key of project pjt, project pjt when key is omitted, or the whole trash when both are.",
+			Query:       []api.Param{{Name: "pjt", Description: "Project name"}, {Name: "key", Description: "Task ID"}},
+			Response:    handlers.PurgeResult{},
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+			Idempotent:  true,
+		},
+		{
+			Method: http.MethodGet, Path: "/printHistory", Handler: th.GetHistoryHttp,
+			Summary:  "List the changes of a task, or of the project when key is omitted",
+			Query:    []api.Param{pjtParam, {Name: "key", Description: "Task ID"}},
+			Response: []models.TaskChange{},
+			Errors:   []int{http.StatusBadRequest},
+		},
+		{
+			Method: http.MethodPost, Path: "/undo", Handler: th.UndoHttp,
+			Summary:    "Revert the mutation that returned the token",
+			Query:      []api.Param{{Name: "token", Required: true, Description: "X-Undo-Token of the mutation"}},
+			Errors:     []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusGone},
+			Idempotent: true,
+		},
+		{
+			Method: http.MethodPost, Path: "/graphql", Handler: gh.Serve,
+			Summary:     "Run a GraphQL query or mutation",
+			Description: "Query errors are reported in the errors member of a 200 response.",
+			Body:        gql.Request{},
+			Response:    map[string]any{},
+			Errors:      []int{http.StatusBadRequest},
+		},
+		{
+			Method: http.MethodPost, Path: "/register", Handler: uh.Register, Public: true,
+			Summary:  "Register a user",
+			Body:     handlers.RegisterRequest{},
+			Status:   http.StatusCreated,
+			Response: Message{},
+			Errors:   []int{http.StatusBadRequest, http.StatusConflict},
+		},
+		{
+			Method: http.MethodPost, Path: "/login", Handler: uh.Login, Public: true,
+			Summary: "Issue a bearer token",
+			Description: "Takes the account's Basic credentials and answers with a token for the Bearer scheme, valid until expires " +
+				"(TOKEN_TTL, default 30 days). Only a hash of the token is stored. Deactivating the account revokes all of its tokens.",
+			Status:   http.StatusCreated,
+			Response: handlers.LoginResponse{},
+			Errors:   []int{http.StatusUnauthorized},
+		},
+		{
+			Method: http.MethodPost, Path: "/logout", Handler: uh.Logout, Public: true,
+			Summary:     "Revoke a bearer token",
+			Description: "Revokes the token in the Bearer Authorization header.",
+			Response:    Message{},
+			Errors:      []int{http.StatusUnauthorized},
+		},
+		{
+			Method: http.MethodDelete, Path: "/deactivate", Handler: uh.DeleteUser,
+			Summary:     "Deactivate the account",
+			Description: "The account can be reactivated until purgeAfter, when it is deleted with all its data. Its tokens are revoked.",
+			Response: struct {
+				Message    string    `json:"message"`
+				PurgeAfter time.Time `json:"purgeAfter"`
+			}{},
+		},
+		{
+			Method: http.MethodGet, Path: "/settings", Handler: uh.GetSettings,
+			Summary:     "Get the account's settings",
+			Description: "Settings never changed are given their default: UTC, weeks starting on monday, en-US and YYYY-MM-DD.",
+			Response:    models.UserSettings{},
+		},
+		{
+			Method: http.MethodPost, Path: "/updateSettings", Handler: uh.UpdateSettings,
+			Summary: "Change the account's settings",
+			Description: "Only the fields present in the body change; an empty value resets one to its default. timeZone is an IANA name, weekStart " +
+				"monday, sunday or saturday, locale a BCP 47 tag and dateFormat one of YYYY-MM-DD, DD/MM/YYYY, MM/DD/YYYY and DD.MM.YYYY. " +
+				"The time zone sets the days of filters, agenda views and plain template anchor dates. dateFormat only reaches the day labels " +
+				"of agenda views and the numeric dates quick-add reads; every other date is RFC 3339.",
+			Body:       models.SettingsUpdate{},
+			Response:   models.UserSettings{},
+			Errors:     []int{http.StatusBadRequest},
+			Idempotent: true,
+		},
+		{
+			Method: http.MethodPost, Path: "/reactivate", Handler: uh.Reactivate, Public: true,
+			Summary:     "Reactivate a deactivated account",
+			Description: "Takes the account's Basic credentials, which regular authentication rejects while it is deactivated.",
+			Response:    Message{},
+			Errors:      []int{http.StatusUnauthorized, http.StatusConflict, http.StatusGone},
+		},
+	}
+}
+
+// webUIRoutes serve the embedded web frontend.
+func webUIRoutes(ui http.Handler) []api.Route {
+	return []api.Route{
+		{
+			Method: http.MethodGet, Path: "/app", Public: true,
+			Handler: http.RedirectHandler("/app/", http.StatusMovedPermanently).ServeHTTP,
+			Summary: "Redirect to the web UI",
+			Status:  http.StatusMovedPermanently,
+		},
+		{
+			Method: http.MethodGet, Path: "/app/", Handler: ui.ServeHTTP, Public: true, Prefix: true,
+			Summary:     "Web UI",
+			Description: "Serves index.html and the static files below /app/.",
+			ContentType: "text/html",
+			Errors:      []int{http.StatusNotFound},
+		},
+	}
+}
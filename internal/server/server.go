@@ -0,0 +1,56 @@
+// Package server assembles the HTTP API from the services: the route table,
+// its middleware and the OpenAPI document describing it.
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"todolist/internal/api"
+	"todolist/internal/gql"
+	"todolist/internal/handlers"
+	"todolist/internal/middleware"
+	"todolist/internal/repository"
+	"todolist/internal/services"
+	"todolist/internal/webui"
+
+	"github.com/gorilla/mux"
+)
+
+// New returns the handler serving the HTTP API, with the web UI under /app
+// when webUI is set. It fails if a registered route is missing from
+// /openapi.json.
+func New(taskService *services.TaskService, userService *services.UserService, idempotencyRepo repository.IdempotencyRepository, webUI bool) (http.Handler, error) {
+	r, routeTable, err := newRouter(taskService, userService, idempotencyRepo, webUI)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.Check(r, api.Spec(routeTable)); err != nil {
+		return nil, err
+	}
+	return middleware.CORS(middleware.RequestID(r)), nil
+}
+
+// newRouter registers the route table, /openapi.json included, and returns
+// the router together with the table.
+func newRouter(taskService *services.TaskService, userService *services.UserService, idempotencyRepo repository.IdempotencyRepository, webUI bool) (*mux.Router, []api.Route, error) {
+	graphqlExecutor, err := gql.NewExecutor(taskService)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid GraphQL schema: %w", err)
+	}
+	routeTable := routes(
+		handlers.NewTaskHandler(taskService),
+		handlers.NewUserHandler(userService, taskService),
+		handlers.NewWelcomeHandler(),
+		handlers.NewGraphQLHandler(graphqlExecutor),
+	)
+	if webUI {
+		routeTable = append(routeTable, webUIRoutes(webui.Handler("/app"))...)
+	}
+	routeTable = append(routeTable, api.SpecRoute("/openapi.json", routeTable))
+
+	auth := middleware.NewAuthMiddleware(userService)
+	idempotency := middleware.NewIdempotencyMiddleware(services.NewIdempotencyService(idempotencyRepo))
+	r := mux.NewRouter()
+	api.Register(r, routeTable, auth.Authenticate, idempotency.Idempotent)
+	return r, routeTable, nil
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"todolist/internal/api"
+	"todolist/internal/repository"
+	"todolist/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// testRouter builds the router, web UI included, over in-memory storage.
+func testRouter(t *testing.T) *mux.Router {
+	t.Helper()
+	tasks := repository.NewInMemTaskRepository()
+	users := repository.NewInMemUserRepository()
+	idempotency := repository.NewInMemIdempotencyRepository(time.Hour)
+	taskService := services.NewTaskService(tasks, repository.NewInMemHistoryRepository(0), repository.NewInMemTemplateRepository(),
+		repository.NewInMemSmartListRepository(), users, services.DefaultUndoWindow, services.DefaultLimits())
+	userService := services.NewUserService(users, idempotency, services.DefaultGracePeriod, services.DefaultTokenTTL, services.DefaultLimits())
+	r, _, err := newRouter(taskService, userService, idempotency, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// servedSpec fetches /openapi.json from r.
+func servedSpec(t *testing.T, r http.Handler) *api.Document {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: status %d", rec.Code)
+	}
+	var doc api.Document
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decoding /openapi.json: %v", err)
+	}
+	return &doc
+}
+
+// registered lists the method and path of every route registered on r, as
+// "METHOD /path", leaving out the OPTIONS and HEAD that come with them.
+func registered(t *testing.T, r *mux.Router) []string {
+	t.Helper()
+	var ops []string
+	err := r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			t.Errorf("route %s accepts every method", path)
+			return nil
+		}
+		for _, m := range methods {
+			if m != http.MethodOptions && m != http.MethodHead {
+				ops = append(ops, m+" "+path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ops
+}
+
+func TestOpenAPIDescribesEveryRoute(t *testing.T) {
+	r := testRouter(t)
+	doc := servedSpec(t, r)
+	ops := registered(t, r)
+
+	for _, op := range []string{"POST /register", "GET /printTasks", "POST /writeTask", "GET /openapi.json", "GET /app"} {
+		if !slices.Contains(ops, op) {
+			t.Errorf("%s is not registered", op)
+		}
+	}
+	for _, op := range ops {
+		method, path, _ := strings.Cut(op, " ")
+		if doc.Paths[path][strings.ToLower(method)] == nil {
+			t.Errorf("%s is registered but missing from /openapi.json", op)
+		}
+	}
+	for path, methods := range doc.Paths {
+		for method := range methods {
+			if op := strings.ToUpper(method) + " " + path; !slices.Contains(ops, op) {
+				t.Errorf("%s is in /openapi.json but not registered", op)
+			}
+		}
+	}
+}
+
+func TestCheckReportsUndocumentedRoutes(t *testing.T) {
+	r := testRouter(t)
+	doc := servedSpec(t, r)
+	if err := api.Check(r, doc); err != nil {
+		t.Fatalf("Check on the route table: %v", err)
+	}
+
+	r.HandleFunc("/undocumented", func(http.ResponseWriter, *http.Request) {}).Methods(http.MethodPost)
+	r.HandleFunc("/anyMethod", func(http.ResponseWriter, *http.Request) {})
+	err := api.Check(r, doc)
+	if err == nil {
+		t.Fatal("Check accepted routes missing from the document")
+	}
+	for _, want := range []string{"POST /undocumented", "* /anyMethod"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Check error %q does not name %s", err, want)
+		}
+	}
+}
+
+func TestRegisterBodyHasOnlyCredentials(t *testing.T) {
+	doc := servedSpec(t, testRouter(t))
+	op := doc.Paths["/register"]["post"]
+	if op == nil || op.RequestBody == nil || op.RequestBody.Content["application/json"] == nil {
+		t.Fatalf("/register has no JSON request body: %+v", op)
+	}
+	ref := op.RequestBody.Content["application/json"].Schema.Ref
+	name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+	if !ok || doc.Components.Schemas[name] == nil {
+		t.Fatalf("/register body schema = %q, want a component", ref)
+	}
+	var props []string
+	for p := range doc.Components.Schemas[name].Properties {
+		props = append(props, p)
+	}
+	slices.Sort(props)
+	if !slices.Equal(props, []string{"password", "username"}) {
+		t.Errorf("/register body properties = %v, want password and username", props)
+	}
+}
+
+func TestValidationErrorsAreJSON(t *testing.T) {
+	r := testRouter(t)
+	serve := func(method, target, body, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.SetBasicAuth("alice", "secret1")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := serve(http.MethodPost, "/register", `{"username":"alice","password":"secret1"}`, ""); rec.Code != http.StatusCreated {
+		t.Fatalf("register: status %d", rec.Code)
+	}
+
+	tests := []struct {
+		name, method, target, body, key string
+		want                            string
+	}{
+		{"register", http.MethodPost, "/register", `{"username":"bad name","password":"123"}`, "",
+			`{"error":"validation failed","fields":[{"field":"username","message":"may only contain letters, digits, '.', '_' and '-'"},` +
+				`{"field":"password","message":"must be at least 6 characters"}]}`},
+		{"writeTask", http.MethodPost, "/writeTask?pjt=home", `{"content":"","priority":11}`, "",
+			`{"error":"validation failed","fields":[{"field":"content","message":"is required"},{"field":"priority","message":"must be between 0 and 10"}]}`},
+		{"idempotency key", http.MethodPost, "/createProject?pjt=home", "", strings.Repeat("k", 256),
+			`{"error":"validation failed","fields":[{"field":"key","message":"must be at most 255 characters"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.method, tt.target, tt.body, tt.key)
+			if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != "application/json" {
+				t.Fatalf("status %d, Content-Type %q; want 400 with JSON", rec.Code, rec.Header().Get("Content-Type"))
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.want {
+				t.Errorf("body = %s\nwant   %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+// Package servertest starts the HTTP API over in-memory storage, for tests of
+// its clients.
+package servertest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"todolist/internal/repository"
+	"todolist/internal/server"
+	"todolist/internal/services"
+)
+
+// New serves the API, without the web UI, on an httptest server closed at the
+// end of the test. wrap, when not nil, is given the API handler and returns
+// the handler to serve, so that tests can inject faults.
+func New(t *testing.T, wrap func(http.Handler) http.Handler) *httptest.Server {
+	t.Helper()
+	tasks := repository.NewInMemTaskRepository()
+	users := repository.NewInMemUserRepository()
+	idempotency := repository.NewInMemIdempotencyRepository(time.Hour)
+	taskService := services.NewTaskService(tasks, repository.NewInMemHistoryRepository(0), repository.NewInMemTemplateRepository(),
+		repository.NewInMemSmartListRepository(), users, services.DefaultUndoWindow, services.DefaultLimits())
+	userService := services.NewUserService(users, idempotency, services.DefaultGracePeriod, services.DefaultTokenTTL, services.DefaultLimits())
+
+	handler, err := server.New(taskService, userService, idempotency, false)
+	if err != nil {
+		t.Fatalf("building the API: %v", err)
+	}
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
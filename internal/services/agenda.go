@@ -0,0 +1,87 @@
+package services
+
+import (
+	"time"
+	"todolist/internal/filter"
+	"todolist/internal/models"
+)
+
+// Agenda views span every project and list only open tasks that have a due
+// date. Days run from midnight to midnight in the time zone asked for.
+
+const (
+	// DefaultUpcomingDays is how many days the upcoming agenda covers by
+	// default, today included.
+	DefaultUpcomingDays = 7
+	// MaxAgendaDays bounds the days an agenda covers.
+	MaxAgendaDays = 90
+)
+
+// GetAgenda returns the open tasks that are overdue or due within the given
+// number of days from today, today included, grouped by day in time zone tz
+// (an IANA name, the user's time zone when empty). Tasks due earlier today
+// are listed under today rather than as overdue. Archived projects are left
+// out unless archived is set.
+func (svc *TaskService) GetAgenda(user, tz string, days int, archived bool) (models.Agenda, error) {
+	settings := svc.userSettings(user)
+	if tz == "" {
+		tz = settings.TimeZone
+	}
+	loc, err := parseAgenda(tz, days)
+	if err != nil {
+		return models.Agenda{}, err
+	}
+	now := svc.now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	end := today.AddDate(0, 0, days)
+
+	var expr filter.Expr = &filter.And{
+		X: &filter.Compare{Field: filter.FieldCompleted, Op: filter.OpEq, Bool: false},
+		Y: &filter.Compare{Field: filter.FieldDue, Op: filter.OpLt, From: end, To: end.Add(time.Nanosecond)},
+	}
+	if !archived {
+		expr = &filter.And{X: expr, Y: &filter.Compare{Field: filter.FieldArchived, Op: filter.OpEq, Bool: false}}
+	}
+	tasks, err := svc.runFilter(user, expr)
+	if err != nil {
+		return models.Agenda{}, err
+	}
+
+	agenda := models.Agenda{TimeZone: loc.String(), Overdue: []models.ProjectTask{}, Days: make([]models.AgendaDay, days)}
+	for i := range agenda.Days {
+		day := today.AddDate(0, 0, i)
+		agenda.Days[i] = models.AgendaDay{
+			Date:  day.Format(time.DateOnly),
+			Label: day.Format(models.DateLayouts[settings.DateFormat]),
+			Tasks: []models.ProjectTask{},
+		}
+	}
+	for _, t := range tasks {
+		due := t.Task.Due.In(loc)
+		if due.Before(today) {
+			agenda.Overdue = append(agenda.Overdue, t)
+			continue
+		}
+		day := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, loc)
+		// Counted in calendar days rather than hours, which a daylight
+		// saving change would throw off.
+		for i := range agenda.Days {
+			if today.AddDate(0, 0, i).Equal(day) {
+				agenda.Days[i].Tasks = append(agenda.Days[i].Tasks, t)
+				break
+			}
+		}
+	}
+	return agenda, nil
+}
+
+// parseAgenda checks the days an agenda covers and loads its time zone.
+func parseAgenda(tz string, days int) (*time.Location, error) {
+	if err := validate(
+		timeZoneRule("tz", tz),
+		check("days", days >= 1 && days <= MaxAgendaDays, "must be between 1 and %d", MaxAgendaDays),
+	); err != nil {
+		return nil, err
+	}
+	return time.LoadLocation(tz)
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"todolist/internal/models"
+)
+
+// agendaString renders an agenda as "overdue tasks | label: tasks | ...".
+func agendaString(a models.Agenda) string {
+	contents := func(tasks []models.ProjectTask) string {
+		var names []string
+		for _, t := range tasks {
+			names = append(names, t.Task.Content)
+		}
+		return strings.Join(names, ", ")
+	}
+	parts := []string{contents(a.Overdue)}
+	for _, d := range a.Days {
+		parts = append(parts, fmt.Sprintf("%s: %s", d.Label, contents(d.Tasks)))
+	}
+	return strings.Join(parts, " | ")
+}
+
+func TestAgendaDays(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	paris, dateFormat := "Europe/Paris", "DD.MM.YYYY"
+	if _, err := env.userSvc.UpdateSettings("alice", models.SettingsUpdate{TimeZone: &paris, DateFormat: &dateFormat}); err != nil {
+		t.Fatal(err)
+	}
+	// 23:30 on Saturday March 29 in Paris, where clocks go forward an hour
+	// the night after.
+	env.taskSvc.now = func() time.Time { return time.Date(2025, 3, 29, 22, 30, 0, 0, time.UTC) }
+
+	work := env.project(t, "alice", "work", "")
+	old := env.project(t, "alice", "old", "")
+	archived := true
+	if _, _, err := env.taskSvc.UpdateProject(context.Background(), "alice", old.ID, models.ProjectUpdate{Archived: &archived}); err != nil {
+		t.Fatal(err)
+	}
+	due := func(day, hour, min int) time.Time { return time.Date(2025, 3, day, hour, min, 0, 0, time.UTC) }
+	for _, task := range []models.Task{
+		{Content: "overdue", Due: due(28, 12, 0)},
+		{Content: "earlier today", Due: due(29, 21, 0)},
+		{Content: "after midnight", Due: due(29, 23, 30)},
+		{Content: "after the change", Due: due(30, 22, 30)},
+		{Content: "too late", Due: due(31, 22, 30)},
+		{Content: "done", Due: due(30, 12, 0), Completed: true},
+		{Content: "no due date"},
+		{Content: "due as none", Due: DefaultTimestamp},
+	} {
+		env.task(t, "alice", work.ID, task)
+	}
+	env.task(t, "alice", old.ID, models.Task{Content: "archived", Due: due(30, 12, 0)})
+
+	for _, tt := range []struct {
+		tz       string
+		archived bool
+		zone     string
+		want     string
+	}{
+		{"", false, "Europe/Paris", "overdue | 29.03.2025: earlier today | 30.03.2025: after midnight | 31.03.2025: after the change"},
+		{"", true, "Europe/Paris", "overdue | 29.03.2025: earlier today | 30.03.2025: after midnight, archived | 31.03.2025: after the change"},
+		{"UTC", false, "UTC", "overdue | 29.03.2025: earlier today, after midnight | 30.03.2025: after the change | 31.03.2025: too late"},
+		{"America/New_York", false, "America/New_York", "overdue | 29.03.2025: earlier today, after midnight | 30.03.2025: after the change | 31.03.2025: too late"},
+	} {
+		agenda, err := env.taskSvc.GetAgenda("alice", tt.tz, 3, tt.archived)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if agenda.TimeZone != tt.zone {
+			t.Errorf("agenda in %q has time zone %s, want %s", tt.tz, agenda.TimeZone, tt.zone)
+		}
+		if got := agendaString(agenda); got != tt.want {
+			t.Errorf("agenda in %q, archived %t:\n got %s\nwant %s", tt.tz, tt.archived, got, tt.want)
+		}
+		for i, d := range agenda.Days {
+			if want := fmt.Sprintf("2025-03-%d", 29+i); d.Date != want {
+				t.Errorf("day %d of the agenda in %q is %s, want %s", i, tt.tz, d.Date, want)
+			}
+		}
+	}
+
+	agenda, err := env.taskSvc.GetAgenda("alice", "", MaxAgendaDays, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := agendaString(agenda); strings.Contains(got, "no due date") || strings.Contains(got, "due as none") {
+		t.Errorf("agenda over %d days lists tasks without a due date: %s", MaxAgendaDays, got)
+	}
+}
+
+func TestAgendaRejected(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	for _, tt := range []struct {
+		tz    string
+		days  int
+		field string
+	}{
+		{"Mars/Olympus", 7, "tz"},
+		{"Local", 7, "tz"},
+		{"", 0, "days"},
+		{"", MaxAgendaDays + 1, "days"},
+	} {
+		_, err := env.taskSvc.GetAgenda("alice", tt.tz, tt.days, false)
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != tt.field {
+			t.Errorf("GetAgenda(%q, %d) = %v, want a %s field error", tt.tz, tt.days, err, tt.field)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+	"todolist/internal/models"
+	"todolist/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Batch operations.
+const (
+	BatchCreate   = "create"
+	BatchUpdate   = "update"
+	BatchComplete = "complete"
+	BatchDelete   = "delete"
+	BatchMove     = "move"
+	BatchCopy     = "copy"
+)
+
+// BatchOp is one operation of a batch. Create and update take Task, the
+// others the task ID; move and copy also take the target project.
+type BatchOp struct {
+	Op        string       `json:"op"`
+	Project   string       `json:"project"`
+	ID        string       `json:"id,omitempty"`
+	Task      *models.Task `json:"task,omitempty"`
+	ToProject string       `json:"toProject,omitempty"`
+}
+
+// BatchResult is the outcome of the operation at Index.
+type BatchResult struct {
+	Index     int          `json:"index"`
+	Op        string       `json:"op"`
+	OK        bool         `json:"ok"`
+	Task      *models.Task `json:"task,omitempty"`
+	UndoToken string       `json:"undoToken,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
+}
+
+// Batch runs ops in order. Each operation sees the effect of the ones before
+// it. In atomic mode every operation is checked first and the writes are
+// applied together or not at all; otherwise each is applied on its own and
+// failures do not stop the rest. There is one result per operation.
+func (svc *TaskService) Batch(ctx context.Context, user string, ops []BatchOp, atomic bool) ([]BatchResult, error) {
+	if err := validate(
+		check("operations", len(ops) > 0, "must not be empty"),
+		check("operations", len(ops) <= svc.limits.MaxBatchSize, "must hold at most %d operations", svc.limits.MaxBatchSize),
+	); err != nil {
+		return nil, err
+	}
+	st := svc.newBatchState(user)
+	results := make([]BatchResult, len(ops))
+	plans := make([]batchPlan, len(ops))
+	failed := false
+	for i, op := range ops {
+		results[i] = BatchResult{Index: i, Op: op.Op}
+		plan, err := st.plan(op)
+		if err == nil && !atomic {
+			err = svc.repo.ApplyTaskWrites(user, plan.writes)
+		}
+		if err != nil {
+			failed = true
+			setBatchError(&results[i], err)
+			if atomic {
+				break
+			}
+			continue
+		}
+		st.note(plan.writes)
+		plans[i] = plan
+		if !atomic {
+			svc.finishBatchOp(ctx, user, plan, &results[i])
+		}
+	}
+	if !atomic {
+		return results, nil
+	}
+
+	if !failed {
+		var writes []repository.TaskWrite
+		for _, plan := range plans {
+			writes = append(writes, plan.writes...)
+		}
+		err := svc.repo.ApplyTaskWrites(user, writes)
+		if err == nil {
+			for i, plan := range plans {
+				svc.finishBatchOp(ctx, user, plan, &results[i])
+			}
+			return results, nil
+		}
+		if !errors.Is(err, ErrTaskChanged) {
+			return nil, err
+		}
+		// A task changed after its operation was checked.
+		for i := range results {
+			results[i].Error = err.Error()
+		}
+	}
+	for i := range results {
+		if results[i].Error == "" {
+			results[i].Error = "not applied"
+		}
+	}
+	return results, ErrBatchAborted
+}
+
+func setBatchError(res *BatchResult, err error) {
+	res.Error = err.Error()
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		res.Fields = verr.Fields
+	}
+}
+
+// batchPlan is what an operation writes and how it is recorded.
+type batchPlan struct {
+	writes []repository.TaskWrite
+	action string
+	from   string // source project of a move
+	to     string
+	before *models.Task
+	after  *models.Task
+}
+
+// finishBatchOp records an applied operation in the history and fills in
+// its result.
+func (svc *TaskService) finishBatchOp(ctx context.Context, user string, plan batchPlan, res *BatchResult) {
+	res.OK = true
+	res.Task = plan.after
+	if plan.action == models.ChangeMove {
+		// The history follows the task; the move itself is then recorded
+		// in both projects.
+		if err := svc.history.MoveTaskHistory(user, plan.from, plan.to, plan.before.ID); err != nil {
+			log.Printf("Error moving history of task '%s' from project '%s' to '%s' for user '%s': %v", plan.before.ID, plan.from, plan.to, user, err)
+		}
+		res.UndoToken = svc.recordMove(ctx, user, plan.from, plan.to, *plan.before, *plan.after)
+		return
+	}
+	id := plan.writes[0].Task.ID
+	if res.Task == nil {
+		res.Task = plan.before
+	}
+	res.UndoToken = svc.record(ctx, user, plan.to, id, plan.action, plan.before, plan.after)
+}
+
+// batchState overlays the writes planned so far on the repository.
+type batchState struct {
+	svc      *TaskService
+	user     string
+	projects []models.Project
+	tasks    map[[2]string]*models.Task // nil once trashed or deleted
+}
+
+func (svc *TaskService) newBatchState(user string) *batchState {
+	return &batchState{svc: svc, user: user, tasks: make(map[[2]string]*models.Task)}
+}
+
+func (st *batchState) task(project, id string) (models.Task, bool) {
+	if t, ok := st.tasks[[2]string{project, id}]; ok {
+		if t == nil {
+			return models.Task{}, false
+		}
+		return *t, true
+	}
+	return st.svc.repo.GetTask(st.user, project, id)
+}
+
+func (st *batchState) loadProjects() error {
+	if st.projects == nil {
+		projects, err := st.svc.repo.ListProjects(st.user)
+		if err != nil {
+			return err
+		}
+		st.projects = append(projects, models.Project{}) // non-nil even without projects
+	}
+	return nil
+}
+
+// projectID resolves a project name or ID like TaskService.projectID, among
+// live projects only.
+func (st *batchState) projectID(ref string) (string, error) {
+	if err := st.loadProjects(); err != nil {
+		return "", err
+	}
+	if p, ok := findProject(st.projects, ref); ok && ref != "" {
+		return p.ID, nil
+	}
+	return ref, nil
+}
+
+func (st *batchState) projectExists(project string) (bool, error) {
+	if err := st.loadProjects(); err != nil {
+		return false, err
+	}
+	return project != "" && slices.ContainsFunc(st.projects, func(p models.Project) bool { return p.ID == project }), nil
+}
+
+func (st *batchState) note(writes []repository.TaskWrite) {
+	for _, w := range writes {
+		key := [2]string{w.Project, w.Task.ID}
+		if w.Kind == repository.WritePut {
+			t := w.Task
+			st.tasks[key] = &t
+		} else {
+			st.tasks[key] = nil
+		}
+	}
+}
+
+// plan checks op against the current state and returns its writes. It
+// applies the same rules as the single-task methods of TaskService. The
+// writes are checked, so ApplyTaskWrites refuses them with ErrTaskChanged
+// if a task they were planned against changes in the meantime.
+func (st *batchState) plan(op BatchOp) (batchPlan, error) {
+	limits := st.svc.limits
+	now := time.Now()
+	var err error
+	if op.Project, err = st.projectID(op.Project); err != nil {
+		return batchPlan{}, err
+	}
+	if op.ToProject, err = st.projectID(op.ToProject); err != nil {
+		return batchPlan{}, err
+	}
+	switch op.Op {
+	case BatchCreate:
+		if op.Task == nil {
+			return batchPlan{}, validate(check("task", false, "is required"))
+		}
+		task := *op.Task
+		if err := limits.validateTask(op.Project, task, false); err != nil {
+			return batchPlan{}, err
+		}
+		if ok, err := st.projectExists(op.Project); err != nil || !ok {
+			return batchPlan{}, orNotFound(err, ErrProjectNotFound)
+		}
+		if task.ID == "" {
+			task.ID = fmt.Sprintf("task_%s", uuid.New().String())
+		} else if _, exists := st.task(op.Project, task.ID); exists {
+			return batchPlan{}, fmt.Errorf("%w: task %s already exists in project %s", ErrTaskExists, task.ID, op.Project)
+		}
+		if task.Due.IsZero() {
+			task.Due = DefaultTimestamp
+		}
+		task.UpdatedTime = now
+		return batchPlan{
+			writes: []repository.TaskWrite{{Kind: repository.WritePut, Project: op.Project, Task: task, Checked: true}},
+			action: models.ChangeCreate, to: op.Project, after: &task,
+		}, nil
+
+	case BatchUpdate:
+		if op.Task == nil {
+			return batchPlan{}, validate(check("task", false, "is required"))
+		}
+		task := *op.Task
+		if task.ID == "" {
+			task.ID = op.ID
+		}
+		if err := validate(append([]rule{required("project", op.Project), required("id", task.ID)}, limits.taskRules(task)...)...); err != nil {
+			return batchPlan{}, err
+		}
+		existing, exists := st.task(op.Project, task.ID)
+		if !exists {
+			return batchPlan{}, ErrTaskNotFound
+		}
+		if task.Due.IsZero() {
+			task.Due = DefaultTimestamp
+		}
+		task.UpdatedTime = now
+		return batchPlan{
+			writes: []repository.TaskWrite{{Kind: repository.WritePut, Project: op.Project, Task: task, Checked: true, Before: &existing}},
+			action: models.ChangeUpdate, to: op.Project, before: &existing, after: &task,
+		}, nil
+
+	case BatchComplete, BatchDelete, BatchMove, BatchCopy:
+		if err := validateTaskRef(op.Project, op.ID); err != nil {
+			return batchPlan{}, err
+		}
+		existing, exists := st.task(op.Project, op.ID)
+		if !exists {
+			return batchPlan{}, ErrTaskNotFound
+		}
+		switch op.Op {
+		case BatchComplete:
+			completed := existing
+			if existing.Recurrence != nil {
+				completed.Due = st.svc.nextDue(st.user, existing)
+			} else {
+				completed.Completed = true
+			}
+			completed.UpdatedTime = now
+			return batchPlan{
+				writes: []repository.TaskWrite{{Kind: repository.WritePut, Project: op.Project, Task: completed, Checked: true, Before: &existing}},
+				action: models.ChangeComplete, to: op.Project, before: &existing, after: &completed,
+			}, nil
+		case BatchDelete:
+			return batchPlan{
+				writes: []repository.TaskWrite{{Kind: repository.WriteTrash, Project: op.Project, Task: existing, DeletedAt: now, Checked: true, Before: &existing}},
+				action: models.ChangeTrash, to: op.Project, before: &existing,
+			}, nil
+		}
+		if err := validate(required("toProject", op.ToProject),
+			check("toProject", op.Op == BatchCopy || op.ToProject != op.Project, "must differ from project")); err != nil {
+			return batchPlan{}, err
+		}
+		if ok, err := st.projectExists(op.ToProject); err != nil || !ok {
+			return batchPlan{}, orNotFound(err, ErrProjectNotFound)
+		}
+		if op.Op == BatchCopy {
+			copied := existing
+			copied.ID = fmt.Sprintf("task_%s", uuid.New().String())
+			copied.UpdatedTime = now
+			return batchPlan{
+				writes: []repository.TaskWrite{{Kind: repository.WritePut, Project: op.ToProject, Task: copied, Checked: true}},
+				action: models.ChangeCreate, to: op.ToProject, after: &copied,
+			}, nil
+		}
+		if _, taken := st.task(op.ToProject, op.ID); taken {
+			return batchPlan{}, fmt.Errorf("%w: task %s already exists in project %s", ErrTaskExists, op.ID, op.ToProject)
+		}
+		return batchPlan{
+			writes: []repository.TaskWrite{
+				{Kind: repository.WriteDelete, Project: op.Project, Task: existing, Checked: true, Before: &existing},
+				{Kind: repository.WritePut, Project: op.ToProject, Task: existing, Checked: true},
+			},
+			action: models.ChangeMove, from: op.Project, to: op.ToProject, before: &existing, after: &existing,
+		}, nil
+	}
+	return batchPlan{}, validate(check("op", false, "must be one of create, update, complete, delete, move and copy"))
+}
+
+// orNotFound returns err, or notFound when err is nil.
+func orNotFound(err, notFound error) error {
+	if err != nil {
+		return err
+	}
+	return notFound
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"todolist/internal/models"
+)
+
+func TestBatchReportsMissingIDAsID(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	p := env.project(t, "alice", "work", "")
+
+	results, err := env.taskSvc.Batch(context.Background(), "alice", []BatchOp{{Op: BatchComplete, Project: p.ID}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results[0].Fields) != 1 || results[0].Fields[0].Field != "id" {
+		t.Errorf("fields = %+v, want one for id, the name of BatchOp's JSON field", results[0].Fields)
+	}
+}
+
+func TestBatchPlanRefusedAfterTaskChanged(t *testing.T) {
+	for _, op := range []string{BatchUpdate, BatchComplete, BatchDelete, BatchMove} {
+		t.Run(op, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.register(t, "alice")
+			work := env.project(t, "alice", "work", "")
+			home := env.project(t, "alice", "home", "")
+			task := env.task(t, "alice", work.ID, models.Task{Content: "draft"})
+
+			planOp := BatchOp{Op: op, Project: work.ID, ID: task.ID, ToProject: home.ID, Task: &models.Task{Content: "final"}}
+			plan, err := env.taskSvc.newBatchState("alice").plan(planOp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Another request edits the task between the check and the write.
+			edited := task
+			edited.Content = "edited elsewhere"
+			env.task(t, "alice", work.ID, edited)
+
+			if err := env.tasks.ApplyTaskWrites("alice", plan.writes); !errors.Is(err, ErrTaskChanged) {
+				t.Fatalf("ApplyTaskWrites = %v, want ErrTaskChanged", err)
+			}
+			if got, ok := env.tasks.GetTask("alice", work.ID, task.ID); !ok || got.Content != "edited elsewhere" || got.Completed {
+				t.Errorf("task = %v, %v, want the concurrent edit kept", got, ok)
+			}
+		})
+	}
+}
+
+func TestAtomicBatchSeesItsOwnWrites(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	p := env.project(t, "alice", "work", "")
+	task := env.task(t, "alice", p.ID, models.Task{Content: "draft"})
+
+	ops := []BatchOp{
+		{Op: BatchUpdate, Project: p.ID, ID: task.ID, Task: &models.Task{Content: "final", Priority: 1}},
+		{Op: BatchComplete, Project: p.ID, ID: task.ID},
+	}
+	results, err := env.taskSvc.Batch(context.Background(), "alice", ops, true)
+	if err != nil {
+		t.Fatalf("Batch = %+v, %v", results, err)
+	}
+	got, _ := env.tasks.GetTask("alice", p.ID, task.ID)
+	if got.Content != "final" || got.Priority != 1 || !got.Completed {
+		t.Errorf("task = %v, want updated then completed", got)
+	}
+}
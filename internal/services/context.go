@@ -0,0 +1,19 @@
+package services
+
+import "context"
+
+type contextKey int
+
+const requestIDKey contextKey = iota
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID that is
+// recorded with every change made while serving the request.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
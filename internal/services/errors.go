@@ -1,6 +1,79 @@
 package services
 
-import "errors"
+import (
+	"errors"
+	"todolist/internal/repository"
+)
 
 // ErrTaskNotFound is returned when a task is not found.
 var ErrTaskNotFound = errors.New("task not found")
+
+// ErrUserExists is returned when registering a username that is already taken.
+var ErrUserExists = errors.New("user already exists")
+
+// ErrInvalidCredentials is returned when a username/password pair does not match.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrUserNotDeactivated is returned when reactivating an account that is still active.
+var ErrUserNotDeactivated = errors.New("user is not deactivated")
+
+// ErrGracePeriodExpired is returned when reactivating an account whose grace period has passed.
+var ErrGracePeriodExpired = errors.New("reactivation grace period has expired")
+
+// ErrProjectNotFound is returned when a project does not exist (or is in the trash).
+var ErrProjectNotFound = errors.New("project not found")
+
+// ErrProjectExists is returned when renaming a project to a name another project has.
+var ErrProjectExists = errors.New("project name already taken")
+
+// ErrProjectTrashed is returned when an operation needs a project that is in the trash.
+var ErrProjectTrashed = repository.ErrProjectTrashed
+
+// ErrNotInTrash is returned when restoring or purging an item that is not in the trash.
+var ErrNotInTrash = repository.ErrNotInTrash
+
+// ErrTaskChanged is returned when a task changes while a write planned
+// against it is applied; nothing is written.
+var ErrTaskChanged = repository.ErrTaskChanged
+
+// ErrUndoNotFound is returned when an undo token is unknown to the user.
+var ErrUndoNotFound = errors.New("undo token not found")
+
+// ErrUndoExpired is returned when an undo token is older than the undo window.
+var ErrUndoExpired = errors.New("undo window has passed")
+
+// ErrUndoConflict is returned when the task or project changed after the mutation being undone.
+var ErrUndoConflict = errors.New("changed since the mutation, cannot undo")
+
+// ErrNotUndoable is returned for mutations that cannot be reverted, such as purges.
+var ErrNotUndoable = errors.New("mutation cannot be undone")
+
+// ErrInvalidPatch is returned when a patch document is malformed or cannot be applied.
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// ErrPatchTestFailed is returned when a JSON Patch "test" operation does not match the task.
+var ErrPatchTestFailed = errors.New("patch test failed")
+
+// ErrUnsupportedPatch is returned for a patch format other than MergePatch and JSONPatch.
+var ErrUnsupportedPatch = errors.New("unsupported patch format")
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is sent again with a different request.
+var ErrIdempotencyKeyReused = errors.New("idempotency key was used for a different request")
+
+// ErrIdempotencyInProgress is returned while the first request with an idempotency key is still running.
+var ErrIdempotencyInProgress = errors.New("a request with this idempotency key is in progress")
+
+// ErrTaskExists is returned when a task ID is already taken in the target project.
+var ErrTaskExists = errors.New("task already exists")
+
+// ErrBatchAborted is returned when an operation of an atomic batch fails, so that none was applied.
+var ErrBatchAborted = errors.New("batch aborted, no operation was applied")
+
+// ErrTemplateNotFound is returned when a project template does not exist.
+var ErrTemplateNotFound = errors.New("template not found")
+
+// ErrTemplateExists is returned when saving a template under a name another template has.
+var ErrTemplateExists = errors.New("template name already taken")
+
+// ErrSmartListNotFound is returned when a smart list does not exist.
+var ErrSmartListNotFound = errors.New("smart list not found")
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"todolist/internal/models"
+)
+
+// feedBuffer is how many changes a subscriber may fall behind before further
+// changes are dropped for it.
+const feedBuffer = 64
+
+// changeFeed fans recorded changes out to the subscribers of each user.
+type changeFeed struct {
+	mu   sync.Mutex
+	subs map[string]map[chan models.TaskChange]struct{}
+}
+
+func newChangeFeed() *changeFeed {
+	return &changeFeed{subs: make(map[string]map[chan models.TaskChange]struct{})}
+}
+
+// SubscribeChanges returns a channel receiving every change made to the
+// user's tasks and projects from now on. The returned cancel function must be
+// called to release the subscription; it closes the channel. A subscriber that
+// does not keep up misses changes rather than blocking the mutations.
+func (svc *TaskService) SubscribeChanges(user string) (<-chan models.TaskChange, func()) {
+	f := svc.feed
+	ch := make(chan models.TaskChange, feedBuffer)
+	f.mu.Lock()
+	if f.subs[user] == nil {
+		f.subs[user] = make(map[chan models.TaskChange]struct{})
+	}
+	f.subs[user][ch] = struct{}{}
+	f.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			f.mu.Lock()
+			delete(f.subs[user], ch)
+			if len(f.subs[user]) == 0 {
+				delete(f.subs, user)
+			}
+			f.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+func (f *changeFeed) publish(user string, change models.TaskChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subs[user] {
+		select {
+		case ch <- change:
+		default:
+			log.Printf("Dropping change %s for a slow subscriber of user '%s'", change.ID, user)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+	"todolist/internal/filter"
+	"todolist/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Filters are written in the query language of the filter package. They run
+// over every live project, archived ones included; "not archived" leaves
+// those out. Relative dates are taken in the user's time zone, with weeks
+// starting on the user's first day of the week.
+
+// FilterTasks returns the tasks matching query, sorted by due date, then
+// priority and content. Relative dates in the query are taken from now.
+func (svc *TaskService) FilterTasks(user, query string) ([]models.ProjectTask, error) {
+	expr, err := svc.parseFilter(user, "q", query)
+	if err != nil {
+		return nil, err
+	}
+	return svc.runFilter(user, expr)
+}
+
+// parseFilter parses query, given in field, for user.
+func (svc *TaskService) parseFilter(user, field, query string) (filter.Expr, error) {
+	settings := svc.userSettings(user)
+	return svc.limits.parseFilter(field, query, svc.userClock(settings), models.WeekStarts[settings.WeekStart])
+}
+
+func (svc *TaskService) runFilter(user string, expr filter.Expr) ([]models.ProjectTask, error) {
+	projects, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := svc.repo.ListTasksMatching(user, projects, expr)
+	if err != nil {
+		return nil, err
+	}
+	listed := []models.ProjectTask{}
+	for _, p := range projects {
+		for _, t := range tasks[p.ID] {
+			listed = append(listed, models.ProjectTask{Project: p.ID, ProjectName: p.Name, Task: t})
+		}
+	}
+	sort.Slice(listed, func(i, j int) bool {
+		a, b := listed[i].Task, listed[j].Task
+		switch {
+		case !a.Due.Equal(b.Due):
+			return a.Due.Before(b.Due)
+		case a.Priority != b.Priority:
+			return a.Priority < b.Priority
+		case a.Content != b.Content:
+			return a.Content < b.Content
+		}
+		return a.ID < b.ID
+	})
+	return listed, nil
+}
+
+// smartListIDPattern matches the IDs given to smart lists. Names may not take
+// that shape, or a list could not be told apart from the one it names.
+var smartListIDPattern = regexp.MustCompile(`^sl_[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// SaveSmartList saves query as the smart list named name. Saving under the
+// name of an existing smart list replaces its query.
+func (svc *TaskService) SaveSmartList(user, name, query string) (models.SmartList, error) {
+	rules := append(svc.limits.projectNameRules("name", name),
+		check("name", !smartListIDPattern.MatchString(name), "may not have the form of a smart list ID"))
+	if err := validate(rules...); err != nil {
+		return models.SmartList{}, err
+	}
+	if _, err := svc.parseFilter(user, "query", query); err != nil {
+		return models.SmartList{}, err
+	}
+	lists, err := svc.smartLists.ListSmartLists(user)
+	if err != nil {
+		return models.SmartList{}, err
+	}
+	list := models.SmartList{
+		ID:      fmt.Sprintf("sl_%s", uuid.New().String()),
+		Name:    name,
+		Created: time.Now(),
+	}
+	for _, l := range lists {
+		if l.Name == name {
+			list = l
+			break
+		}
+	}
+	list.Query = query
+	if err := svc.smartLists.SaveSmartList(user, list); err != nil {
+		return models.SmartList{}, err
+	}
+	return list, nil
+}
+
+// GetSmartLists returns the user's smart lists sorted by name.
+func (svc *TaskService) GetSmartLists(user string) ([]models.SmartList, error) {
+	lists, err := svc.smartLists.ListSmartLists(user)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Name < lists[j].Name })
+	return lists, nil
+}
+
+// GetSmartList returns the smart list named or identified by ref.
+func (svc *TaskService) GetSmartList(user, ref string) (models.SmartList, error) {
+	if err := validate(required("list", ref)); err != nil {
+		return models.SmartList{}, err
+	}
+	lists, err := svc.smartLists.ListSmartLists(user)
+	if err != nil {
+		return models.SmartList{}, err
+	}
+	for _, l := range lists {
+		if l.ID == ref {
+			return l, nil
+		}
+	}
+	for _, l := range lists {
+		if l.Name == ref {
+			return l, nil
+		}
+	}
+	return models.SmartList{}, ErrSmartListNotFound
+}
+
+// RunSmartList returns the tasks currently matching the smart list's query,
+// sorted like FilterTasks.
+func (svc *TaskService) RunSmartList(user, ref string) ([]models.ProjectTask, error) {
+	list, err := svc.GetSmartList(user, ref)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := svc.parseFilter(user, "query", list.Query)
+	if err != nil {
+		return nil, fmt.Errorf("smart list %s: %w", list.Name, err)
+	}
+	return svc.runFilter(user, expr)
+}
+
+// RemoveSmartList deletes a smart list.
+func (svc *TaskService) RemoveSmartList(user, ref string) error {
+	list, err := svc.GetSmartList(user, ref)
+	if err != nil {
+		return err
+	}
+	return svc.smartLists.DeleteSmartList(user, list.ID)
+}
+
+// parseFilter checks and parses the filter query given in field, taking
+// relative dates from now and weeks as starting on weekStart.
+func (l Limits) parseFilter(field, query string, now time.Time, weekStart time.Weekday) (filter.Expr, error) {
+	if err := validate(required(field, query), maxLength(field, query, l.MaxContentLength)); err != nil {
+		return nil, err
+	}
+	expr, err := filter.Parse(query, now, weekStart)
+	if err != nil {
+		return nil, validate(check(field, false, "%v", err))
+	}
+	return expr, nil
+}
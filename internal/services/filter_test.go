@@ -0,0 +1,39 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSaveSmartListByName(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice")
+
+	urgent, err := env.taskSvc.SaveSmartList("alice", "urgent", "priority <= 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = env.taskSvc.SaveSmartList("alice", urgent.ID, "completed")
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != "name" {
+		t.Errorf("SaveSmartList named after a smart list ID = %v, want a name field error", err)
+	}
+	if got, _ := env.taskSvc.GetSmartList("alice", urgent.ID); got.Query != "priority <= 1" {
+		t.Errorf("urgent = %v after saving under its ID, want it unchanged", got)
+	}
+
+	notes, err := env.taskSvc.SaveSmartList("alice", "sl_notes", "content:notes")
+	if err != nil || notes.ID == urgent.ID {
+		t.Fatalf("SaveSmartList(sl_notes) = %v, %v, want a new smart list", notes, err)
+	}
+	again, err := env.taskSvc.SaveSmartList("alice", "urgent", "priority <= 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != urgent.ID || again.Query != "priority <= 2" || !again.Created.Equal(urgent.Created) {
+		t.Errorf("saving urgent again = %v, want %s with the new query", again, urgent.ID)
+	}
+	if lists, _ := env.taskSvc.GetSmartLists("alice"); len(lists) != 2 {
+		t.Errorf("smart lists = %v, want sl_notes and urgent", lists)
+	}
+}
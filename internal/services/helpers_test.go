@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"todolist/internal/models"
+	"todolist/internal/repository"
+)
+
+// testEnv holds services over in-memory repositories, with the repositories
+// at hand for checking what was stored.
+type testEnv struct {
+	tasks       *repository.InMemTaskRepository
+	history     *repository.InMemHistoryRepository
+	templates   *repository.InMemTemplateRepository
+	smartLists  *repository.InMemSmartListRepository
+	users       *repository.InMemUserRepository
+	idempotency *repository.InMemIdempotencyRepository
+
+	taskSvc *TaskService
+	userSvc *UserService
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	env := &testEnv{
+		tasks:       repository.NewInMemTaskRepository(),
+		history:     repository.NewInMemHistoryRepository(0),
+		templates:   repository.NewInMemTemplateRepository(),
+		smartLists:  repository.NewInMemSmartListRepository(),
+		users:       repository.NewInMemUserRepository(),
+		idempotency: repository.NewInMemIdempotencyRepository(time.Hour),
+	}
+	env.taskSvc = NewTaskService(env.tasks, env.history, env.templates, env.smartLists, env.users, DefaultUndoWindow, DefaultLimits())
+	env.userSvc = NewUserService(env.users, env.idempotency, DefaultGracePeriod, DefaultTokenTTL, DefaultLimits())
+	return env
+}
+
+// register creates an account, failing the test on error.
+func (env *testEnv) register(t *testing.T, username string) {
+	t.Helper()
+	if err := env.userSvc.RegisterUser(username, "secret1"); err != nil {
+		t.Fatalf("RegisterUser(%s): %v", username, err)
+	}
+}
+
+// project creates a project, failing the test on error.
+func (env *testEnv) project(t *testing.T, user, name, parent string) models.Project {
+	t.Helper()
+	p, _, err := env.taskSvc.CreateProject(context.Background(), user, name, parent)
+	if err != nil {
+		t.Fatalf("CreateProject(%s): %v", name, err)
+	}
+	return p
+}
+
+// task writes a task, failing the test on error.
+func (env *testEnv) task(t *testing.T, user, project string, task models.Task) models.Task {
+	t.Helper()
+	written, _, err := env.taskSvc.WriteTask(context.Background(), user, project, task)
+	if err != nil {
+		t.Fatalf("WriteTask(%s): %v", task.Content, err)
+	}
+	return written
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"time"
+	"todolist/internal/models"
+)
+
+// Projects nest through their ParentID. Names stay unique across the whole
+// hierarchy, so a sub-project is referenced like any other project.
+
+// What RemoveProject does with the sub-projects of the removed project.
+const (
+	// ChildrenTrash trashes every project nested below it along with it;
+	// restoring the project restores them too. It is the default.
+	ChildrenTrash = "trash"
+	// ChildrenLift moves its direct sub-projects up to its own parent.
+	ChildrenLift = "lift"
+)
+
+// checkParent fails when nesting project under parent would make the
+// project its own ancestor.
+func checkParent(projects []models.Project, project, parent string) error {
+	parents := make(map[string]string, len(projects))
+	for _, p := range projects {
+		parents[p.ID] = p.ParentID
+	}
+	seen := make(map[string]bool)
+	for id := parent; id != "" && !seen[id]; id = parents[id] {
+		if id == project {
+			return validate(check("parent", false, "must not be the project itself or one of its sub-projects"))
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// descendants returns the IDs of the projects nested below project, at any
+// depth, parents before their children.
+func descendants(projects []models.Project, project string) []string {
+	children := make(map[string][]string)
+	for _, p := range projects {
+		children[p.ParentID] = append(children[p.ParentID], p.ID)
+	}
+	var ids []string
+	seen := map[string]bool{project: true}
+	queue := []string{project}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, child := range children[id] {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+				queue = append(queue, child)
+			}
+		}
+	}
+	return ids
+}
+
+// GetProjectTree returns the user's projects as a tree, sorted by name at
+// every level, with task counts summed up the hierarchy. When root is not
+// empty only the tree below that project is returned. Archived projects, and
+// everything nested below them, are left out unless archived is set.
+func (svc *TaskService) GetProjectTree(user, root string, archived bool) ([]models.ProjectNode, error) {
+	projects, err := svc.GetProjects(user, true)
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[string]bool, len(projects))
+	for _, p := range projects {
+		live[p.ID] = true
+	}
+	var listed []models.Project
+	for _, p := range projects {
+		if archived || !p.Archived {
+			listed = append(listed, p)
+		}
+	}
+	ids := make([]string, len(listed))
+	for i, p := range listed {
+		ids[i] = p.ID
+	}
+	tasks, err := svc.repo.ListTasksInProjects(user, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// A project whose parent is gone is shown at the top level; one whose
+	// parent is hidden is hidden with it.
+	children := make(map[string][]models.Project)
+	for _, p := range listed {
+		parent := p.ParentID
+		if !live[parent] {
+			parent = ""
+		}
+		children[parent] = append(children[parent], p)
+	}
+	top := ""
+	if root != "" {
+		p, ok := findProject(projects, root)
+		if !ok {
+			return nil, ErrProjectNotFound
+		}
+		top = p.ID
+	}
+	seen := make(map[string]bool)
+	var build func(parent string) []models.ProjectNode
+	build = func(parent string) []models.ProjectNode {
+		nodes := []models.ProjectNode{}
+		for _, p := range children[parent] {
+			if seen[p.ID] {
+				continue
+			}
+			seen[p.ID] = true
+			node := models.ProjectNode{Project: p, TaskCount: len(tasks[p.ID]), Children: build(p.ID)}
+			for _, t := range tasks[p.ID] {
+				if !t.Completed {
+					node.OpenTaskCount++
+				}
+			}
+			node.TotalTaskCount, node.TotalOpenTaskCount = node.TaskCount, node.OpenTaskCount
+			for _, child := range node.Children {
+				node.TotalTaskCount += child.TotalTaskCount
+				node.TotalOpenTaskCount += child.TotalOpenTaskCount
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+	tree := build("")
+	if top == "" {
+		return tree, nil
+	}
+	if node, ok := findNode(tree, top); ok {
+		return []models.ProjectNode{node}, nil
+	}
+	return []models.ProjectNode{}, nil
+}
+
+// findNode finds the node of project id anywhere in tree.
+func findNode(tree []models.ProjectNode, id string) (models.ProjectNode, bool) {
+	for _, node := range tree {
+		if node.Project.ID == id {
+			return node, true
+		}
+		if found, ok := findNode(node.Children, id); ok {
+			return found, true
+		}
+	}
+	return models.ProjectNode{}, false
+}
+
+// removeChildren applies the children rule of RemoveProject before project
+// itself is trashed at deletedAt, and returns the IDs of the sub-projects it
+// lifted.
+func (svc *TaskService) removeChildren(ctx context.Context, user string, projects []models.Project, project models.Project, children string, deletedAt time.Time) ([]string, error) {
+	if children == ChildrenLift {
+		var ids []string
+		for _, p := range projects {
+			if p.ParentID != project.ID {
+				continue
+			}
+			lifted := p
+			lifted.ParentID = project.ParentID
+			if err := svc.repo.UpdateProject(user, lifted); err != nil {
+				return ids, err
+			}
+			svc.recordProjectUpdate(ctx, user, p.ID, diffProjects(p, lifted))
+			ids = append(ids, p.ID)
+		}
+		return ids, nil
+	}
+	for _, id := range descendants(projects, project.ID) {
+		if err := svc.repo.TrashProject(user, id, deletedAt); err != nil {
+			return nil, err
+		}
+		svc.record(ctx, user, id, "", models.ChangeTrash, nil, nil)
+	}
+	return nil, nil
+}
+
+// checkLifted refuses to undo the trash of a project whose lifted
+// sub-projects are no longer where the trash left them: live, directly below
+// the trashed project's parent.
+func (svc *TaskService) checkLifted(user string, change models.TaskChange) error {
+	if len(change.Lifted) == 0 {
+		return nil
+	}
+	items, err := svc.repo.ListTrash(user)
+	if err != nil {
+		return err
+	}
+	parent, found := "", false
+	for _, item := range items {
+		if item.Kind == models.TrashKindProject && item.Project == change.Project {
+			parent, found = item.Parent, true
+		}
+	}
+	if !found {
+		return ErrNotInTrash
+	}
+	projects, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return err
+	}
+	for _, id := range change.Lifted {
+		p, ok := findProjectByID(projects, id)
+		if !ok || p.ParentID != parent {
+			return ErrUndoConflict
+		}
+	}
+	return nil
+}
+
+// renestLifted moves the sub-projects lifted by change back below the
+// project, once it is restored.
+func (svc *TaskService) renestLifted(ctx context.Context, user string, change models.TaskChange) error {
+	projects, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return err
+	}
+	for _, id := range change.Lifted {
+		p, ok := findProjectByID(projects, id)
+		if !ok {
+			continue
+		}
+		nested := p
+		nested.ParentID = change.Project
+		if err := svc.repo.UpdateProject(user, nested); err != nil {
+			return err
+		}
+		svc.recordProjectUpdate(ctx, user, p.ID, diffProjects(p, nested))
+	}
+	return nil
+}
+
+// restoreProjectTree restores a trashed project together with the
+// sub-projects trashed at the same moment. A project cannot be restored while
+// its parent is in the trash; one whose parent was purged returns at the top
+// level. Only the restores of sub-projects are recorded.
+func (svc *TaskService) restoreProjectTree(ctx context.Context, user, project string) error {
+	items, err := svc.repo.ListTrash(user)
+	if err != nil {
+		return err
+	}
+	trashed := make(map[string]models.TrashItem)
+	for _, item := range items {
+		if item.Kind == models.TrashKindProject {
+			trashed[item.Project] = item
+		}
+	}
+	item, ok := trashed[project]
+	if !ok {
+		return ErrNotInTrash
+	}
+	if _, ok := trashed[item.Parent]; ok {
+		return ErrProjectTrashed
+	}
+	if err := svc.repo.RestoreProject(user, project); err != nil {
+		return err
+	}
+	if item.Parent != "" {
+		projects, err := svc.repo.ListProjects(user)
+		if err != nil {
+			return err
+		}
+		if restored, ok := findProject(projects, project); ok && !projectExists(projects, item.Parent) {
+			restored.ParentID = ""
+			if err := svc.repo.UpdateProject(user, restored); err != nil {
+				return err
+			}
+		}
+	}
+
+	var restoreChildren func(parent string) error
+	restoreChildren = func(parent string) error {
+		for _, child := range items {
+			if child.Kind != models.TrashKindProject || child.Parent != parent || !child.DeletedAt.Equal(item.DeletedAt) {
+				continue
+			}
+			if err := svc.repo.RestoreProject(user, child.Project); err != nil {
+				return err
+			}
+			svc.record(ctx, user, child.Project, "", models.ChangeRestore, nil, nil)
+			if err := restoreChildren(child.Project); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return restoreChildren(project)
+}
+
+// trashProjectTree trashes a project and everything nested below it, for
+// undoing its restore.
+func (svc *TaskService) trashProjectTree(user, project string) error {
+	projects, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, id := range append(descendants(projects, project), project) {
+		if err := svc.repo.TrashProject(user, id, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeSubProjects permanently deletes the trashed projects nested below
+// project, which is being purged.
+func (svc *TaskService) purgeSubProjects(ctx context.Context, user, project string) error {
+	items, err := svc.repo.ListTrash(user)
+	if err != nil {
+		return err
+	}
+	var trashed []models.Project
+	for _, item := range items {
+		if item.Kind == models.TrashKindProject {
+			trashed = append(trashed, models.Project{ID: item.Project, ParentID: item.Parent})
+		}
+	}
+	for _, id := range descendants(trashed, project) {
+		if err := svc.repo.DeleteProject(user, id); err != nil {
+			return err
+		}
+		svc.record(ctx, user, id, "", models.ChangePurge, nil, nil)
+	}
+	return nil
+}
+
+func projectExists(projects []models.Project, id string) bool {
+	_, ok := findProjectByID(projects, id)
+	return ok
+}
+
+func findProjectByID(projects []models.Project, id string) (models.Project, bool) {
+	for _, p := range projects {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return models.Project{}, false
+}
+
+func validateRemoveProject(project, children string) error {
+	return validate(required("project", project),
+		check("children", children == ChildrenTrash || children == ChildrenLift, "must be %s or %s", ChildrenTrash, ChildrenLift))
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"todolist/internal/models"
+)
+
+func TestMoveProjectRejectsCycles(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	work := env.project(t, "alice", "work", "")
+	alpha := env.project(t, "alice", "alpha", "work")
+	env.project(t, "alice", "beta", "alpha")
+	env.project(t, "alice", "home", "")
+
+	for _, parent := range []string{"work", "alpha", "beta"} {
+		_, _, err := env.taskSvc.UpdateProject(ctx, "alice", "work", models.ProjectUpdate{Parent: &parent})
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != "parent" {
+			t.Errorf("moving work under %s: err = %v, want a parent field error", parent, err)
+		}
+	}
+	projects := mustProjects(t, env, "alice")
+	if p, _ := findProjectByID(projects, work.ID); p.ParentID != "" {
+		t.Errorf("work moved under %s by a rejected move", p.ParentID)
+	}
+
+	// Moving a sub-project elsewhere, or to the top level, is fine.
+	home := "home"
+	moved, _, err := env.taskSvc.UpdateProject(ctx, "alice", "alpha", models.ProjectUpdate{Parent: &home})
+	if err != nil || moved.ID != alpha.ID || moved.ParentID == "" || moved.ParentID == work.ID {
+		t.Errorf("moving alpha under home = %v, %v", moved, err)
+	}
+	top := ""
+	if moved, _, err := env.taskSvc.UpdateProject(ctx, "alice", "alpha", models.ProjectUpdate{Parent: &top}); err != nil || moved.ParentID != "" {
+		t.Errorf("moving alpha to the top level = %v, %v", moved, err)
+	}
+}
+
+func TestRestoreChildOfTrashedParent(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	home := env.project(t, "alice", "home", "")
+	garden := env.project(t, "alice", "garden", "home")
+	if _, err := env.taskSvc.RemoveProject(ctx, "alice", "home", ChildrenTrash); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := env.taskSvc.RestoreProject(ctx, "alice", "garden"); !errors.Is(err, ErrProjectTrashed) {
+		t.Errorf("restoring garden while home is trashed: err = %v, want ErrProjectTrashed", err)
+	}
+	if projects := mustProjects(t, env, "alice"); len(projects) != 0 {
+		t.Errorf("projects = %v after a refused restore, want none", projects)
+	}
+
+	// Restoring the parent brings the child back below it.
+	if _, err := env.taskSvc.RestoreProject(ctx, "alice", "home"); err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := findProjectByID(mustProjects(t, env, "alice"), garden.ID); !ok || p.ParentID != home.ID {
+		t.Errorf("garden = %+v, %v after restoring home, want it below home", p, ok)
+	}
+
+	// A child whose parent was purged returns at the top level. The sweeper
+	// purges each trashed project once it expires, so a parent can go first.
+	if _, err := env.taskSvc.RemoveProject(ctx, "alice", "garden", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.taskSvc.RemoveProject(ctx, "alice", "home", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.tasks.DeleteProject("alice", home.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.taskSvc.RestoreProject(ctx, "alice", "garden"); err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := findProjectByID(mustProjects(t, env, "alice"), garden.ID); !ok || p.ParentID != "" {
+		t.Errorf("garden = %+v, %v after its parent was purged, want it at the top level", p, ok)
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+	"todolist/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// GetTaskHistory returns every recorded change of the task, oldest first.
+func (svc *TaskService) GetTaskHistory(user, project, taskID string) ([]models.TaskChange, error) {
+	if err := validateTaskRef(project, taskID); err != nil {
+		return nil, err
+	}
+	return svc.history.ListTaskHistory(user, svc.projectID(user, project), taskID)
+}
+
+// GetProjectHistory returns every recorded change in the project, oldest first.
+func (svc *TaskService) GetProjectHistory(user, project string) ([]models.TaskChange, error) {
+	if err := validateProjectRef(project); err != nil {
+		return nil, err
+	}
+	return svc.history.ListProjectHistory(user, svc.projectID(user, project))
+}
+
+// record appends a history entry for a mutation that has already been
+// applied and returns its ID, which doubles as the undo token. before and
+// after are nil when the task did not exist on that side of the change. A
+// failure to record is logged rather than returned, since the mutation itself
+// has succeeded; the returned token is then empty. Subscribers are notified
+// either way.
+func (svc *TaskService) record(ctx context.Context, user, project, taskID, action string, before, after *models.Task) string {
+	change := models.TaskChange{
+		ID:        fmt.Sprintf("chg_%s", uuid.New().String()),
+		Project:   project,
+		TaskID:    taskID,
+		Actor:     user,
+		Action:    action,
+		Time:      time.Now(),
+		RequestID: RequestIDFromContext(ctx),
+		Changes:   diffTasks(before, after),
+		Before:    before,
+		After:     after,
+	}
+	return svc.addChange(user, change)
+}
+
+// addChange stores and publishes a change built by record or one of its
+// variants and updates the search index, returning its ID or "" when it could
+// not be stored.
+func (svc *TaskService) addChange(user string, change models.TaskChange) string {
+	if err := svc.history.AddChange(user, change); err != nil {
+		log.Printf("Error recording %s of task '%s' in project '%s' for user '%s': %v", change.Action, change.TaskID, change.Project, user, err)
+		change.ID = ""
+	}
+	svc.indexChange(user, change)
+	svc.feed.publish(user, change)
+	return change.ID
+}
+
+// recordMove records a task moving between projects in the history of both,
+// with the project itself as the first field change. Only the entry of the
+// target project, whose ID is returned, can be undone.
+func (svc *TaskService) recordMove(ctx context.Context, user, from, to string, before, after models.Task) string {
+	moved := models.FieldChange{Field: "project", Old: from, New: to}
+	change := models.TaskChange{
+		ID:        fmt.Sprintf("chg_%s", uuid.New().String()),
+		Project:   from,
+		TaskID:    before.ID,
+		Actor:     user,
+		Action:    models.ChangeMove,
+		Time:      time.Now(),
+		RequestID: RequestIDFromContext(ctx),
+		Changes:   []models.FieldChange{moved},
+		Before:    &before,
+	}
+	svc.addChange(user, change)
+
+	change.ID = fmt.Sprintf("chg_%s", uuid.New().String())
+	change.Project = to
+	change.TaskID = after.ID
+	change.Changes = append([]models.FieldChange{moved}, diffTasks(&before, &after)...)
+	change.After = &after
+	return svc.addChange(user, change)
+}
+
+// moveSource returns the project a move change took the task from.
+func moveSource(change models.TaskChange) (string, bool) {
+	for _, fc := range change.Changes {
+		if fc.Field == "project" {
+			return fc.Old, true
+		}
+	}
+	return "", false
+}
+
+// diffTasks lists the user-editable fields that differ between before and after.
+func diffTasks(before, after *models.Task) []models.FieldChange {
+	if after == nil {
+		return nil
+	}
+	fields := func(t *models.Task) [5]string {
+		if t == nil {
+			return [5]string{}
+		}
+		recurrence := ""
+		if t.Recurrence != nil {
+			recurrence = t.Recurrence.String()
+		}
+		return [5]string{t.Content, strconv.Itoa(t.Priority), t.Due.UTC().Format(time.RFC3339), strconv.FormatBool(t.Completed), recurrence}
+	}
+	names := [5]string{"content", "priority", "due", "completed", "recurrence"}
+	old, cur := fields(before), fields(after)
+	var changes []models.FieldChange
+	for i := range names {
+		if old[i] != cur[i] {
+			changes = append(changes, models.FieldChange{Field: names[i], Old: old[i], New: cur[i]})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"log"
+	"time"
+	"todolist/internal/models"
+	"todolist/internal/repository"
+)
+
+// DefaultIdempotencyWindow is how long the response to a write made with an
+// idempotency key is replayed.
+const DefaultIdempotencyWindow = 24 * time.Hour
+
+// IdempotencyLease is how long a request holds its idempotency key before
+// storing a response. A retry arriving later takes the key over, so that a
+// key whose request died with the server does not stay in progress.
+const IdempotencyLease = time.Minute
+
+// IdempotencyService remembers the responses of writes sent with an
+// idempotency key so that retries return them instead of writing again.
+type IdempotencyService struct {
+	repo  repository.IdempotencyRepository
+	lease time.Duration
+}
+
+func NewIdempotencyService(repo repository.IdempotencyRepository) *IdempotencyService {
+	return &IdempotencyService{repo: repo, lease: IdempotencyLease}
+}
+
+// Begin claims key for the request identified by fingerprint. It returns the
+// lease the request holds the key with when the request should run, or the
+// stored record when it already ran and its response should be replayed.
+func (svc *IdempotencyService) Begin(user, key, fingerprint string) (time.Time, *models.IdempotencyRecord, error) {
+	if err := validate(required("key", key), maxLength("key", key, 255)); err != nil {
+		return time.Time{}, nil, err
+	}
+	// Cassandra stores milliseconds; the lease is compared as stored.
+	now := time.Now().Truncate(time.Millisecond)
+	rec := models.IdempotencyRecord{Key: key, Fingerprint: fingerprint, CreatedAt: now, LeaseUntil: now.Add(svc.lease)}
+	stored, reserved, err := svc.repo.ReserveKey(user, rec)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	switch {
+	case reserved:
+		return rec.LeaseUntil, nil, nil
+	case stored.Fingerprint != fingerprint:
+		return time.Time{}, nil, ErrIdempotencyKeyReused
+	case stored.Status == 0 && now.Before(stored.LeaseUntil):
+		return time.Time{}, nil, ErrIdempotencyInProgress
+	case stored.Status == 0:
+		tookOver, err := svc.repo.TakeOverKey(user, rec, stored.LeaseUntil)
+		if err != nil {
+			return time.Time{}, nil, err
+		}
+		if !tookOver {
+			return time.Time{}, nil, ErrIdempotencyInProgress
+		}
+		log.Printf("Idempotency key '%s' taken over after its lease lapsed at %s", key, stored.LeaseUntil.Format(time.RFC3339))
+		return rec.LeaseUntil, nil, nil
+	}
+	return time.Time{}, &stored, nil
+}
+
+// Finish stores the response of a request started with Begin, given the lease
+// Begin returned.
+func (svc *IdempotencyService) Finish(user string, lease time.Time, rec models.IdempotencyRecord) {
+	rec.LeaseUntil = lease
+	if err := svc.repo.SaveResponse(user, rec); err != nil {
+		log.Printf("Error saving response for idempotency key '%s': %v", rec.Key, err)
+	}
+}
+
+// Abort frees key so the request can be retried, for failures that did not
+// change anything.
+func (svc *IdempotencyService) Abort(user, key string, lease time.Time) {
+	if err := svc.repo.ReleaseKey(user, key, lease); err != nil {
+		log.Printf("Error releasing idempotency key '%s': %v", key, err)
+	}
+}
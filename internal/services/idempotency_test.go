@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"todolist/internal/models"
+	"todolist/internal/repository"
+)
+
+func TestIdempotencyRetryTakesOverLapsedLease(t *testing.T) {
+	svc := NewIdempotencyService(repository.NewInMemIdempotencyRepository(time.Hour))
+	svc.lease = 20 * time.Millisecond
+
+	first, stored, err := svc.Begin("alice", "k", "fp")
+	if err != nil || stored != nil || first.IsZero() {
+		t.Fatalf("Begin = %v, %v, %v, want a lease", first, stored, err)
+	}
+	if _, _, err := svc.Begin("alice", "k", "fp"); !errors.Is(err, ErrIdempotencyInProgress) {
+		t.Errorf("Begin while the lease runs = %v, want ErrIdempotencyInProgress", err)
+	}
+	if _, _, err := svc.Begin("alice", "k", "other"); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("Begin with another request = %v, want ErrIdempotencyKeyReused", err)
+	}
+
+	time.Sleep(2 * svc.lease)
+	retry, stored, err := svc.Begin("alice", "k", "fp")
+	if err != nil || stored != nil || !retry.After(first) {
+		t.Fatalf("Begin after the lease lapsed = %v, %v, %v, want a new lease", retry, stored, err)
+	}
+
+	// The first request's response comes too late to be stored.
+	svc.Finish("alice", first, models.IdempotencyRecord{Key: "k", Fingerprint: "fp", Status: 500, CreatedAt: time.Now()})
+	svc.Finish("alice", retry, models.IdempotencyRecord{Key: "k", Fingerprint: "fp", Status: 201, Body: []byte("ok"), CreatedAt: time.Now()})
+	_, stored, err = svc.Begin("alice", "k", "fp")
+	if err != nil || stored == nil || stored.Status != 201 || string(stored.Body) != "ok" {
+		t.Fatalf("Begin after Finish = %+v, %v, want the retry's response", stored, err)
+	}
+}
+
+func TestIdempotencyAbortFreesKey(t *testing.T) {
+	svc := NewIdempotencyService(repository.NewInMemIdempotencyRepository(time.Hour))
+	lease, _, err := svc.Begin("alice", "k", "fp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.Abort("alice", "k", lease)
+	if _, stored, err := svc.Begin("alice", "k", "fp"); err != nil || stored != nil {
+		t.Errorf("Begin after Abort = %v, %v, want the key reserved again", stored, err)
+	}
+}
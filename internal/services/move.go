@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"todolist/internal/models"
+)
+
+// MoveTask moves a task to another project, keeping its ID and UpdatedTime.
+// Its history moves along. The task is removed from the source and written to
+// the target in one step, so it is never visible in both or neither. It fails
+// with ErrTaskExists when the target project has a task with the same ID.
+func (svc *TaskService) MoveTask(ctx context.Context, user, from, to, taskID string) (models.Task, string, error) {
+	return svc.transfer(ctx, user, BatchOp{Op: BatchMove, Project: from, ID: taskID, ToProject: to})
+}
+
+// CopyTask copies a task into a project, which may be its own, under a new ID.
+// The copy starts a history of its own.
+func (svc *TaskService) CopyTask(ctx context.Context, user, from, to, taskID string) (models.Task, string, error) {
+	return svc.transfer(ctx, user, BatchOp{Op: BatchCopy, Project: from, ID: taskID, ToProject: to})
+}
+
+// transfer plans and applies a single move or copy.
+func (svc *TaskService) transfer(ctx context.Context, user string, op BatchOp) (models.Task, string, error) {
+	plan, err := svc.newBatchState(user).plan(op)
+	if err != nil {
+		return models.Task{}, "", err
+	}
+	if err := svc.repo.ApplyTaskWrites(user, plan.writes); err != nil {
+		return models.Task{}, "", err
+	}
+	var res BatchResult
+	svc.finishBatchOp(ctx, user, plan, &res)
+	return *res.Task, res.UndoToken, nil
+}
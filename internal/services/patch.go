@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"todolist/internal/models"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// Patch formats accepted by PatchTask, named by their media types.
+const (
+	MergePatch = "application/merge-patch+json" // RFC 7396
+	JSONPatch  = "application/json-patch+json"  // RFC 6902
+)
+
+// PatchTask applies patch, in the given format, to the JSON form of an
+// existing task. Only the fields the patch changes are written; the merged
+// task is validated as a whole. Setting due to null clears the due date, and
+// recurrence to null stops the task repeating. A patch that changes nothing
+// returns the task without an undo token.
+func (svc *TaskService) PatchTask(ctx context.Context, user, project, taskID, format string, patch []byte) (models.Task, string, error) {
+	if err := validateTaskRef(project, taskID); err != nil {
+		return models.Task{}, "", err
+	}
+	project = svc.projectID(user, project)
+	existing, exist := svc.repo.GetTask(user, project, taskID)
+	if !exist {
+		return models.Task{}, "", ErrTaskNotFound
+	}
+	doc, err := json.Marshal(existing)
+	if err != nil {
+		return models.Task{}, "", err
+	}
+	patched, err := applyPatch(format, doc, patch)
+	if err != nil {
+		return models.Task{}, "", err
+	}
+	task, err := svc.decodePatchedTask(existing, patched)
+	if err != nil {
+		return models.Task{}, "", err
+	}
+
+	update := taskDiff(existing, task)
+	if update.IsEmpty() {
+		return existing, "", nil
+	}
+	if err := svc.repo.UpdateTaskFields(user, project, taskID, update); err != nil {
+		return models.Task{}, "", err
+	}
+	updated, _ := svc.repo.GetTask(user, project, taskID)
+	return updated, svc.record(ctx, user, project, taskID, models.ChangeUpdate, &existing, &updated), nil
+}
+
+func applyPatch(format string, doc, patch []byte) ([]byte, error) {
+	switch format {
+	case MergePatch:
+		out, err := jsonpatch.MergePatch(doc, patch)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		return out, nil
+	case JSONPatch:
+		p, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		out, err := p.Apply(doc)
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return nil, fmt.Errorf("%w: %v", ErrPatchTestFailed, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedPatch, format)
+}
+
+// patchableFields are the JSON fields of a task a patch may set; id and
+// updatedTime may be present but not changed.
+var patchableFields = []string{"id", "content", "priority", "updatedTime", "due", "completed", "recurrence"}
+
+// decodePatchedTask decodes the patched document field by field so that each
+// type error is reported against its field, then validates the result.
+func (svc *TaskService) decodePatchedTask(existing models.Task, patched []byte) (models.Task, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(patched, &raw); err != nil {
+		return models.Task{}, validate(check("task", false, "must be a JSON object"))
+	}
+
+	var task models.Task
+	targets := map[string]any{
+		"id": &task.ID, "content": &task.Content, "priority": &task.Priority,
+		"updatedTime": &task.UpdatedTime, "due": &task.Due, "completed": &task.Completed,
+		"recurrence": &task.Recurrence,
+	}
+	var rules []rule
+	for _, field := range patchableFields {
+		if value, ok := raw[field]; ok {
+			err := json.Unmarshal(value, targets[field])
+			rules = append(rules, check(field, err == nil, "has the wrong type"))
+		}
+	}
+	unknown := make([]string, 0, len(raw))
+	for field := range raw {
+		if !slices.Contains(patchableFields, field) {
+			unknown = append(unknown, field)
+		}
+	}
+	slices.Sort(unknown)
+	for _, field := range unknown {
+		rules = append(rules, check(field, false, "is not a task field"))
+	}
+	if task.Due.IsZero() {
+		task.Due = DefaultTimestamp
+	}
+	rules = append(rules,
+		check("id", task.ID == existing.ID, "cannot be changed"),
+		check("updatedTime", task.UpdatedTime.Equal(existing.UpdatedTime), "is set by the server"),
+	)
+	rules = append(rules, svc.limits.taskRules(task)...)
+	if err := validate(rules...); err != nil {
+		return models.Task{}, err
+	}
+	return task, nil
+}
+
+// taskDiff lists the fields that differ between before and after.
+func taskDiff(before, after models.Task) models.TaskUpdate {
+	var update models.TaskUpdate
+	if after.Content != before.Content {
+		update.Content = &after.Content
+	}
+	if after.Priority != before.Priority {
+		update.Priority = &after.Priority
+	}
+	if !after.Due.Equal(before.Due) {
+		update.Due = &after.Due
+	}
+	if after.Completed != before.Completed {
+		update.Completed = &after.Completed
+	}
+	switch {
+	case after.Recurrence == nil && before.Recurrence != nil:
+		update.Recurrence = &models.Recurrence{}
+	case after.Recurrence != nil && (before.Recurrence == nil || *after.Recurrence != *before.Recurrence):
+		update.Recurrence = after.Recurrence
+	}
+	return update
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"todolist/internal/models"
+)
+
+func TestPatchTask(t *testing.T) {
+	due := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	original := models.Task{Content: "Pay rent", Priority: 2, Due: due, Recurrence: &models.Recurrence{Interval: 1, Unit: models.RecurMonth}}
+	tests := []struct {
+		name   string
+		format string
+		patch  string
+		want   func(task *models.Task) // applied to the original for the expected result
+	}{
+		{"missing fields are left unchanged", MergePatch, `{"priority":5}`,
+			func(task *models.Task) { task.Priority = 5 }},
+		{"null due resets it to none", MergePatch, `{"due":null}`,
+			func(task *models.Task) { task.Due = DefaultTimestamp }},
+		{"null priority resets it to the default", MergePatch, `{"priority":null}`,
+			func(task *models.Task) { task.Priority = 0 }},
+		{"several fields", MergePatch, `{"content":"Pay the rent","completed":true}`,
+			func(task *models.Task) { task.Content, task.Completed = "Pay the rent", true }},
+		{"json patch test then replace", JSONPatch, `[{"op":"test","path":"/content","value":"Pay rent"},{"op":"replace","path":"/content","value":"Pay rent now"}]`,
+			func(task *models.Task) { task.Content = "Pay rent now" }},
+		{"json patch remove", JSONPatch, `[{"op":"remove","path":"/due"},{"op":"remove","path":"/recurrence"}]`,
+			func(task *models.Task) { task.Due, task.Recurrence = DefaultTimestamp, nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.register(t, "alice")
+			p := env.project(t, "alice", "home", "")
+			task := env.task(t, "alice", p.ID, original)
+
+			patched, token, err := env.taskSvc.PatchTask(context.Background(), "alice", "home", task.ID, tt.format, []byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token == "" {
+				t.Error("no undo token for a change")
+			}
+			want := task
+			tt.want(&want)
+			stored, _ := env.tasks.GetTask("alice", p.ID, task.ID)
+			for _, got := range []models.Task{patched, stored} {
+				if got.Content != want.Content || got.Priority != want.Priority || !got.Due.Equal(want.Due) ||
+					got.Completed != want.Completed || (got.Recurrence == nil) != (want.Recurrence == nil) {
+					t.Errorf("task = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPatchTaskRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		patch  string
+		err    error
+		fields []string // rejected fields of a validation error
+	}{
+		{"failed json patch test", JSONPatch, `[{"op":"test","path":"/content","value":"other"},{"op":"replace","path":"/priority","value":1}]`, ErrPatchTestFailed, nil},
+		{"malformed json patch", JSONPatch, `{"op":"remove"}`, ErrInvalidPatch, nil},
+		{"remove of a missing field", JSONPatch, `[{"op":"remove","path":"/missing"}]`, ErrInvalidPatch, nil},
+		{"unsupported format", "application/json", `{}`, ErrUnsupportedPatch, nil},
+		{"merged result out of range", MergePatch, `{"priority":11}`, ErrValidation, []string{"priority"}},
+		{"null content leaves it empty", MergePatch, `{"content":null}`, ErrValidation, []string{"content"}},
+		{"every invalid field", MergePatch, `{"content":"","priority":"high","due":"1999-12-31T00:00:00Z","color":"red"}`, ErrValidation, []string{"priority", "color", "content", "due"}},
+		{"id change", JSONPatch, `[{"op":"replace","path":"/id","value":"task_other"}]`, ErrValidation, []string{"id"}},
+		{"server-set updatedTime", MergePatch, `{"updatedTime":"2020-01-01T00:00:00Z"}`, ErrValidation, []string{"updatedTime"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.register(t, "alice")
+			p := env.project(t, "alice", "home", "")
+			task := env.task(t, "alice", p.ID, models.Task{Content: "Pay rent", Priority: 2})
+
+			_, _, err := env.taskSvc.PatchTask(context.Background(), "alice", "home", task.ID, tt.format, []byte(tt.patch))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				var fields []string
+				for _, f := range verr.Fields {
+					fields = append(fields, f.Field)
+				}
+				if len(fields) != len(tt.fields) {
+					t.Errorf("rejected fields = %v, want %v", fields, tt.fields)
+				}
+				for i := range min(len(fields), len(tt.fields)) {
+					if fields[i] != tt.fields[i] {
+						t.Errorf("rejected fields = %v, want %v", fields, tt.fields)
+						break
+					}
+				}
+			}
+			if stored, _ := env.tasks.GetTask("alice", p.ID, task.ID); stored.Content != task.Content || stored.Priority != task.Priority || !stored.UpdatedTime.Equal(task.UpdatedTime) {
+				t.Errorf("task = %v after a rejected patch, want it unchanged", stored)
+			}
+		})
+	}
+}
+
+func TestPatchTaskWithoutChange(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	p := env.project(t, "alice", "home", "")
+	task := env.task(t, "alice", p.ID, models.Task{Content: "Pay rent", Priority: 2})
+
+	patched, token, err := env.taskSvc.PatchTask(context.Background(), "alice", "home", task.ID, MergePatch, []byte(`{"priority":2}`))
+	if err != nil || token != "" || !patched.UpdatedTime.Equal(task.UpdatedTime) {
+		t.Errorf("PatchTask = %v, %q, %v; want the task unchanged and no undo token", patched, token, err)
+	}
+}
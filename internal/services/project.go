@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+	"todolist/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Every method taking a project accepts its name or its ID. IDs are looked up
+// first, so a project can always be reached by its ID whatever the other
+// projects are named; history entries and trash items refer to projects by
+// ID.
+
+// GetProjects returns the user's projects sorted by name. Archived projects
+// are left out unless archived is set.
+func (svc *TaskService) GetProjects(user string, archived bool) ([]models.Project, error) {
+	projects, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return nil, err
+	}
+	listed := make([]models.Project, 0, len(projects))
+	for _, p := range projects {
+		if archived || !p.Archived {
+			listed = append(listed, p)
+		}
+	}
+	sort.Slice(listed, func(i, j int) bool { return listed[i].Name < listed[j].Name })
+	return listed, nil
+}
+
+// GetProject returns the live project, archived or not, named or identified
+// by ref.
+func (svc *TaskService) GetProject(user, ref string) (models.Project, error) {
+	if err := validateProjectRef(ref); err != nil {
+		return models.Project{}, err
+	}
+	projects, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return models.Project{}, err
+	}
+	if p, ok := findProject(projects, ref); ok {
+		return p, nil
+	}
+	return models.Project{}, ErrProjectNotFound
+}
+
+// CreateProject creates a project named name under a new ID, nested in
+// parent when that is not empty. Creating a project whose name is taken
+// returns the existing project and no undo token, unless that project is in
+// the trash.
+func (svc *TaskService) CreateProject(ctx context.Context, user, name, parent string) (models.Project, string, error) {
+	if err := svc.limits.validateNewProject(name); err != nil {
+		return models.Project{}, "", err
+	}
+	projects, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return models.Project{}, "", err
+	}
+	for _, p := range projects {
+		if p.Name == name {
+			return p, "", nil
+		}
+	}
+	parentID := ""
+	if parent != "" {
+		p, ok := findProject(projects, parent)
+		if !ok {
+			return models.Project{}, "", ErrProjectNotFound
+		}
+		parentID = p.ID
+	}
+	if _, trashed, err := svc.nameTaken(user, name, ""); err != nil {
+		return models.Project{}, "", err
+	} else if trashed {
+		return models.Project{}, "", ErrProjectTrashed
+	}
+	project := models.Project{
+		ID:       fmt.Sprintf("pjt_%s", uuid.New().String()),
+		Name:     name,
+		Created:  time.Now(),
+		ParentID: parentID,
+	}
+	if err := svc.repo.CreateProject(user, project); err != nil {
+		return models.Project{}, "", err
+	}
+	return project, svc.record(ctx, user, project.ID, "", models.ChangeCreate, nil, nil), nil
+}
+
+// UpdateProject renames, describes, recolors, archives, unarchives or moves
+// a project. Its tasks are left untouched. The undo token is empty when the
+// update changed nothing.
+func (svc *TaskService) UpdateProject(ctx context.Context, user, ref string, update models.ProjectUpdate) (models.Project, string, error) {
+	if err := svc.limits.validateProjectUpdate(ref, update); err != nil {
+		return models.Project{}, "", err
+	}
+	projects, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return models.Project{}, "", err
+	}
+	existing, ok := findProject(projects, ref)
+	if !ok {
+		return models.Project{}, "", ErrProjectNotFound
+	}
+	if update.Parent != nil && *update.Parent != "" {
+		parent, ok := findProject(projects, *update.Parent)
+		if !ok {
+			return models.Project{}, "", ErrProjectNotFound
+		}
+		if err := checkParent(projects, existing.ID, parent.ID); err != nil {
+			return models.Project{}, "", err
+		}
+		update.Parent = &parent.ID
+	}
+	updated := update.Apply(existing)
+	changes := diffProjects(existing, updated)
+	if len(changes) == 0 {
+		return existing, "", nil
+	}
+	if updated.Name != existing.Name {
+		if live, trashed, err := svc.nameTaken(user, updated.Name, existing.ID); err != nil {
+			return models.Project{}, "", err
+		} else if live || trashed {
+			return models.Project{}, "", ErrProjectExists
+		}
+	}
+	if err := svc.repo.UpdateProject(user, updated); err != nil {
+		return models.Project{}, "", err
+	}
+	return updated, svc.recordProjectUpdate(ctx, user, existing.ID, changes), nil
+}
+
+// recordProjectUpdate records changes to the fields of a project, returning
+// the undo token.
+func (svc *TaskService) recordProjectUpdate(ctx context.Context, user, project string, changes []models.FieldChange) string {
+	return svc.addChange(user, models.TaskChange{
+		ID:        fmt.Sprintf("chg_%s", uuid.New().String()),
+		Project:   project,
+		Actor:     user,
+		Action:    models.ChangeUpdate,
+		Time:      time.Now(),
+		RequestID: RequestIDFromContext(ctx),
+		Changes:   changes,
+	})
+}
+
+// revertProjectUpdate sets the fields changed by an UpdateProject change
+// back to their old values.
+func (svc *TaskService) revertProjectUpdate(user string, change models.TaskChange) error {
+	projects, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return err
+	}
+	project, ok := findProject(projects, change.Project)
+	if !ok {
+		return ErrProjectNotFound
+	}
+	var update models.ProjectUpdate
+	for _, fc := range change.Changes {
+		old := fc.Old
+		switch fc.Field {
+		case "name":
+			if live, trashed, err := svc.nameTaken(user, old, project.ID); err != nil {
+				return err
+			} else if live || trashed {
+				return ErrUndoConflict
+			}
+			update.Name = &old
+		case "description":
+			update.Description = &old
+		case "color":
+			update.Color = &old
+		case "archived":
+			archived := old == "true"
+			update.Archived = &archived
+		case "parent":
+			if old != "" {
+				if _, ok := findProject(projects, old); !ok || checkParent(projects, project.ID, old) != nil {
+					return ErrUndoConflict
+				}
+			}
+			update.Parent = &old
+		}
+	}
+	return svc.repo.UpdateProject(user, update.Apply(project))
+}
+
+// nameTaken reports whether a project other than exceptID is named name,
+// either live or in the trash.
+func (svc *TaskService) nameTaken(user, name, exceptID string) (live, trashed bool, err error) {
+	projects, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return false, false, err
+	}
+	for _, p := range projects {
+		if p.Name == name && p.ID != exceptID {
+			return true, false, nil
+		}
+	}
+	ids, err := svc.repo.FindTrashedProjects(user, name)
+	if err != nil {
+		return false, false, err
+	}
+	for _, id := range ids {
+		if id != exceptID {
+			return false, true, nil
+		}
+	}
+	return false, false, nil
+}
+
+// projectID resolves ref, a project name or ID, to the ID the project's
+// tasks are stored under. Live projects are searched first, then the trash.
+// A ref that matches no project is returned as is, so the repository reports
+// it missing as it would any unknown ID.
+func (svc *TaskService) projectID(user, ref string) string {
+	if ref == "" {
+		return ref
+	}
+	if projects, err := svc.repo.ListProjects(user); err == nil {
+		if p, ok := findProject(projects, ref); ok {
+			return p.ID
+		}
+	}
+	if ids, err := svc.repo.FindTrashedProjects(user, ref); err == nil && len(ids) > 0 {
+		return ids[0]
+	}
+	return ref
+}
+
+// findProject finds the project with ID ref or, failing that, named ref. IDs
+// come first, so that a project named after another's ID cannot shadow it.
+func findProject(projects []models.Project, ref string) (models.Project, bool) {
+	if p, ok := findProjectByID(projects, ref); ok {
+		return p, true
+	}
+	for _, p := range projects {
+		if p.Name == ref {
+			return p, true
+		}
+	}
+	return models.Project{}, false
+}
+
+// diffProjects lists the user-editable fields that differ between before and after.
+func diffProjects(before, after models.Project) []models.FieldChange {
+	names := [5]string{"name", "description", "color", "archived", "parent"}
+	old := [5]string{before.Name, before.Description, before.Color, strconv.FormatBool(before.Archived), before.ParentID}
+	cur := [5]string{after.Name, after.Description, after.Color, strconv.FormatBool(after.Archived), after.ParentID}
+	var changes []models.FieldChange
+	for i := range names {
+		if old[i] != cur[i] {
+			changes = append(changes, models.FieldChange{Field: names[i], Old: old[i], New: cur[i]})
+		}
+	}
+	return changes
+}
+
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+func (l Limits) validateProjectUpdate(project string, update models.ProjectUpdate) error {
+	rules := []rule{required("project", project)}
+	if update.Name != nil {
+		rules = append(rules, l.projectNameRules("name", *update.Name)...)
+	}
+	if update.Description != nil {
+		rules = append(rules, maxLength("description", *update.Description, l.MaxContentLength))
+	}
+	if update.Color != nil {
+		rules = append(rules, check("color", *update.Color == "" || colorPattern.MatchString(*update.Color), "must be a color such as #1e90ff, or empty"))
+	}
+	return validate(rules...)
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"todolist/internal/models"
+)
+
+func TestProjectRefsMatchIDsBeforeNames(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	work := env.project(t, "alice", "work", "")
+	// A project named after work's ID does not shadow work.
+	impostor := env.project(t, "alice", work.ID, "")
+
+	if p, err := env.taskSvc.GetProject("alice", work.ID); err != nil || p.ID != work.ID {
+		t.Errorf("GetProject(work's ID) = %v, %v, want work", p, err)
+	}
+	if p, err := env.taskSvc.GetProject("alice", impostor.ID); err != nil || p.ID != impostor.ID {
+		t.Errorf("GetProject(impostor's ID) = %v, %v, want the impostor", p, err)
+	}
+	if p, err := env.taskSvc.GetProject("alice", "work"); err != nil || p.ID != work.ID {
+		t.Errorf("GetProject(work) = %v, %v, want work", p, err)
+	}
+
+	task := env.task(t, "alice", work.ID, models.Task{Content: "report"})
+	if got, ok := env.tasks.GetTask("alice", work.ID, task.ID); !ok || got.Content != "report" {
+		t.Errorf("task written through work's ID went elsewhere: %v, %v", got, ok)
+	}
+	if _, _, err := env.taskSvc.CreateProject(context.Background(), "alice", "sub", work.ID); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := env.taskSvc.GetProject("alice", "sub"); p.ParentID != work.ID {
+		t.Errorf("sub nested in %s, want work", p.ParentID)
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"todolist/internal/models"
+	"todolist/internal/quickadd"
+)
+
+// Quick-add strings are parsed by the quickadd package, with dates in the
+// user's time zone, weeks starting on the user's first day of the week,
+// numeric dates also read in the user's date format and priorities within
+// the configured limits. A recognized recurrence is stored on the task.
+
+// ParseQuickAdd returns what is recognized in text, without creating
+// anything, so that clients can preview a quick add.
+func (svc *TaskService) ParseQuickAdd(user, text string) (models.QuickAdd, error) {
+	if err := validate(required("q", text), maxLength("q", text, svc.limits.MaxContentLength)); err != nil {
+		return models.QuickAdd{}, err
+	}
+	settings := svc.userSettings(user)
+	return quickadd.Parse(text, quickadd.Options{
+		Now:         svc.userClock(settings),
+		WeekStart:   models.WeekStarts[settings.WeekStart],
+		DateLayout:  models.DateLayouts[settings.DateFormat],
+		MinPriority: svc.limits.MinPriority,
+		MaxPriority: svc.limits.MaxPriority,
+	}), nil
+}
+
+// QuickAdd creates the task parsed from text in the project it names, or in
+// project when it names none, and returns it with the undo token of its
+// creation.
+func (svc *TaskService) QuickAdd(ctx context.Context, user, text, project string) (models.QuickAddResult, string, error) {
+	parsed, err := svc.ParseQuickAdd(user, text)
+	if err != nil {
+		return models.QuickAddResult{}, "", err
+	}
+	ref := parsed.Project
+	if ref == "" {
+		ref = project
+	}
+	if err := validate(check("pjt", ref != "", "is required when the text names no #project")); err != nil {
+		return models.QuickAddResult{}, "", err
+	}
+	projects, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return models.QuickAddResult{}, "", err
+	}
+	p, ok := findProject(projects, ref)
+	if !ok {
+		return models.QuickAddResult{}, "", ErrProjectNotFound
+	}
+
+	task := models.Task{Content: parsed.Content, Recurrence: parsed.Recurrence}
+	if parsed.Priority != nil {
+		task.Priority = *parsed.Priority
+	}
+	if parsed.Due != nil {
+		task.Due = *parsed.Due
+	}
+	created, undoToken, err := svc.WriteTask(ctx, user, p.ID, task)
+	if err != nil {
+		return models.QuickAddResult{}, "", err
+	}
+	return models.QuickAddResult{Parsed: parsed, Project: p.ID, ProjectName: p.Name, Task: created}, undoToken, nil
+}
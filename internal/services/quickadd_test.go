@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"todolist/internal/models"
+)
+
+func TestQuickAddStoresRecurrence(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	work := env.project(t, "alice", "work", "")
+
+	result, _, err := env.taskSvc.QuickAdd(ctx, "alice", "Standup every weekday 9:30am #work", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.Recurrence{Interval: 1, Unit: models.RecurWeekday}
+	if result.Task.Content != "Standup" || result.Task.Recurrence == nil || *result.Task.Recurrence != want {
+		t.Errorf("task = %v, want Standup every weekday", result.Task)
+	}
+	if d := result.Task.Due.Weekday(); d == time.Saturday || d == time.Sunday {
+		t.Errorf("due = %v, want a weekday", result.Task.Due)
+	}
+	stored, _ := env.tasks.GetTask("alice", work.ID, result.Task.ID)
+	if stored.Recurrence == nil || *stored.Recurrence != want {
+		t.Errorf("stored task = %v, want its recurrence kept", stored)
+	}
+}
+
+func TestQuickAddPriorityWithinLimits(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	env.project(t, "alice", "work", "")
+
+	result, _, err := env.taskSvc.QuickAdd(context.Background(), "alice", "Submit form by dec 31 p11", "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Task.Content != "Submit form p11" || result.Task.Priority != 0 {
+		t.Errorf("task = %v, want p11 left in the content", result.Task)
+	}
+	if result.Task.Due.Month() != time.December || result.Task.Due.Day() != 31 {
+		t.Errorf("due = %v, want December 31", result.Task.Due)
+	}
+}
+
+func TestCompleteRecurringTaskMovesDue(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	p := env.project(t, "alice", "home", "")
+	daily := &models.Recurrence{Interval: 1, Unit: models.RecurDay}
+	task := env.task(t, "alice", p.ID, models.Task{Content: "water plants", Due: time.Date(2025, 5, 7, 9, 30, 0, 0, time.UTC), Recurrence: daily})
+
+	token, err := env.taskSvc.MarkTaskComplete(ctx, "alice", p.ID, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	want := time.Date(now.Year(), now.Month(), now.Day(), 9, 30, 0, 0, time.UTC)
+	if !want.After(now) {
+		want = want.AddDate(0, 0, 1)
+	}
+	got, _ := env.tasks.GetTask("alice", p.ID, task.ID)
+	if got.Completed || !got.Due.Equal(want) || got.Recurrence == nil {
+		t.Errorf("task after completion = %v, want open and due %v", got, want)
+	}
+
+	if _, err := env.taskSvc.Undo(ctx, "alice", token); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := env.tasks.GetTask("alice", p.ID, task.ID); !got.Due.Equal(task.Due) {
+		t.Errorf("due after undo = %v, want %v", got.Due, task.Due)
+	}
+
+	once := env.task(t, "alice", p.ID, models.Task{Content: "call plumber"})
+	if _, err := env.taskSvc.MarkTaskComplete(ctx, "alice", p.ID, once.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := env.tasks.GetTask("alice", p.ID, once.ID); !got.Completed {
+		t.Errorf("task without recurrence = %v, want completed", got)
+	}
+}
+
+func TestBatchCompleteRecurringTask(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	p := env.project(t, "alice", "work", "")
+	weekly := &models.Recurrence{Interval: 1, Unit: models.RecurWeek, Weekday: "monday"}
+	task := env.task(t, "alice", p.ID, models.Task{Content: "review", Recurrence: weekly})
+
+	if _, err := env.taskSvc.Batch(context.Background(), "alice", []BatchOp{{Op: BatchComplete, Project: p.ID, ID: task.ID}}, true); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := env.tasks.GetTask("alice", p.ID, task.ID)
+	if got.Completed || got.Due.Weekday() != time.Monday || !got.Due.After(time.Now()) || got.Due.After(time.Now().AddDate(0, 0, 14)) {
+		t.Errorf("task after completion = %v, want open and due on a coming monday", got)
+	}
+}
+
+func TestPatchTaskRecurrence(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	p := env.project(t, "alice", "work", "")
+	task := env.task(t, "alice", p.ID, models.Task{Content: "review", Recurrence: &models.Recurrence{Interval: 1, Unit: models.RecurWeek}})
+
+	patched, _, err := env.taskSvc.PatchTask(ctx, "alice", p.ID, task.ID, MergePatch, []byte(`{"recurrence":{"interval":2,"unit":"month"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (models.Recurrence{Interval: 2, Unit: models.RecurMonth}); patched.Recurrence == nil || *patched.Recurrence != want {
+		t.Errorf("recurrence = %v, want %v", patched.Recurrence, want)
+	}
+	patched, _, err = env.taskSvc.PatchTask(ctx, "alice", p.ID, task.ID, MergePatch, []byte(`{"recurrence":null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Recurrence != nil {
+		t.Errorf("recurrence = %v after setting it to null, want none", patched.Recurrence)
+	}
+
+	_, _, err = env.taskSvc.PatchTask(ctx, "alice", p.ID, task.ID, MergePatch, []byte(`{"recurrence":{"interval":0,"unit":"day"}}`))
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != "recurrence" {
+		t.Errorf("PatchTask with an invalid recurrence = %v, want a recurrence field error", err)
+	}
+}
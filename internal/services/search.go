@@ -0,0 +1,356 @@
+package services
+
+import (
+	"html"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"todolist/internal/models"
+	"unicode"
+)
+
+// Search limits.
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// The search index is an in-memory inverted index from terms to the live
+// tasks containing them, one per user. It is loaded from the repository for
+// every account at startup, or for a user on their first search, and is kept
+// current by addChange, which every TaskService mutation goes through. Each
+// user's index has a lock of its own, held while it is read from the
+// repository, so that loading one user's tasks does not hold up the others.
+
+type docKey struct {
+	project, task string
+}
+
+type userIndex struct {
+	mu       sync.Mutex
+	loaded   bool
+	docs     map[docKey]models.Task
+	postings map[string]map[docKey]int // term -> task -> occurrences
+	terms    []string                  // sorted keys of postings; nil when stale
+}
+
+type searchIndex struct {
+	mu    sync.Mutex // guards users only
+	users map[string]*userIndex
+}
+
+// user returns the index of a user, creating an empty one not yet loaded
+// when create is set, or nil.
+func (idx *searchIndex) user(user string, create bool) *userIndex {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	ui := idx.users[user]
+	if ui == nil && create {
+		ui = &userIndex{}
+		idx.users[user] = ui
+	}
+	return ui
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{users: make(map[string]*userIndex)}
+}
+
+// token is a term of a text with its byte range in the text.
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits s into lowercased runs of letters and digits.
+func tokenize(s string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range s {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		} else if !word && start >= 0 {
+			tokens = append(tokens, token{strings.ToLower(s[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{strings.ToLower(s[start:]), start, len(s)})
+	}
+	return tokens
+}
+
+func (ui *userIndex) put(key docKey, task models.Task) {
+	ui.remove(key)
+	ui.docs[key] = task
+	for _, tok := range tokenize(task.Content) {
+		if ui.postings[tok.term] == nil {
+			ui.postings[tok.term] = make(map[docKey]int)
+			ui.terms = nil
+		}
+		ui.postings[tok.term][key]++
+	}
+}
+
+func (ui *userIndex) remove(key docKey) {
+	task, ok := ui.docs[key]
+	if !ok {
+		return
+	}
+	delete(ui.docs, key)
+	for _, tok := range tokenize(task.Content) {
+		delete(ui.postings[tok.term], key)
+		if len(ui.postings[tok.term]) == 0 {
+			delete(ui.postings, tok.term)
+			ui.terms = nil
+		}
+	}
+}
+
+// withPrefix returns the indexed terms starting with prefix.
+func (ui *userIndex) withPrefix(prefix string) []string {
+	if ui.terms == nil {
+		ui.terms = make([]string, 0, len(ui.postings))
+		for term := range ui.postings {
+			ui.terms = append(ui.terms, term)
+		}
+		sort.Strings(ui.terms)
+	}
+	var terms []string
+	for i := sort.SearchStrings(ui.terms, prefix); i < len(ui.terms) && strings.HasPrefix(ui.terms[i], prefix); i++ {
+		terms = append(terms, ui.terms[i])
+	}
+	return terms
+}
+
+// loadIndex fills the index of a user from the repository. The caller must
+// hold ui.mu. On error the index is left unloaded, to be loaded again on the
+// next search.
+func (svc *TaskService) loadIndex(user string, ui *userIndex) error {
+	ui.loaded = false
+	projects, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, len(projects))
+	for i, p := range projects {
+		ids[i] = p.ID
+	}
+	tasks, err := svc.repo.ListTasksInProjects(user, ids)
+	if err != nil {
+		return err
+	}
+	ui.docs, ui.postings, ui.terms = make(map[docKey]models.Task), make(map[string]map[docKey]int), nil
+	for project, list := range tasks {
+		for _, t := range list {
+			ui.put(docKey{project, t.ID}, t)
+		}
+	}
+	ui.loaded = true
+	return nil
+}
+
+// RebuildSearchIndex loads the search index of the given users from the
+// repository, replacing what was indexed for them.
+func (svc *TaskService) RebuildSearchIndex(users []string) error {
+	for _, user := range users {
+		ui := svc.index.user(user, true)
+		ui.mu.Lock()
+		err := svc.loadIndex(user, ui)
+		ui.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexChange brings the index up to date with a recorded change. A task
+// change re-reads that task; project changes and undos, which may touch
+// several projects, reload the user's index.
+func (svc *TaskService) indexChange(user string, change models.TaskChange) {
+	ui := svc.index.user(user, false)
+	if ui == nil {
+		return // loaded in full on the user's first search
+	}
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	if !ui.loaded {
+		return
+	}
+	if change.TaskID == "" || change.Action == models.ChangeUndo {
+		if err := svc.loadIndex(user, ui); err != nil {
+			log.Printf("Error reloading the search index of user '%s': %v", user, err)
+		}
+		return
+	}
+	key := docKey{change.Project, change.TaskID}
+	if task, ok := svc.repo.GetTask(user, change.Project, change.TaskID); ok {
+		ui.put(key, task)
+	} else {
+		ui.remove(key)
+	}
+}
+
+// dropIndex forgets the index of a user whose tasks were deleted.
+func (svc *TaskService) dropIndex(user string) {
+	svc.index.mu.Lock()
+	ui := svc.index.users[user]
+	delete(svc.index.users, user)
+	svc.index.mu.Unlock()
+	if ui != nil {
+		ui.mu.Lock()
+		ui.loaded = false
+		ui.mu.Unlock()
+	}
+}
+
+// Search finds the user's tasks whose content contains every word of query,
+// as a word or the start of one, in any live project, archived ones included,
+// or only in project when that is not empty. Hits are ranked by how often and
+// how exactly the words occur, weighted by how rare they are, with open
+// tasks first among equal scores. At most limit hits are returned.
+func (svc *TaskService) Search(user, query, project string, limit int) ([]models.SearchHit, error) {
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	}
+	terms := tokenize(query)
+	if err := svc.limits.validateSearch(query, len(terms), limit); err != nil {
+		return nil, err
+	}
+	projects, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(projects))
+	for _, p := range projects {
+		names[p.ID] = p.Name
+	}
+	if project != "" {
+		p, ok := findProject(projects, project)
+		if !ok {
+			return nil, ErrProjectNotFound
+		}
+		project = p.ID
+	}
+
+	ui := svc.index.user(user, true)
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	if !ui.loaded {
+		if err := svc.loadIndex(user, ui); err != nil {
+			return nil, err
+		}
+	}
+
+	var scores map[docKey]float64
+	seen := make(map[string]bool)
+	for _, qt := range terms {
+		if seen[qt.term] {
+			continue
+		}
+		seen[qt.term] = true
+		// An exact word counts fully, a longer word starting with the
+		// query word half as much.
+		matched := make(map[docKey]float64)
+		for _, term := range ui.withPrefix(qt.term) {
+			weight := 1.0
+			if term != qt.term {
+				weight = 0.5
+			}
+			for key, n := range ui.postings[term] {
+				if project == "" || key.project == project {
+					matched[key] += weight * (1 + math.Log(float64(n)))
+				}
+			}
+		}
+		idf := math.Log(1 + float64(len(ui.docs))/float64(max(len(matched), 1)))
+		next := make(map[docKey]float64, len(matched))
+		for key, score := range matched {
+			if scores == nil {
+				next[key] = idf * score
+			} else if prev, ok := scores[key]; ok {
+				next[key] = prev + idf*score
+			}
+		}
+		scores = next
+	}
+
+	hits := make([]models.SearchHit, 0, len(scores))
+	for key, score := range scores {
+		name, live := names[key.project]
+		if !live {
+			continue
+		}
+		task := ui.docs[key]
+		matches := highlightRanges(task.Content, seen)
+		hits = append(hits, models.SearchHit{
+			Project:     key.project,
+			ProjectName: name,
+			Task:        task,
+			Score:       math.Round(score*1000) / 1000,
+			Matches:     matches,
+			Highlight:   highlight(task.Content, matches),
+		})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		switch {
+		case a.Score != b.Score:
+			return a.Score > b.Score
+		case a.Task.Completed != b.Task.Completed:
+			return !a.Task.Completed
+		case a.Task.Priority != b.Task.Priority:
+			return a.Task.Priority < b.Task.Priority
+		}
+		return a.Task.Content < b.Task.Content
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// highlightRanges returns the ranges of the words of content that start with
+// one of terms.
+func highlightRanges(content string, terms map[string]bool) []models.TextRange {
+	ranges := []models.TextRange{}
+	for _, tok := range tokenize(content) {
+		for term := range terms {
+			if strings.HasPrefix(tok.term, term) {
+				ranges = append(ranges, models.TextRange{Start: tok.start, End: tok.end})
+				break
+			}
+		}
+	}
+	return ranges
+}
+
+// highlight HTML-escapes content and wraps the given ranges, which must be
+// sorted and disjoint, in <mark> elements.
+func highlight(content string, ranges []models.TextRange) string {
+	var b strings.Builder
+	last := 0
+	for _, r := range ranges {
+		b.WriteString(html.EscapeString(content[last:r.Start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(content[r.Start:r.End]))
+		b.WriteString("</mark>")
+		last = r.End
+	}
+	b.WriteString(html.EscapeString(content[last:]))
+	return b.String()
+}
+
+// validateSearch checks a search query that tokenized into terms words.
+func (l Limits) validateSearch(query string, terms, limit int) error {
+	return validate(
+		required("q", query),
+		maxLength("q", query, l.MaxContentLength),
+		check("q", strings.TrimSpace(query) == "" || terms > 0, "must contain a letter or digit"),
+		check("limit", limit >= 1 && limit <= MaxSearchLimit, "must be between 1 and %d", MaxSearchLimit),
+	)
+}
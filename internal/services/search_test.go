@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"todolist/internal/models"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []token
+	}{
+		{"", nil},
+		{"  ,.! ", nil},
+		{"Buy milk", []token{{"buy", 0, 3}, {"milk", 4, 8}}},
+		{"e-mail Bob's 2nd_draft", []token{{"e", 0, 1}, {"mail", 2, 6}, {"bob", 7, 10}, {"s", 11, 12}, {"2nd", 13, 16}, {"draft", 17, 22}}},
+		{"Ÿes Café", []token{{"ÿes", 0, 4}, {"café", 5, 10}}},
+		{"日本語 text", []token{{"日本語", 0, 9}, {"text", 10, 14}}},
+	}
+	for _, tt := range tests {
+		if got := tokenize(tt.input); !slices.Equal(got, tt.want) {
+			t.Errorf("tokenize(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	ui := &userIndex{docs: make(map[docKey]models.Task), postings: make(map[string]map[docKey]int)}
+	ui.put(docKey{"p", "1"}, models.Task{Content: "buy butter and bread"})
+	ui.put(docKey{"p", "2"}, models.Task{Content: "call bank"})
+	for _, tt := range []struct {
+		prefix string
+		want   []string
+	}{
+		{"b", []string{"bank", "bread", "butter", "buy"}},
+		{"bu", []string{"butter", "buy"}},
+		{"buy", []string{"buy"}},
+		{"buyer", nil},
+		{"z", nil},
+	} {
+		if got := ui.withPrefix(tt.prefix); !slices.Equal(got, tt.want) {
+			t.Errorf("withPrefix(%q) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+
+	// Removing the only task with a term drops the term.
+	ui.remove(docKey{"p", "2"})
+	if got := ui.withPrefix("ba"); got != nil {
+		t.Errorf("withPrefix(ba) after removing its task = %v, want none", got)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		content string
+		terms   []string
+		ranges  []models.TextRange
+		html    string
+	}{
+		{"Buy milk", []string{"mi"}, []models.TextRange{{Start: 4, End: 8}}, "Buy <mark>milk</mark>"},
+		{"Fix <b> & bugs", []string{"fix", "bug"}, []models.TextRange{{Start: 0, End: 3}, {Start: 10, End: 14}}, "<mark>Fix</mark> &lt;b&gt; &amp; <mark>bugs</mark>"},
+		{"Café crème", []string{"crè"}, []models.TextRange{{Start: 6, End: 12}}, "Café <mark>crème</mark>"},
+		{"nothing here", []string{"x"}, []models.TextRange{}, "nothing here"},
+	}
+	for _, tt := range tests {
+		terms := make(map[string]bool)
+		for _, term := range tt.terms {
+			terms[term] = true
+		}
+		ranges := highlightRanges(tt.content, terms)
+		if !slices.Equal(ranges, tt.ranges) {
+			t.Errorf("highlightRanges(%q) = %v, want %v", tt.content, ranges, tt.ranges)
+		}
+		if got := highlight(tt.content, ranges); got != tt.html {
+			t.Errorf("highlight(%q) = %q, want %q", tt.content, got, tt.html)
+		}
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	env.project(t, "alice", "home", "")
+	env.project(t, "alice", "work", "")
+	env.task(t, "alice", "home", models.Task{Content: "Buy milk"})
+	env.task(t, "alice", "home", models.Task{Content: "Buy milk, milk and more milk"})
+	env.task(t, "alice", "home", models.Task{Content: "Buy milkshake"})
+	env.task(t, "alice", "work", models.Task{Content: "Buy milk for the office", Completed: true})
+	env.task(t, "alice", "work", models.Task{Content: "Order paper"})
+
+	contents := func(hits []models.SearchHit) []string {
+		var got []string
+		for _, h := range hits {
+			got = append(got, h.Task.Content)
+		}
+		return got
+	}
+	tests := []struct {
+		query, project string
+		limit          int
+		want           []string
+	}{
+		// More occurrences rank higher; an exact word beats a prefix; open
+		// tasks come before completed ones of the same score.
+		{"milk", "", 0, []string{"Buy milk, milk and more milk", "Buy milk", "Buy milk for the office", "Buy milkshake"}},
+		{"MILK", "work", 0, []string{"Buy milk for the office"}},
+		{"buy milk", "", 2, []string{"Buy milk, milk and more milk", "Buy milk"}},
+		{"milk office", "", 0, []string{"Buy milk for the office"}},
+		{"pap", "", 0, []string{"Order paper"}},
+		{"tea", "", 0, nil},
+	}
+	for _, tt := range tests {
+		hits, err := env.taskSvc.Search("alice", tt.query, tt.project, tt.limit)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", tt.query, err)
+		}
+		if got := contents(hits); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q, %q) = %q, want %q", tt.query, tt.project, got, tt.want)
+		}
+	}
+
+	hits, _ := env.taskSvc.Search("alice", "pap", "", 0)
+	if len(hits) != 1 || hits[0].ProjectName != "work" || hits[0].Highlight != "Order <mark>paper</mark>" || hits[0].Score <= 0 {
+		t.Errorf("hit = %+v", hits)
+	}
+}
+
+func TestSearchFollowsChanges(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	env.register(t, "bob")
+	env.project(t, "alice", "home", "")
+	env.project(t, "bob", "home", "")
+	task := env.task(t, "alice", "home", models.Task{Content: "Buy milk"})
+	env.task(t, "bob", "home", models.Task{Content: "Buy milk"})
+	search := func(user, query string) int {
+		t.Helper()
+		hits, err := env.taskSvc.Search(user, query, "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(hits)
+	}
+	if n := search("alice", "milk"); n != 1 {
+		t.Fatalf("hits = %d, want 1", n)
+	}
+
+	task.Content = "Buy bread"
+	if _, _, err := env.taskSvc.WriteTask(ctx, "alice", "home", task); err != nil {
+		t.Fatal(err)
+	}
+	if search("alice", "milk") != 0 || search("alice", "bread") != 1 {
+		t.Error("index not updated after an edit")
+	}
+	token, err := env.taskSvc.RemoveProject(ctx, "alice", "home", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if search("alice", "bread") != 0 {
+		t.Error("task of a trashed project found")
+	}
+	if _, err := env.taskSvc.Undo(ctx, "alice", token); err != nil {
+		t.Fatal(err)
+	}
+	if search("alice", "bread") != 1 {
+		t.Error("task not found after undoing the removal")
+	}
+	if search("bob", "milk") != 1 || search("bob", "bread") != 0 {
+		t.Error("bob's index affected by alice's changes")
+	}
+}
+
+// TestSearchConcurrentUsers runs searches and writes for several users at
+// once; run with -race.
+func TestSearchConcurrentUsers(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	users := []string{"alice", "bob", "carol"}
+	for _, user := range users {
+		env.register(t, user)
+		env.project(t, user, "home", "")
+	}
+	var wg sync.WaitGroup
+	for _, user := range users {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 20 {
+				if _, _, err := env.taskSvc.WriteTask(ctx, user, "home", models.Task{Content: fmt.Sprintf("task %d of %s", i, user)}); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := env.taskSvc.Search(user, user, "", MaxSearchLimit); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for _, user := range users {
+		if hits, _ := env.taskSvc.Search(user, user, "", MaxSearchLimit); len(hits) != 20 {
+			t.Errorf("%s has %d hits, want 20", user, len(hits))
+		}
+	}
+}
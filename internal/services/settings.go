@@ -0,0 +1,94 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"todolist/internal/models"
+
+	"golang.org/x/text/language"
+)
+
+// GetSettings returns the user's settings, with the default for every
+// setting never changed.
+func (svc *UserService) GetSettings(username string) (models.UserSettings, error) {
+	user, err := svc.repo.GetUser(username)
+	if err != nil {
+		return models.UserSettings{}, err
+	}
+	return user.Settings.WithDefaults(), nil
+}
+
+// UpdateSettings changes the settings present in update and returns the
+// settings now in effect. An empty value resets a setting to its default.
+// Week starts are case-insensitive and locales are stored in their canonical
+// form, such as "en-US" for "en_us".
+func (svc *UserService) UpdateSettings(username string, update models.SettingsUpdate) (models.UserSettings, error) {
+	user, err := svc.repo.GetUser(username)
+	if err != nil {
+		return models.UserSettings{}, err
+	}
+	settings := update.Apply(user.Settings)
+	settings.WeekStart = strings.ToLower(settings.WeekStart)
+	if err := validateSettings(settings); err != nil {
+		return models.UserSettings{}, err
+	}
+	if settings.Locale != "" {
+		settings.Locale = language.Make(settings.Locale).String()
+	}
+	if err := svc.repo.UpdateSettings(username, settings); err != nil {
+		return models.UserSettings{}, err
+	}
+	return settings.WithDefaults(), nil
+}
+
+// userSettings returns the settings that date logic applies for user. The
+// defaults apply when they cannot be read.
+func (svc *TaskService) userSettings(user string) models.UserSettings {
+	u, err := svc.users.GetUser(user)
+	if err != nil {
+		return models.DefaultSettings
+	}
+	return u.Settings.WithDefaults()
+}
+
+// Location returns the user's time zone, in which plain dates given to the
+// services stand for midnight.
+func (svc *TaskService) Location(user string) *time.Location {
+	return svc.userClock(svc.userSettings(user)).Location()
+}
+
+// userClock returns the current time in the time zone of settings.
+func (svc *TaskService) userClock(settings models.UserSettings) time.Time {
+	loc, err := time.LoadLocation(settings.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return svc.now().In(loc)
+}
+
+// validateSettings checks user settings about to be saved; empty fields are
+// valid and stand for the default.
+func validateSettings(settings models.UserSettings) error {
+	_, weekStart := models.WeekStarts[settings.WeekStart]
+	_, localeErr := language.Parse(settings.Locale)
+	_, dateFormat := models.DateLayouts[settings.DateFormat]
+	formats := make([]string, 0, len(models.DateLayouts))
+	for f := range models.DateLayouts {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return validate(
+		timeZoneRule("timeZone", settings.TimeZone),
+		check("weekStart", settings.WeekStart == "" || weekStart, "must be monday, sunday or saturday"),
+		check("locale", settings.Locale == "" || localeErr == nil, "must be a language tag such as en-US"),
+		check("dateFormat", settings.DateFormat == "" || dateFormat, "must be one of %s", strings.Join(formats, ", ")),
+	)
+}
+
+// timeZoneRule accepts an IANA time zone name, or empty for UTC. "Local",
+// the server's own zone, is refused.
+func timeZoneRule(field, tz string) rule {
+	_, err := time.LoadLocation(tz)
+	return check(field, err == nil && tz != "Local", "must be an IANA time zone such as Europe/Paris")
+}
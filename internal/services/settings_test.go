@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"todolist/internal/filter"
+	"todolist/internal/models"
+)
+
+func TestUpdateSettings(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice")
+
+	if got, err := env.userSvc.GetSettings("alice"); err != nil || got != models.DefaultSettings {
+		t.Fatalf("GetSettings = %+v, %v, want the defaults", got, err)
+	}
+	got, err := env.userSvc.UpdateSettings("alice", models.SettingsUpdate{TimeZone: ptrTo("Europe/Paris"), WeekStart: ptrTo("Sunday"), Locale: ptrTo("fr_fr")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.UserSettings{TimeZone: "Europe/Paris", WeekStart: "sunday", Locale: "fr-FR", DateFormat: "YYYY-MM-DD"}
+	if got != want {
+		t.Errorf("UpdateSettings = %+v, want %+v", got, want)
+	}
+	got, err = env.userSvc.UpdateSettings("alice", models.SettingsUpdate{TimeZone: ptrTo(""), DateFormat: ptrTo("DD/MM/YYYY")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = models.UserSettings{TimeZone: "UTC", WeekStart: "sunday", Locale: "fr-FR", DateFormat: "DD/MM/YYYY"}
+	if stored, _ := env.userSvc.GetSettings("alice"); got != want || stored != want {
+		t.Errorf("settings after a reset = %+v, stored %+v, want %+v", got, stored, want)
+	}
+
+	for _, tt := range []struct {
+		update models.SettingsUpdate
+		field  string
+	}{
+		{models.SettingsUpdate{TimeZone: ptrTo("Mars/Olympus")}, "timeZone"},
+		{models.SettingsUpdate{TimeZone: ptrTo("Local")}, "timeZone"},
+		{models.SettingsUpdate{WeekStart: ptrTo("wednesday")}, "weekStart"},
+		{models.SettingsUpdate{Locale: ptrTo("not a tag")}, "locale"},
+		{models.SettingsUpdate{DateFormat: ptrTo("DD-MM-YY")}, "dateFormat"},
+	} {
+		_, err := env.userSvc.UpdateSettings("alice", tt.update)
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != tt.field {
+			t.Errorf("UpdateSettings(%+v) = %v, want a %s field error", tt.update, err, tt.field)
+		}
+	}
+	if stored, _ := env.userSvc.GetSettings("alice"); stored != want {
+		t.Errorf("settings after rejected updates = %+v, want %+v", stored, want)
+	}
+}
+
+// US clocks go forward an hour at 2:00 on Sunday March 9, 2025.
+var losAngeles, _ = time.LoadLocation("America/Los_Angeles")
+
+// settingsEnv returns an environment whose clock stands at now, with alice
+// in Los Angeles and the given week start and date format.
+func settingsEnv(t *testing.T, now time.Time, weekStart, dateFormat string) *testEnv {
+	t.Helper()
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	update := models.SettingsUpdate{TimeZone: ptrTo(losAngeles.String()), WeekStart: &weekStart, DateFormat: &dateFormat}
+	if _, err := env.userSvc.UpdateSettings("alice", update); err != nil {
+		t.Fatal(err)
+	}
+	env.taskSvc.now = func() time.Time { return now }
+	return env
+}
+
+func TestSettingsFilterDays(t *testing.T) {
+	// 23:30 on Saturday March 8 in Los Angeles, already Sunday in UTC.
+	saturday := time.Date(2025, 3, 9, 7, 30, 0, 0, time.UTC)
+	// 05:00 on Sunday March 9, three hours after clocks went forward.
+	sunday := time.Date(2025, 3, 9, 12, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 0, 0, 0, 0, losAngeles) }
+	for _, tt := range []struct {
+		now       time.Time
+		weekStart string
+		value     string
+		from, to  time.Time
+	}{
+		{saturday, "monday", "today", day(8), day(9)},
+		{saturday, "monday", "thisweek", day(3), day(10)},
+		{saturday, "sunday", "thisweek", day(2), day(9)},
+		{saturday, "saturday", "thisweek", day(8), day(15)},
+		{saturday, "saturday", "nextweek", day(15), day(22)},
+		{sunday, "monday", "today", day(9), day(10)},
+		{sunday, "monday", "yesterday", day(8), day(9)},
+		{sunday, "sunday", "thisweek", day(9), day(16)},
+	} {
+		env := settingsEnv(t, tt.now, tt.weekStart, "YYYY-MM-DD")
+		e, err := env.taskSvc.parseFilter("alice", "q", "due = "+tt.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := e.(*filter.Compare)
+		if !c.From.Equal(tt.from) || !c.To.Equal(tt.to) {
+			t.Errorf("%s at %v with weeks from %s = [%v, %v), want [%v, %v)", tt.value, tt.now, tt.weekStart, c.From, c.To, tt.from, tt.to)
+		}
+	}
+
+	// The day clocks go forward lasts 23 hours.
+	env := settingsEnv(t, sunday, "monday", "YYYY-MM-DD")
+	e, _ := env.taskSvc.parseFilter("alice", "q", "due = today")
+	if c := e.(*filter.Compare); c.To.Sub(c.From) != 23*time.Hour {
+		t.Errorf("today on March 9 lasts %v, want 23h", c.To.Sub(c.From))
+	}
+}
+
+func TestSettingsQuickAdd(t *testing.T) {
+	saturday := time.Date(2025, 3, 9, 7, 30, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		dateFormat string
+		weekStart  string
+		input      string
+		due        string // in Los Angeles
+	}{
+		{"YYYY-MM-DD", "monday", "Call mom today", "2025-03-08 23:59:59"},
+		{"YYYY-MM-DD", "monday", "Call mom tomorrow 9am", "2025-03-09 09:00:00"},
+		{"DD/MM/YYYY", "monday", "Launch 09/06/2025", "2025-06-09 23:59:59"},
+		{"MM/DD/YYYY", "monday", "Launch 09/06/2025", "2025-09-06 23:59:59"},
+		{"YYYY-MM-DD", "monday", "Plan next week", "2025-03-10 23:59:59"},
+		{"YYYY-MM-DD", "sunday", "Plan next week", "2025-03-09 23:59:59"},
+	} {
+		env := settingsEnv(t, saturday, tt.weekStart, tt.dateFormat)
+		got, err := env.taskSvc.ParseQuickAdd("alice", tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Due == nil || got.Due.In(losAngeles).Format(time.DateTime) != tt.due {
+			t.Errorf("%q in %s with weeks from %s is due %v, want %s", tt.input, tt.dateFormat, tt.weekStart, got.Due, tt.due)
+		}
+	}
+}
+
+func TestCompleteRecurringTaskWithoutDueInUserZone(t *testing.T) {
+	// Late on Saturday March 8 in Los Angeles: a daily task without a due
+	// date counts from the end of that day, and moves to the end of Sunday,
+	// after clocks went forward.
+	env := settingsEnv(t, time.Date(2025, 3, 9, 7, 30, 0, 0, time.UTC), "monday", "YYYY-MM-DD")
+	p := env.project(t, "alice", "home", "")
+	task := env.task(t, "alice", p.ID, models.Task{Content: "water plants", Recurrence: &models.Recurrence{Interval: 1, Unit: models.RecurDay}})
+	if !task.Due.Equal(DefaultTimestamp) {
+		t.Fatalf("due = %v, want DefaultTimestamp", task.Due)
+	}
+	if _, err := env.taskSvc.MarkTaskComplete(context.Background(), "alice", p.ID, task.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := env.tasks.GetTask("alice", p.ID, task.ID)
+	if want := time.Date(2025, 3, 9, 23, 59, 59, 0, losAngeles); got.Completed || !got.Due.Equal(want) {
+		t.Errorf("task after completion = %v, want open and due %v", got, want)
+	}
+}
+
+func ptrTo[T any](v T) *T { return &v }
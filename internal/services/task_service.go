@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"time"
 	"todolist/internal/models"
 	"todolist/internal/repository"
@@ -9,68 +11,350 @@ import (
 	"github.com/google/uuid"
 )
 
-var DefaultTimestamp = time.Date(2099, 12, 31, 23, 59, 59, 0, time.UTC)
+var DefaultTimestamp = models.NoDue
 
-type TaskService struct {
-	repo repository.TaskRepository
-}
+// DefaultUndoWindow is how long after a mutation its undo token stays valid.
+const DefaultUndoWindow = 15 * time.Minute
 
-func NewTaskService(repo repository.TaskRepository) *TaskService {
-	return &TaskService{repo: repo}
+// Mutating methods return an undo token alongside their result; see Undo.
+type TaskService struct {
+	repo       repository.TaskRepository
+	history    repository.HistoryRepository
+	templates  repository.TemplateRepository
+	smartLists repository.SmartListRepository
+	users      repository.UserRepository
+	undoWindow time.Duration
+	limits     Limits
+	feed       *changeFeed
+	index      *searchIndex
+	now        func() time.Time // the clock of date logic, fixed in tests
 }
 
-func (svc *TaskService) CreateProject(user, project string) error {
-	return svc.repo.CreateProject(user, project)
+func NewTaskService(repo repository.TaskRepository, history repository.HistoryRepository, templates repository.TemplateRepository, smartLists repository.SmartListRepository, users repository.UserRepository, undoWindow time.Duration, limits Limits) *TaskService {
+	return &TaskService{repo: repo, history: history, templates: templates, smartLists: smartLists, users: users, undoWindow: undoWindow, limits: limits, feed: newChangeFeed(), index: newSearchIndex(), now: time.Now}
 }
 
-func (svc *TaskService) WriteTask(user, project string, task models.Task) (models.Task, error) {
+func (svc *TaskService) WriteTask(ctx context.Context, user, project string, task models.Task) (models.Task, string, error) {
+	var existing models.Task
+	exist := false
+	ref := project
+	project = svc.projectID(user, project)
+	if task.ID != "" && project != "" {
+		existing, exist = svc.repo.GetTask(user, project, task.ID)
+	}
+	if err := svc.limits.validateTask(ref, task, exist); err != nil {
+		return models.Task{}, "", err
+	}
 	if task.ID == "" {
 		task.ID = fmt.Sprintf("task_%s", uuid.New().String())
 	}
 	if task.Due.IsZero() {
 		task.Due = DefaultTimestamp
 	}
-	if _, exist := svc.repo.GetTask(user, project, task.ID); !exist {
+	if !exist {
 		err := svc.repo.CreateTask(user, project, task)
 		if err != nil {
-			return models.Task{}, err
+			return models.Task{}, "", err
 		}
 	} else {
 		err := svc.repo.UpdateTask(user, project, task)
 		if err != nil {
-			return models.Task{}, err
+			return models.Task{}, "", err
 		}
 	}
 	updatedTask, _ := svc.repo.GetTask(user, project, task.ID)
-	return updatedTask, nil
+	if !exist {
+		return updatedTask, svc.record(ctx, user, project, task.ID, models.ChangeCreate, nil, &updatedTask), nil
+	}
+	return updatedTask, svc.record(ctx, user, project, task.ID, models.ChangeUpdate, &existing, &updatedTask), nil
 }
 
-func (svc *TaskService) MarkTaskComplete(user, project, taskID string) error {
-	if _, exist := svc.repo.GetTask(user, project, taskID); !exist {
-		return ErrTaskNotFound
+// MarkTaskComplete completes the task. A recurring task stays open and moves
+// to its next due date instead; see nextDue.
+func (svc *TaskService) MarkTaskComplete(ctx context.Context, user, project, taskID string) (string, error) {
+	if err := validateTaskRef(project, taskID); err != nil {
+		return "", err
+	}
+	project = svc.projectID(user, project)
+	existing, exist := svc.repo.GetTask(user, project, taskID)
+	if !exist {
+		return "", ErrTaskNotFound
+	}
+	var err error
+	if existing.Recurrence != nil {
+		next := svc.nextDue(user, existing)
+		err = svc.repo.UpdateTaskFields(user, project, taskID, models.TaskUpdate{Due: &next})
+	} else {
+		err = svc.repo.CompleteTask(user, project, taskID)
+	}
+	if err != nil {
+		return "", err
 	}
-	return svc.repo.CompleteTask(user, project, taskID)
+	completed, _ := svc.repo.GetTask(user, project, taskID)
+	return svc.record(ctx, user, project, taskID, models.ChangeComplete, &existing, &completed), nil
+}
+
+// nextDue returns the due date a recurring task moves to when completed: the
+// first of its next occurrences, in the user's time zone, that is still
+// ahead. A task without a due date counts from the end of today.
+func (svc *TaskService) nextDue(user string, task models.Task) time.Time {
+	now := svc.userClock(svc.userSettings(user))
+	due := task.Due.In(now.Location())
+	if task.Due.IsZero() || task.Due.Equal(DefaultTimestamp) {
+		due = time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
+	}
+	due = task.Recurrence.Next(due)
+	for !due.After(now) {
+		due = task.Recurrence.Next(due)
+	}
+	return due
 }
 
 func (svc *TaskService) GetTasks(user, project string) ([]models.Task, error) {
-	return svc.repo.ListTasks(user, project)
+	if err := validateProjectRef(project); err != nil {
+		return nil, err
+	}
+	return svc.repo.ListTasks(user, svc.projectID(user, project))
+}
+
+// GetTasksInProjects returns the tasks of several projects, keyed by project
+// as given, with a single repository call.
+func (svc *TaskService) GetTasksInProjects(user string, projects []string) (map[string][]models.Task, error) {
+	live, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(projects))
+	for i, ref := range projects {
+		ids[i] = ref
+		if p, ok := findProject(live, ref); ok {
+			ids[i] = p.ID
+		}
+	}
+	tasks, err := svc.repo.ListTasksInProjects(user, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]models.Task, len(projects))
+	for i, ref := range projects {
+		result[ref] = tasks[ids[i]]
+	}
+	return result, nil
 }
 
-func (svc *TaskService) GetProjects(user string) ([]string, error) {
-	return svc.repo.ListProjects(user)
+// RemoveProject moves the project and every task in it to the trash.
+// children says what happens to its sub-projects: ChildrenTrash, the
+// default when empty, trashes them too; ChildrenLift moves them up to the
+// project's own parent. The undo token restores the project, and with it the
+// sub-projects trashed alongside.
+func (svc *TaskService) RemoveProject(ctx context.Context, user, project, children string) (string, error) {
+	if children == "" {
+		children = ChildrenTrash
+	}
+	if err := validateRemoveProject(project, children); err != nil {
+		return "", err
+	}
+	projects, err := svc.repo.ListProjects(user)
+	if err != nil {
+		return "", err
+	}
+	existing, ok := findProject(projects, project)
+	if !ok {
+		return "", ErrProjectNotFound
+	}
+	now := time.Now()
+	lifted, err := svc.removeChildren(ctx, user, projects, existing, children, now)
+	if err != nil {
+		return "", err
+	}
+	if err := svc.repo.TrashProject(user, existing.ID, now); err != nil {
+		return "", err
+	}
+	return svc.addChange(user, models.TaskChange{
+		ID:        fmt.Sprintf("chg_%s", uuid.New().String()),
+		Project:   existing.ID,
+		Actor:     user,
+		Action:    models.ChangeTrash,
+		Time:      time.Now(),
+		RequestID: RequestIDFromContext(ctx),
+		Lifted:    lifted,
+	}), nil
 }
 
-func (svc *TaskService) RemoveProject(user, project string) error {
-	return svc.repo.DeleteProject(user, project)
+// RemoveTask moves the task to the trash.
+func (svc *TaskService) RemoveTask(ctx context.Context, user, project, taskID string) (string, error) {
+	if err := validateTaskRef(project, taskID); err != nil {
+		return "", err
+	}
+	project = svc.projectID(user, project)
+	existing, exist := svc.repo.GetTask(user, project, taskID)
+	if !exist {
+		return "", ErrTaskNotFound
+	}
+	if err := svc.repo.TrashTask(user, project, taskID, time.Now()); err != nil {
+		return "", err
+	}
+	return svc.record(ctx, user, project, taskID, models.ChangeTrash, &existing, nil), nil
 }
 
-func (svc *TaskService) RemoveTask(user, project, taskID string) error {
-	if _, exist := svc.repo.GetTask(user, project, taskID); !exist {
-		return ErrTaskNotFound
+func (svc *TaskService) GetTrash(user string) ([]models.TrashItem, error) {
+	return svc.repo.ListTrash(user)
+}
+
+func (svc *TaskService) RestoreTask(ctx context.Context, user, project, taskID string) (string, error) {
+	if err := validateTaskRef(project, taskID); err != nil {
+		return "", err
+	}
+	project = svc.projectID(user, project)
+	if err := svc.repo.RestoreTask(user, project, taskID); err != nil {
+		return "", err
+	}
+	restored, _ := svc.repo.GetTask(user, project, taskID)
+	return svc.record(ctx, user, project, taskID, models.ChangeRestore, nil, &restored), nil
+}
+
+func (svc *TaskService) RestoreProject(ctx context.Context, user, project string) (string, error) {
+	if err := validateProjectRef(project); err != nil {
+		return "", err
+	}
+	project = svc.projectID(user, project)
+	if err := svc.restoreProjectTree(ctx, user, project); err != nil {
+		return "", err
 	}
-	return svc.repo.DeleteTask(user, project, taskID)
+	return svc.record(ctx, user, project, "", models.ChangeRestore, nil, nil), nil
 }
 
+// PurgeTask permanently deletes a task that is in the trash.
+func (svc *TaskService) PurgeTask(ctx context.Context, user, project, taskID string) error {
+	if err := validateTaskRef(project, taskID); err != nil {
+		return err
+	}
+	project = svc.projectID(user, project)
+	if !svc.inTrash(user, project, taskID) {
+		return ErrNotInTrash
+	}
+	if err := svc.repo.DeleteTask(user, project, taskID); err != nil {
+		return err
+	}
+	svc.record(ctx, user, project, taskID, models.ChangePurge, nil, nil)
+	return nil
+}
+
+// PurgeProject permanently deletes a project that is in the trash, with all of
+// its tasks and the sub-projects trashed below it.
+func (svc *TaskService) PurgeProject(ctx context.Context, user, project string) error {
+	if err := validateProjectRef(project); err != nil {
+		return err
+	}
+	project = svc.projectID(user, project)
+	if !svc.inTrash(user, project, "") {
+		return ErrNotInTrash
+	}
+	if err := svc.purgeSubProjects(ctx, user, project); err != nil {
+		return err
+	}
+	if err := svc.repo.DeleteProject(user, project); err != nil {
+		return err
+	}
+	svc.record(ctx, user, project, "", models.ChangePurge, nil, nil)
+	return nil
+}
+
+// EmptyTrash permanently deletes everything in the user's trash.
+func (svc *TaskService) EmptyTrash(ctx context.Context, user string) (int, error) {
+	items, err := svc.repo.ListTrash(user)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := svc.repo.EmptyTrash(user, time.Now())
+	if err != nil {
+		return purged, err
+	}
+	for _, item := range items {
+		if item.Kind == models.TrashKindProject {
+			svc.record(ctx, user, item.Project, "", models.ChangePurge, nil, nil)
+		} else {
+			svc.record(ctx, user, item.Project, item.Task.ID, models.ChangePurge, nil, nil)
+		}
+	}
+	return purged, nil
+}
+
+// StartTrashSweeper permanently deletes trash items older than retention,
+// checking every interval until ctx is done.
+func (svc *TaskService) StartTrashSweeper(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				svc.sweepTrash(time.Now().Add(-retention))
+			}
+		}
+	}()
+}
+
+// sweepTrash purges every user's trash items deleted before the cutoff and
+// records a purge for each in its owner's history.
+func (svc *TaskService) sweepTrash(before time.Time) {
+	purged, err := svc.repo.EmptyAllTrash(before)
+	for _, item := range purged {
+		svc.addChange(item.Username, models.TaskChange{
+			ID:      fmt.Sprintf("chg_%s", uuid.New().String()),
+			Project: item.Project,
+			TaskID:  item.TaskID,
+			Actor:   models.ActorSystem,
+			Action:  models.ChangePurge,
+			Time:    time.Now(),
+		})
+	}
+	if err != nil {
+		log.Printf("Error sweeping trash: %v", err)
+		return
+	}
+	if len(purged) > 0 {
+		log.Printf("Trash sweeper purged %d items", len(purged))
+	}
+}
+
+// inTrash reports whether the task (or the project, when taskID is empty) is in the user's trash.
+func (svc *TaskService) inTrash(user, project, taskID string) bool {
+	items, err := svc.repo.ListTrash(user)
+	if err != nil {
+		return false
+	}
+	for _, item := range items {
+		if item.Project != project {
+			continue
+		}
+		if taskID == "" && item.Kind == models.TrashKindProject {
+			return true
+		}
+		if taskID != "" && item.Kind == models.TrashKindTask && item.Task.ID == taskID {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveUserTasks permanently deletes all of the user's tasks and their history.
 func (svc *TaskService) RemoveUserTasks(user string) error {
-	return svc.repo.DeleteUserTasks(user)
+	if err := svc.repo.DeleteUserTasks(user); err != nil {
+		return err
+	}
+	svc.dropIndex(user)
+	return svc.history.DeleteUserHistory(user)
+}
+
+func (svc *TaskService) RemoveUserProjects(user string) error {
+	if err := svc.repo.DeleteUserProjects(user); err != nil {
+		return err
+	}
+	if err := svc.templates.DeleteUserTemplates(user); err != nil {
+		return err
+	}
+	return svc.smartLists.DeleteUserSmartLists(user)
 }
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+	"todolist/internal/models"
+	"todolist/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Templates and clones create an ordinary project and write all its tasks in
+// one ApplyTaskWrites batch. If the batch fails the project is deleted again,
+// so a copy is made whole or not at all. The project and every task get their
+// own history entry and undo token; the copy as a whole is reverted by
+// removing the project.
+
+// SaveTemplate saves the tasks of project as a template named name, or after
+// the project when name is empty. Due dates are stored relative to anchor,
+// the current time when zero, and completed tasks are saved as open ones.
+func (svc *TaskService) SaveTemplate(user, project, name string, anchor time.Time) (models.Template, error) {
+	source, err := svc.GetProject(user, project)
+	if err != nil {
+		return models.Template{}, err
+	}
+	if name == "" {
+		name = source.Name
+	}
+	if err := svc.limits.validateNewTemplate(project, name); err != nil {
+		return models.Template{}, err
+	}
+	if _, err := svc.GetTemplate(user, name); err == nil {
+		return models.Template{}, ErrTemplateExists
+	}
+	if anchor.IsZero() {
+		anchor = time.Now()
+	}
+	tasks, err := svc.repo.ListTasks(user, source.ID)
+	if err != nil {
+		return models.Template{}, err
+	}
+	template := models.Template{
+		ID:          fmt.Sprintf("tpl_%s", uuid.New().String()),
+		Name:        name,
+		Description: source.Description,
+		Color:       source.Color,
+		Created:     time.Now(),
+		Tasks:       make([]models.TemplateTask, 0, len(tasks)),
+	}
+	for _, t := range sortedForCopy(tasks) {
+		task := models.TemplateTask{Content: t.Content, Priority: t.Priority, Recurrence: t.Recurrence}
+		if !t.Due.IsZero() && !t.Due.Equal(DefaultTimestamp) {
+			offset := int64(t.Due.Sub(anchor) / time.Second)
+			task.DueOffset = &offset
+		}
+		template.Tasks = append(template.Tasks, task)
+	}
+	if err := svc.templates.CreateTemplate(user, template); err != nil {
+		return models.Template{}, err
+	}
+	return template, nil
+}
+
+// GetTemplates returns the user's templates sorted by name.
+func (svc *TaskService) GetTemplates(user string) ([]models.Template, error) {
+	templates, err := svc.templates.ListTemplates(user)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// GetTemplate returns the template named or identified by ref.
+func (svc *TaskService) GetTemplate(user, ref string) (models.Template, error) {
+	if err := validate(required("template", ref)); err != nil {
+		return models.Template{}, err
+	}
+	templates, err := svc.templates.ListTemplates(user)
+	if err != nil {
+		return models.Template{}, err
+	}
+	for _, t := range templates {
+		if t.ID == ref {
+			return t, nil
+		}
+	}
+	for _, t := range templates {
+		if t.Name == ref {
+			return t, nil
+		}
+	}
+	return models.Template{}, ErrTemplateNotFound
+}
+
+// RemoveTemplate deletes a template. Projects created from it are kept.
+func (svc *TaskService) RemoveTemplate(user, ref string) error {
+	template, err := svc.GetTemplate(user, ref)
+	if err != nil {
+		return err
+	}
+	return svc.templates.DeleteTemplate(user, template.ID)
+}
+
+// InstantiateTemplate creates a project named name, nested in parent when
+// that is not empty, with the template's description, color and tasks. Due
+// dates are set relative to anchor, the current time when zero.
+func (svc *TaskService) InstantiateTemplate(ctx context.Context, user, template, name, parent string, anchor time.Time) (models.Project, error) {
+	if err := svc.limits.validateCopy(template, name); err != nil {
+		return models.Project{}, err
+	}
+	tpl, err := svc.GetTemplate(user, template)
+	if err != nil {
+		return models.Project{}, err
+	}
+	if anchor.IsZero() {
+		anchor = time.Now()
+	}
+	tasks := make([]models.Task, 0, len(tpl.Tasks))
+	for _, t := range tpl.Tasks {
+		task := models.Task{Content: t.Content, Priority: t.Priority, Recurrence: t.Recurrence}
+		if t.DueOffset != nil {
+			task.Due = anchor.Add(time.Duration(*t.DueOffset) * time.Second)
+		}
+		tasks = append(tasks, task)
+	}
+	return svc.createCopy(ctx, user, name, parent, tpl.Description, tpl.Color, tasks)
+}
+
+// CloneProject copies a project, with its description, color, parent and
+// tasks, into a new project named name. The tasks keep their content,
+// priority, due date, completion and recurrence, under new IDs.
+func (svc *TaskService) CloneProject(ctx context.Context, user, project, name string) (models.Project, error) {
+	if err := svc.limits.validateCopy(project, name); err != nil {
+		return models.Project{}, err
+	}
+	source, err := svc.GetProject(user, project)
+	if err != nil {
+		return models.Project{}, err
+	}
+	tasks, err := svc.repo.ListTasks(user, source.ID)
+	if err != nil {
+		return models.Project{}, err
+	}
+	return svc.createCopy(ctx, user, name, source.ParentID, source.Description, source.Color, sortedForCopy(tasks))
+}
+
+// createCopy creates the project of a template instantiation or clone, nested
+// in parent when that is not empty, and writes tasks to it under new IDs. The
+// tasks are validated before anything is written; the name must not be taken.
+func (svc *TaskService) createCopy(ctx context.Context, user, name, parent, description, color string, tasks []models.Task) (models.Project, error) {
+	now := time.Now()
+	writes := make([]repository.TaskWrite, 0, len(tasks))
+	for _, task := range tasks {
+		if err := validate(svc.limits.taskRules(task)...); err != nil {
+			return models.Project{}, err
+		}
+		task.ID = fmt.Sprintf("task_%s", uuid.New().String())
+		if task.Due.IsZero() {
+			task.Due = DefaultTimestamp
+		}
+		task.UpdatedTime = now
+		writes = append(writes, repository.TaskWrite{Kind: repository.WritePut, Task: task, Checked: true})
+	}
+	if live, trashed, err := svc.nameTaken(user, name, ""); err != nil {
+		return models.Project{}, err
+	} else if live {
+		return models.Project{}, ErrProjectExists
+	} else if trashed {
+		return models.Project{}, ErrProjectTrashed
+	}
+	project := models.Project{
+		ID:          fmt.Sprintf("pjt_%s", uuid.New().String()),
+		Name:        name,
+		Description: description,
+		Color:       color,
+		Created:     now,
+	}
+	if parent != "" {
+		projects, err := svc.repo.ListProjects(user)
+		if err != nil {
+			return models.Project{}, err
+		}
+		p, ok := findProject(projects, parent)
+		if !ok {
+			return models.Project{}, ErrProjectNotFound
+		}
+		project.ParentID = p.ID
+	}
+
+	if err := svc.repo.CreateProject(user, project); err != nil {
+		return models.Project{}, err
+	}
+	for i := range writes {
+		writes[i].Project = project.ID
+	}
+	if err := svc.repo.ApplyTaskWrites(user, writes); err != nil {
+		if err := svc.repo.DeleteProject(user, project.ID); err != nil {
+			log.Printf("Error deleting project '%s' of a failed copy for user '%s': %v", project.ID, user, err)
+		}
+		return models.Project{}, err
+	}
+	svc.record(ctx, user, project.ID, "", models.ChangeCreate, nil, nil)
+	for _, w := range writes {
+		task := w.Task
+		svc.record(ctx, user, project.ID, task.ID, models.ChangeCreate, nil, &task)
+	}
+	return project, nil
+}
+
+// sortedForCopy orders tasks by due date, then priority, so that copies list
+// them in a stable order.
+func sortedForCopy(tasks []models.Task) []models.Task {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if !tasks[i].Due.Equal(tasks[j].Due) {
+			return tasks[i].Due.Before(tasks[j].Due)
+		}
+		return tasks[i].Priority < tasks[j].Priority
+	})
+	return tasks
+}
+
+func (l Limits) validateNewTemplate(project, name string) error {
+	return validate(append([]rule{required("project", project)}, l.projectNameRules("name", name)...)...)
+}
+
+// validateCopy checks the project a template instantiation or a clone creates.
+func (l Limits) validateCopy(source, name string) error {
+	return validate(append([]rule{required("source", source)}, l.projectNameRules("name", name)...)...)
+}
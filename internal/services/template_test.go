@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"todolist/internal/models"
+	"todolist/internal/repository"
+)
+
+// failingWrites fails every ApplyTaskWrites.
+type failingWrites struct {
+	*repository.InMemTaskRepository
+}
+
+var errWrites = errors.New("writes failed")
+
+func (failingWrites) ApplyTaskWrites(string, []repository.TaskWrite) error {
+	return errWrites
+}
+
+func TestCloneProjectKeepsTasks(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	area := env.project(t, "alice", "area", "")
+	source := env.project(t, "alice", "release", area.ID)
+	desc, color := "ship it", "#1e90ff"
+	if _, _, err := env.taskSvc.UpdateProject(ctx, "alice", source.ID, models.ProjectUpdate{Description: &desc, Color: &color}); err != nil {
+		t.Fatal(err)
+	}
+	due := time.Date(2030, 5, 1, 12, 0, 0, 0, time.UTC)
+	open := env.task(t, "alice", source.ID, models.Task{Content: "tag", Priority: 2, Due: due})
+	done := env.task(t, "alice", source.ID, models.Task{Content: "changelog", Priority: 1, Completed: true})
+
+	clone, err := env.taskSvc.CloneProject(ctx, "alice", "release", "release-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clone.Name != "release-copy" || clone.ParentID != area.ID || clone.Description != desc || clone.Color != color {
+		t.Errorf("clone = %+v, want the source's parent, description and color", clone)
+	}
+	tasks, _ := env.tasks.ListTasks("alice", clone.ID)
+	if len(tasks) != 2 {
+		t.Fatalf("clone has %d tasks, want 2", len(tasks))
+	}
+	for _, want := range []models.Task{open, done} {
+		found := false
+		for _, got := range tasks {
+			if got.Content != want.Content {
+				continue
+			}
+			found = true
+			if got.ID == want.ID || got.Priority != want.Priority || got.Completed != want.Completed || !got.Due.Equal(want.Due) {
+				t.Errorf("copy of %s = %+v, want %+v under a new ID", want.Content, got, want)
+			}
+			if changes, _ := env.taskSvc.GetTaskHistory("alice", clone.ID, got.ID); len(changes) != 1 || changes[0].Action != models.ChangeCreate {
+				t.Errorf("history of the copy of %s = %+v, want one create", want.Content, changes)
+			}
+		}
+		if !found {
+			t.Errorf("%s was not copied", want.Content)
+		}
+	}
+}
+
+func TestFailedCopyLeavesNothing(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	source := env.project(t, "alice", "release", "")
+	env.task(t, "alice", source.ID, models.Task{Content: "tag"})
+	if _, err := env.taskSvc.SaveTemplate("alice", "release", "", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	env.taskSvc = NewTaskService(failingWrites{env.tasks}, env.history, env.templates, env.smartLists, env.users, DefaultUndoWindow, DefaultLimits())
+
+	if _, err := env.taskSvc.CloneProject(ctx, "alice", "release", "copy"); !errors.Is(err, errWrites) {
+		t.Errorf("CloneProject = %v, want the write error", err)
+	}
+	if _, err := env.taskSvc.InstantiateTemplate(ctx, "alice", "release", "instance", "", time.Time{}); !errors.Is(err, errWrites) {
+		t.Errorf("InstantiateTemplate = %v, want the write error", err)
+	}
+	projects, _ := env.tasks.ListProjects("alice")
+	if len(projects) != 1 || projects[0].ID != source.ID {
+		t.Errorf("projects = %+v, want only the source", projects)
+	}
+	if trash, _ := env.tasks.ListTrash("alice"); len(trash) != 0 {
+		t.Errorf("trash = %+v, want empty", trash)
+	}
+	// The names are free again.
+	env.taskSvc = NewTaskService(env.tasks, env.history, env.templates, env.smartLists, env.users, DefaultUndoWindow, DefaultLimits())
+	if _, err := env.taskSvc.CloneProject(ctx, "alice", "release", "copy"); err != nil {
+		t.Errorf("CloneProject after a failed copy: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"todolist/internal/models"
+)
+
+func TestTrashedProjectResolvedByName(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.register(t, "alice")
+	home := env.project(t, "alice", "home", "")
+	if _, err := env.taskSvc.RemoveProject(ctx, "alice", "home", ""); err != nil {
+		t.Fatalf("RemoveProject: %v", err)
+	}
+
+	if _, _, err := env.taskSvc.CreateProject(ctx, "alice", "home", ""); !errors.Is(err, ErrProjectTrashed) {
+		t.Fatalf("CreateProject over a trashed name: err = %v, want ErrProjectTrashed", err)
+	}
+	if got := env.taskSvc.projectID("alice", "home"); got != home.ID {
+		t.Fatalf("projectID(home) = %q, want %q", got, home.ID)
+	}
+	if _, err := env.taskSvc.RestoreProject(ctx, "alice", "home"); err != nil {
+		t.Fatalf("RestoreProject by name: %v", err)
+	}
+	if _, _, err := env.taskSvc.CreateProject(ctx, "alice", "work", ""); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+}
+
+func TestSweepTrashRecordsPurges(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.register(t, "alice")
+	home := env.project(t, "alice", "home", "")
+	work := env.project(t, "alice", "work", "")
+	task := env.task(t, "alice", home.ID, models.Task{Content: "milk"})
+	kept := env.task(t, "alice", home.ID, models.Task{Content: "bread"})
+	if _, err := env.taskSvc.RemoveTask(ctx, "alice", home.ID, task.ID); err != nil {
+		t.Fatalf("RemoveTask: %v", err)
+	}
+	if _, err := env.taskSvc.RemoveProject(ctx, "alice", work.ID, ""); err != nil {
+		t.Fatalf("RemoveProject: %v", err)
+	}
+
+	env.taskSvc.sweepTrash(time.Now().Add(time.Second))
+
+	if items, _ := env.tasks.ListTrash("alice"); len(items) != 0 {
+		t.Fatalf("trash after sweep = %v, want empty", items)
+	}
+	for _, c := range []struct{ project, taskID string }{{home.ID, task.ID}, {work.ID, ""}} {
+		changes, err := env.history.ListProjectHistory("alice", c.project)
+		if err != nil {
+			t.Fatalf("ListProjectHistory: %v", err)
+		}
+		last := changes[len(changes)-1]
+		if last.Action != models.ChangePurge || last.TaskID != c.taskID || last.Actor != models.ActorSystem {
+			t.Errorf("last change of %s/%s = %s of %q by %s, want a purge by %s",
+				c.project, c.taskID, last.Action, last.TaskID, last.Actor, models.ActorSystem)
+		}
+	}
+	if _, ok := env.tasks.GetTask("alice", home.ID, kept.ID); !ok {
+		t.Error("live task was purged")
+	}
+}
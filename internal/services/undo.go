@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+	"todolist/internal/models"
+	"todolist/internal/repository"
+)
+
+// Undo reverts the mutation identified by token, the value returned by the
+// mutating TaskService methods. It refuses once the undo window has passed or
+// when the task (or, for project-level mutations, anything in the project) has
+// changed since: a task must still match the change's snapshot of it,
+// UpdatedTime included. The undo is itself recorded in the history and returns
+// the change that was reverted.
+func (svc *TaskService) Undo(ctx context.Context, user, token string) (models.TaskChange, error) {
+	if err := validate(required("token", token)); err != nil {
+		return models.TaskChange{}, err
+	}
+	change, err := svc.history.GetChange(user, token)
+	if err != nil {
+		if errors.Is(err, repository.ErrChangeNotFound) {
+			return models.TaskChange{}, ErrUndoNotFound
+		}
+		return models.TaskChange{}, err
+	}
+	if time.Since(change.Time) > svc.undoWindow {
+		return models.TaskChange{}, ErrUndoExpired
+	}
+	if change.Action == models.ChangePurge || change.Action == models.ChangeUndo {
+		return models.TaskChange{}, ErrNotUndoable
+	}
+
+	var unchanged bool
+	if change.TaskID == "" {
+		unchanged, err = svc.projectUnchanged(user, change)
+		if err != nil {
+			return models.TaskChange{}, err
+		}
+	} else {
+		unchanged = svc.taskUnchanged(user, change)
+	}
+	if !unchanged {
+		return models.TaskChange{}, ErrUndoConflict
+	}
+
+	if change.TaskID == "" {
+		err = svc.undoProjectChange(ctx, user, change)
+	} else {
+		err = svc.undoTaskChange(user, change)
+	}
+	if err != nil {
+		return models.TaskChange{}, err
+	}
+
+	var current *models.Task
+	if change.TaskID != "" {
+		if task, exist := svc.repo.GetTask(user, change.Project, change.TaskID); exist {
+			current = &task
+		}
+	}
+	svc.record(ctx, user, change.Project, change.TaskID, models.ChangeUndo, change.After, current)
+	return change, nil
+}
+
+func (svc *TaskService) undoTaskChange(user string, change models.TaskChange) error {
+	switch change.Action {
+	case models.ChangeCreate:
+		return svc.repo.DeleteTask(user, change.Project, change.TaskID)
+	case models.ChangeUpdate, models.ChangeComplete:
+		if change.Before == nil {
+			return ErrNotUndoable
+		}
+		return svc.repo.UpdateTask(user, change.Project, *change.Before)
+	case models.ChangeTrash:
+		return svc.repo.RestoreTask(user, change.Project, change.TaskID)
+	case models.ChangeRestore:
+		return svc.repo.TrashTask(user, change.Project, change.TaskID, time.Now())
+	case models.ChangeMove:
+		from, ok := moveSource(change)
+		if !ok || change.Before == nil || change.After == nil {
+			return ErrNotUndoable
+		}
+		if _, taken := svc.repo.GetTask(user, from, change.Before.ID); taken {
+			return ErrUndoConflict
+		}
+		err := svc.repo.ApplyTaskWrites(user, []repository.TaskWrite{
+			{Kind: repository.WriteDelete, Project: change.Project, Task: *change.After},
+			{Kind: repository.WritePut, Project: from, Task: *change.Before},
+		})
+		if err != nil {
+			return err
+		}
+		return svc.history.MoveTaskHistory(user, change.Project, from, change.TaskID)
+	}
+	return ErrNotUndoable
+}
+
+func (svc *TaskService) undoProjectChange(ctx context.Context, user string, change models.TaskChange) error {
+	switch change.Action {
+	case models.ChangeCreate:
+		return svc.repo.DeleteProject(user, change.Project)
+	case models.ChangeUpdate:
+		return svc.revertProjectUpdate(user, change)
+	case models.ChangeTrash:
+		if err := svc.checkLifted(user, change); err != nil {
+			return err
+		}
+		if err := svc.restoreProjectTree(ctx, user, change.Project); err != nil {
+			return err
+		}
+		return svc.renestLifted(ctx, user, change)
+	case models.ChangeRestore:
+		return svc.trashProjectTree(user, change.Project)
+	}
+	return ErrNotUndoable
+}
+
+// taskUnchanged reports whether the task is as change left it: live and
+// equal to change.After, UpdatedTime included, or not live when the change
+// removed it.
+func (svc *TaskService) taskUnchanged(user string, change models.TaskChange) bool {
+	current, live := svc.repo.GetTask(user, change.Project, change.TaskID)
+	if change.After == nil {
+		return !live
+	}
+	return live && current.Equal(*change.After)
+}
+
+// projectUnchanged reports whether the project's history has no entry after
+// change. Entries are only ordered to the precision of their times, so one at
+// the same time as change counts as later.
+func (svc *TaskService) projectUnchanged(user string, change models.TaskChange) (bool, error) {
+	entries, err := svc.history.ListProjectHistory(user, change.Project)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if entry.ID != change.ID && !entry.Time.Before(change.Time) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"todolist/internal/models"
+)
+
+func TestUndoLiftedRemoveProjectRenestsChildren(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.register(t, "alice")
+	root := env.project(t, "alice", "root", "")
+	mid := env.project(t, "alice", "mid", root.ID)
+	a := env.project(t, "alice", "a", mid.ID)
+	b := env.project(t, "alice", "b", mid.ID)
+
+	token, err := env.taskSvc.RemoveProject(ctx, "alice", mid.ID, ChildrenLift)
+	if err != nil {
+		t.Fatalf("RemoveProject: %v", err)
+	}
+	for _, id := range []string{a.ID, b.ID} {
+		if p := mustProject(t, env, "alice", id); p.ParentID != root.ID {
+			t.Fatalf("lifted %s has parent %q, want %q", id, p.ParentID, root.ID)
+		}
+	}
+
+	if _, err := env.taskSvc.Undo(ctx, "alice", token); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if p := mustProject(t, env, "alice", mid.ID); p.ParentID != root.ID {
+		t.Errorf("restored project has parent %q, want %q", p.ParentID, root.ID)
+	}
+	for _, id := range []string{a.ID, b.ID} {
+		if p := mustProject(t, env, "alice", id); p.ParentID != mid.ID {
+			t.Errorf("%s has parent %q after undo, want %q", id, p.ParentID, mid.ID)
+		}
+	}
+}
+
+func TestUndoLiftedRemoveProjectConflictsWhenChildMoved(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.register(t, "alice")
+	mid := env.project(t, "alice", "mid", "")
+	child := env.project(t, "alice", "child", mid.ID)
+	other := env.project(t, "alice", "other", "")
+
+	token, err := env.taskSvc.RemoveProject(ctx, "alice", mid.ID, ChildrenLift)
+	if err != nil {
+		t.Fatalf("RemoveProject: %v", err)
+	}
+	parent := other.ID
+	if _, _, err := env.taskSvc.UpdateProject(ctx, "alice", child.ID, models.ProjectUpdate{Parent: &parent}); err != nil {
+		t.Fatalf("UpdateProject: %v", err)
+	}
+	if _, err := env.taskSvc.Undo(ctx, "alice", token); !errors.Is(err, ErrUndoConflict) {
+		t.Fatalf("Undo after moving a lifted child: err = %v, want ErrUndoConflict", err)
+	}
+	if _, ok := findProject(mustProjects(t, env, "alice"), mid.ID); ok {
+		t.Error("project restored despite the conflict")
+	}
+}
+
+func TestUndoTaskConflicts(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.register(t, "alice")
+	home := env.project(t, "alice", "home", "")
+
+	tests := []struct {
+		name    string
+		mutate  func(t *testing.T, task models.Task) string // returns the token to undo
+		later   func(t *testing.T, task models.Task)        // nil for no later change
+		wantErr error
+	}{
+		{
+			name: "update then undo",
+			mutate: func(t *testing.T, task models.Task) string {
+				task.Content = "edited"
+				_, token, err := env.taskSvc.WriteTask(ctx, "alice", home.ID, task)
+				mustNilErr(t, err)
+				return token
+			},
+		},
+		{
+			name: "update then complete",
+			mutate: func(t *testing.T, task models.Task) string {
+				task.Content = "edited"
+				_, token, err := env.taskSvc.WriteTask(ctx, "alice", home.ID, task)
+				mustNilErr(t, err)
+				return token
+			},
+			later: func(t *testing.T, task models.Task) {
+				_, err := env.taskSvc.MarkTaskComplete(ctx, "alice", home.ID, task.ID)
+				mustNilErr(t, err)
+			},
+			wantErr: ErrUndoConflict,
+		},
+		{
+			name: "complete then undo",
+			mutate: func(t *testing.T, task models.Task) string {
+				token, err := env.taskSvc.MarkTaskComplete(ctx, "alice", home.ID, task.ID)
+				mustNilErr(t, err)
+				return token
+			},
+		},
+		{
+			name: "update then update",
+			mutate: func(t *testing.T, task models.Task) string {
+				task.Content = "first"
+				_, token, err := env.taskSvc.WriteTask(ctx, "alice", home.ID, task)
+				mustNilErr(t, err)
+				return token
+			},
+			later: func(t *testing.T, task models.Task) {
+				task.Content = "second"
+				_, _, err := env.taskSvc.WriteTask(ctx, "alice", home.ID, task)
+				mustNilErr(t, err)
+			},
+			wantErr: ErrUndoConflict,
+		},
+		{
+			name: "trash then restore",
+			mutate: func(t *testing.T, task models.Task) string {
+				token, err := env.taskSvc.RemoveTask(ctx, "alice", home.ID, task.ID)
+				mustNilErr(t, err)
+				return token
+			},
+			later: func(t *testing.T, task models.Task) {
+				_, err := env.taskSvc.RestoreTask(ctx, "alice", home.ID, task.ID)
+				mustNilErr(t, err)
+			},
+			wantErr: ErrUndoConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := env.task(t, "alice", home.ID, models.Task{Content: tt.name, Due: time.Now().Add(time.Hour)})
+			token := tt.mutate(t, task)
+			if tt.later != nil {
+				tt.later(t, task)
+			}
+			_, err := env.taskSvc.Undo(ctx, "alice", token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Undo: err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				if _, err := env.taskSvc.Undo(ctx, "alice", token); !errors.Is(err, ErrUndoConflict) {
+					t.Errorf("second Undo: err = %v, want ErrUndoConflict", err)
+				}
+			}
+		})
+	}
+}
+
+func mustProject(t *testing.T, env *testEnv, user, id string) models.Project {
+	t.Helper()
+	p, ok := findProjectByID(mustProjects(t, env, user), id)
+	if !ok {
+		t.Fatalf("project %s not found", id)
+	}
+	return p
+}
+
+func mustNilErr(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
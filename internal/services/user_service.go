@@ -1,22 +1,42 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 	"todolist/internal/models"
 	"todolist/internal/repository"
 )
 
+// DefaultGracePeriod is how long a deactivated account can be reactivated
+// before the purger deletes it for good.
+const DefaultGracePeriod = 30 * 24 * time.Hour
+
+// DefaultTokenTTL is how long a token issued by Login stays valid.
+const DefaultTokenTTL = 30 * 24 * time.Hour
+
 type UserService struct {
-	repo repository.UserRepository
+	repo        repository.UserRepository
+	idempotency repository.IdempotencyRepository
+	gracePeriod time.Duration
+	tokenTTL    time.Duration
+	limits      Limits
 }
 
-func NewUserService(repo repository.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo repository.UserRepository, idempotency repository.IdempotencyRepository, gracePeriod, tokenTTL time.Duration, limits Limits) *UserService {
+	return &UserService{repo: repo, idempotency: idempotency, gracePeriod: gracePeriod, tokenTTL: tokenTTL, limits: limits}
 }
 
 func (svc *UserService) RegisterUser(username, password string) error {
-	if username == "" || password == "" {
-		return errors.New("username and password cannot be empty")
+	if err := svc.limits.validateUser(username, password); err != nil {
+		return err
 	}
 
 	user := models.User{
@@ -25,19 +45,223 @@ func (svc *UserService) RegisterUser(username, password string) error {
 		Active:   true,
 	}
 
-	return svc.repo.AddUser(user)
+	if err := svc.repo.AddUser(user); err != nil {
+		if errors.Is(err, repository.ErrUserExists) {
+			return ErrUserExists
+		}
+		return err
+	}
+	return nil
 }
 
 func (svc *UserService) AuthenticateUser(username, password string) bool {
 	return svc.repo.Authenticate(username, password)
 }
 
-func (svc *UserService) DeactivateUser(username string, taskSvc *TaskService) error {
+// Login checks the user's password and issues a bearer token that is valid
+// until the returned time. The token is the base64url username, a dot and 32
+// random bytes in base64url; only its hash is stored.
+func (svc *UserService) Login(username, password string) (string, time.Time, error) {
+	if !svc.repo.Authenticate(username, password) {
+		return "", time.Time{}, ErrInvalidCredentials
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", time.Time{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString([]byte(username)) + "." + base64.RawURLEncoding.EncodeToString(secret)
+	now := time.Now()
+	stored := models.AuthToken{Hash: hashToken(token), Created: now, Expires: now.Add(svc.tokenTTL)}
+	if err := svc.repo.AddToken(username, stored); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, stored.Expires, nil
+}
+
+// AuthenticateToken returns the user a token was issued to, if the token was
+// neither revoked nor has expired and the account is active.
+func (svc *UserService) AuthenticateToken(token string) (string, bool) {
+	username, ok := tokenUser(token)
+	if !ok {
+		return "", false
+	}
+	if _, found, err := svc.repo.GetToken(username, hashToken(token)); err != nil || !found {
+		return "", false
+	}
+	user, err := svc.repo.GetUser(username)
+	if err != nil || !user.Active {
+		return "", false
+	}
+	return username, true
+}
+
+// Logout revokes a token. It returns ErrInvalidCredentials for a token that
+// is unknown, revoked or expired.
+func (svc *UserService) Logout(token string) error {
+	username, ok := tokenUser(token)
+	if !ok {
+		return ErrInvalidCredentials
+	}
+	hash := hashToken(token)
+	if _, found, err := svc.repo.GetToken(username, hash); err != nil {
+		return err
+	} else if !found {
+		return ErrInvalidCredentials
+	}
+	return svc.repo.DeleteToken(username, hash)
+}
+
+// tokenUser returns the username encoded in the first part of a token.
+func tokenUser(token string) (string, bool) {
+	encoded, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", false
+	}
+	username, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(username) == 0 {
+		return "", false
+	}
+	return string(username), true
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// DeactivateUser blocks the user from logging in and revokes their tokens,
+// but keeps their data until the grace period has passed. It returns the
+// time after which the account will be purged.
+func (svc *UserService) DeactivateUser(username string) (time.Time, error) {
 	if err := svc.repo.DeactivateUser(username); err != nil {
+		return time.Time{}, err
+	}
+	if err := svc.repo.DeleteUserTokens(username); err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(svc.gracePeriod), nil
+}
+
+// ReactivateUser restores a deactivated account that is still within its
+// grace period. The password is checked directly because deactivated users
+// cannot pass the regular authentication.
+func (svc *UserService) ReactivateUser(username, password string) error {
+	user, err := svc.repo.GetUser(username)
+	if err != nil || user.Password != password {
+		return ErrInvalidCredentials
+	}
+	if user.Active {
+		return ErrUserNotDeactivated
+	}
+	if time.Since(user.DeactivatedAt) > svc.gracePeriod {
+		return ErrGracePeriodExpired
+	}
+	if err := svc.repo.ReactivateUser(username); err != nil {
+		if errors.Is(err, repository.ErrUserPurging) {
+			return ErrGracePeriodExpired
+		}
 		return err
 	}
-	// Remove user's tasks clearly
-	return taskSvc.RemoveUserTasks(username)
+	return nil
+}
+
+// PurgeDeactivatedUsers permanently deletes every user whose grace period has
+// passed, together with everything stored for them: projects, tasks, trash,
+// history, templates, smart lists, idempotency records and tokens. Only the
+// audit log keeps the user's name, in the record of the purge left for each
+// one. It returns the number of purged users.
+func (svc *UserService) PurgeDeactivatedUsers(taskSvc *TaskService) (int, error) {
+	users, err := svc.repo.ListDeactivatedUsers(time.Now().Add(-svc.gracePeriod))
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, user := range users {
+		ok, err := svc.purgeUser(user, taskSvc)
+		if err != nil {
+			log.Printf("Error purging user %s: %v", user.Username, err)
+			continue
+		}
+		if ok {
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// purgeUser deletes a user listed as deactivated, unless the account was
+// reactivated in the meantime; it then reports false. The account is first
+// claimed, which stops it from being reactivated, so that a purge that fails
+// halfway is finished on the next run rather than leaving a live account
+// with part of its data gone.
+func (svc *UserService) purgeUser(user models.User, taskSvc *TaskService) (bool, error) {
+	claimed, err := svc.repo.ClaimForPurge(user.Username, user.DeactivatedAt)
+	if err != nil || !claimed {
+		return false, err
+	}
+	if err := taskSvc.RemoveUserTasks(user.Username); err != nil {
+		return false, fmt.Errorf("error deleting tasks: %w", err)
+	}
+	if err := taskSvc.RemoveUserProjects(user.Username); err != nil {
+		return false, fmt.Errorf("error deleting projects: %w", err)
+	}
+	if err := svc.idempotency.DeleteUserKeys(user.Username); err != nil {
+		return false, fmt.Errorf("error deleting idempotency records: %w", err)
+	}
+	if err := svc.repo.DeleteUserTokens(user.Username); err != nil {
+		return false, fmt.Errorf("error deleting tokens: %w", err)
+	}
+	if err := svc.repo.DeleteUser(user.Username); err != nil {
+		return false, fmt.Errorf("error deleting user: %w", err)
+	}
+	record := models.AuditRecord{
+		Username: user.Username,
+		Action:   "user_purged",
+		Detail:   fmt.Sprintf("deactivated at %s, deleted user, projects, tasks, history, templates, smart lists, idempotency records and tokens", user.DeactivatedAt.Format(time.RFC3339)),
+		Time:     time.Now(),
+	}
+	if err := svc.repo.AddAuditRecord(record); err != nil {
+		log.Printf("Error writing audit record for purged user %s: %v", user.Username, err)
+	}
+	log.Printf("User %s purged after grace period", user.Username)
+	return true, nil
+}
+
+// GetAuditRecords returns the audit records of a user, newest first. They
+// outlive the account.
+func (svc *UserService) GetAuditRecords(username string) ([]models.AuditRecord, error) {
+	return svc.repo.ListAuditRecords(username)
+}
+
+// StartPurger runs PurgeDeactivatedUsers every interval until ctx is done.
+func (svc *UserService) StartPurger(ctx context.Context, taskSvc *TaskService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := svc.PurgeDeactivatedUsers(taskSvc); err != nil {
+					log.Printf("Error purging deactivated users: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// ListUsernames returns the names of every account, deactivated ones included.
+func (svc *UserService) ListUsernames() ([]string, error) {
+	users, err := svc.repo.ListUsers()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(users))
+	for i, user := range users {
+		names[i] = user.Username
+	}
+	return names, nil
 }
 
 func (svc *UserService) GetUser(username string) (models.User, error) {
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"todolist/internal/models"
+	"todolist/internal/repository"
+)
+
+func TestRegisterUserConcurrentDuplicates(t *testing.T) {
+	const n = 64
+	svc := newTestEnv(t).userSvc
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	start := make(chan struct{})
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = svc.RegisterUser("alice", "secret1")
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded, existed := 0, 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrUserExists):
+			if errors.Is(err, repository.ErrUserExists) {
+				t.Errorf("RegisterUser leaks the repository error: %v", err)
+			}
+			existed++
+		default:
+			t.Errorf("RegisterUser: unexpected error %v", err)
+		}
+	}
+	if succeeded != 1 || existed != n-1 {
+		t.Errorf("got %d successes and %d ErrUserExists, want 1 and %d", succeeded, existed, n-1)
+	}
+}
+
+func TestPurgeDeletesEveryUserStore(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	var home models.Project
+	for _, user := range []string{"alice", "bob"} {
+		env.register(t, user)
+		home = env.project(t, user, "home", "")
+		env.task(t, user, "home", models.Task{Content: "Buy milk"})
+		trashed := env.task(t, user, "home", models.Task{Content: "Old chore"})
+		if _, err := env.taskSvc.RemoveTask(ctx, user, "home", trashed.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.taskSvc.SaveTemplate(user, "home", "chores", time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.taskSvc.SaveSmartList(user, "urgent", "priority <= 1"); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := env.idempotency.ReserveKey(user, models.IdempotencyRecord{Key: "k1", Fingerprint: "f", CreatedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if history, _ := env.history.ListProjectHistory("bob", home.ID); len(history) == 0 {
+		t.Fatal("no history recorded")
+	}
+	home, _ = findProject(mustProjects(t, env, "alice"), "home")
+	if _, err := env.userSvc.DeactivateUser("alice"); err != nil {
+		t.Fatal(err)
+	}
+	env.userSvc.gracePeriod = 0
+	// Deactivation revokes tokens, so one is left behind directly.
+	if err := env.users.AddToken("alice", models.AuthToken{Hash: "h", Expires: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := env.userSvc.PurgeDeactivatedUsers(env.taskSvc)
+	if err != nil || purged != 1 {
+		t.Fatalf("PurgeDeactivatedUsers = %d, %v; want 1, nil", purged, err)
+	}
+
+	if _, err := env.users.GetUser("alice"); err == nil {
+		t.Error("user alice still exists")
+	}
+	checkEmpty := func(what string, n int, err error) {
+		t.Helper()
+		if err != nil {
+			t.Errorf("%s: %v", what, err)
+		} else if n != 0 {
+			t.Errorf("%s: %d left after purge", what, n)
+		}
+	}
+	projects, err := env.tasks.ListProjects("alice")
+	checkEmpty("projects", len(projects), err)
+	trash, err := env.tasks.ListTrash("alice")
+	checkEmpty("trash", len(trash), err)
+	tasks, err := env.tasks.ListTasksInProjects("alice", []string{home.ID})
+	checkEmpty("tasks", len(tasks[home.ID]), err)
+	templates, err := env.templates.ListTemplates("alice")
+	checkEmpty("templates", len(templates), err)
+	lists, err := env.smartLists.ListSmartLists("alice")
+	checkEmpty("smart lists", len(lists), err)
+	history, err := env.history.ListProjectHistory("alice", home.ID)
+	checkEmpty("history", len(history), err)
+	if _, reserved, _ := env.idempotency.ReserveKey("alice", models.IdempotencyRecord{Key: "k1", Fingerprint: "g", CreatedAt: time.Now()}); !reserved {
+		t.Error("idempotency record k1 survived the purge")
+	}
+	if _, found, _ := env.users.GetToken("alice", "h"); found {
+		t.Error("token survived the purge")
+	}
+
+	records, err := env.userSvc.GetAuditRecords("alice")
+	if err != nil || len(records) != 1 || records[0].Action != "user_purged" {
+		t.Errorf("GetAuditRecords = %+v, %v; want one user_purged record", records, err)
+	}
+
+	// bob was not deactivated and keeps everything.
+	if projects, _ := env.tasks.ListProjects("bob"); len(projects) != 1 {
+		t.Errorf("bob has %d projects, want 1", len(projects))
+	}
+	if lists, _ := env.smartLists.ListSmartLists("bob"); len(lists) != 1 {
+		t.Errorf("bob has %d smart lists, want 1", len(lists))
+	}
+}
+
+func TestPurgeSkipsUserReactivatedAfterListing(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	env.project(t, "alice", "home", "")
+	if _, err := env.userSvc.DeactivateUser("alice"); err != nil {
+		t.Fatal(err)
+	}
+	listed, err := env.users.ListDeactivatedUsers(time.Now().Add(time.Second))
+	if err != nil || len(listed) != 1 {
+		t.Fatalf("ListDeactivatedUsers = %v, %v", listed, err)
+	}
+	if err := env.userSvc.ReactivateUser("alice", "secret1"); err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := env.userSvc.purgeUser(listed[0], env.taskSvc)
+	if err != nil || purged {
+		t.Fatalf("purgeUser = %v, %v; want false, nil", purged, err)
+	}
+	if user, err := env.users.GetUser("alice"); err != nil || !user.Active {
+		t.Errorf("alice = %+v, %v; want an active account", user, err)
+	}
+	if projects, _ := env.tasks.ListProjects("alice"); len(projects) != 1 {
+		t.Errorf("alice has %d projects, want 1", len(projects))
+	}
+}
+
+func TestReactivateRefusedOnceClaimed(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	if _, err := env.userSvc.DeactivateUser("alice"); err != nil {
+		t.Fatal(err)
+	}
+	user, _ := env.users.GetUser("alice")
+	if claimed, err := env.users.ClaimForPurge("alice", user.DeactivatedAt); err != nil || !claimed {
+		t.Fatalf("ClaimForPurge = %v, %v", claimed, err)
+	}
+	if err := env.userSvc.ReactivateUser("alice", "secret1"); !errors.Is(err, ErrGracePeriodExpired) {
+		t.Errorf("ReactivateUser = %v, want ErrGracePeriodExpired", err)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice")
+	env.register(t, "bob")
+
+	if _, _, err := env.userSvc.Login("alice", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Login with a wrong password = %v, want ErrInvalidCredentials", err)
+	}
+	token, expires, err := env.userSvc.Login("alice", "secret1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(expires); d < DefaultTokenTTL-time.Minute || d > DefaultTokenTTL {
+		t.Errorf("token expires in %v, want %v", d, DefaultTokenTTL)
+	}
+	if user, ok := env.userSvc.AuthenticateToken(token); !ok || user != "alice" {
+		t.Errorf("AuthenticateToken = %q, %v; want alice", user, ok)
+	}
+	other, _, err := env.userSvc.Login("alice", "secret1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobs, _, err := env.userSvc.Login("bob", "secret1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A token only works for the user it was issued to, and only as issued.
+	_, secret, _ := strings.Cut(token, ".")
+	for _, forged := range []string{token + "x", secret, "Ym9i." + secret, "alice:secret1", ""} {
+		if user, ok := env.userSvc.AuthenticateToken(forged); ok {
+			t.Errorf("AuthenticateToken(%q) = %q, want it rejected", forged, user)
+		}
+	}
+
+	if err := env.userSvc.Logout(token); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := env.userSvc.AuthenticateToken(token); ok {
+		t.Error("token accepted after Logout")
+	}
+	if err := env.userSvc.Logout(token); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("second Logout = %v, want ErrInvalidCredentials", err)
+	}
+	if _, ok := env.userSvc.AuthenticateToken(other); !ok {
+		t.Error("Logout revoked another token of the user")
+	}
+
+	if _, err := env.userSvc.DeactivateUser("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := env.userSvc.AuthenticateToken(other); ok {
+		t.Error("token accepted after deactivation")
+	}
+	if err := env.userSvc.ReactivateUser("alice", "secret1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := env.userSvc.AuthenticateToken(other); ok {
+		t.Error("token revoked by deactivation accepted after reactivation")
+	}
+	if user, ok := env.userSvc.AuthenticateToken(bobs); !ok || user != "bob" {
+		t.Errorf("bob's token = %q, %v after alice was deactivated", user, ok)
+	}
+}
+
+func mustProjects(t *testing.T, env *testEnv, user string) []models.Project {
+	t.Helper()
+	projects, err := env.tasks.ListProjects(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return projects
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"todolist/internal/models"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrValidation matches every *ValidationError through errors.Is.
+var ErrValidation = errors.New("validation failed")
+
+// FieldError is a single rejected input field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every field of a request that failed validation. It
+// is encoded as is in the body of 400 responses.
+type ValidationError struct {
+	Message string       `json:"error"`
+	Fields  []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + " " + f.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Message, strings.Join(parts, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Limits bounds the input the services accept.
+type Limits struct {
+	MaxNameLength     int // usernames and project names
+	MinPasswordLength int
+	MaxPasswordLength int
+	MaxContentLength  int // task contents and project descriptions
+	MaxIDLength       int
+	MinPriority       int
+	MaxPriority       int
+	MaxBatchSize      int
+	// Due dates must fall within [EarliestDue, LatestDue].
+	EarliestDue time.Time
+	LatestDue   time.Time
+}
+
+// DefaultLimits are used unless the server is configured otherwise.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxNameLength:     64,
+		MinPasswordLength: 6,
+		MaxPasswordLength: 128,
+		MaxContentLength:  1000,
+		MaxIDLength:       64,
+		MinPriority:       0,
+		MaxPriority:       10,
+		MaxBatchSize:      100,
+		EarliestDue:       time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		LatestDue:         DefaultTimestamp,
+	}
+}
+
+var (
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	idPattern       = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// rule checks one field and returns nil when it is valid.
+type rule func() *FieldError
+
+// validate runs every rule and collects all violations, reporting at most one
+// per field.
+func validate(rules ...rule) error {
+	var fields []FieldError
+	failed := make(map[string]bool)
+	for _, r := range rules {
+		if fe := r(); fe != nil && !failed[fe.Field] {
+			failed[fe.Field] = true
+			fields = append(fields, *fe)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Message: ErrValidation.Error(), Fields: fields}
+}
+
+func check(field string, ok bool, format string, args ...any) rule {
+	return func() *FieldError {
+		if ok {
+			return nil
+		}
+		return &FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+	}
+}
+
+func required(field, value string) rule {
+	return check(field, strings.TrimSpace(value) != "", "is required")
+}
+
+func maxLength(field, value string, max int) rule {
+	return check(field, utf8.RuneCountInString(value) <= max, "must be at most %d characters", max)
+}
+
+func printable(field, value string) rule {
+	return check(field, strings.IndexFunc(value, unicode.IsControl) < 0, "must not contain control characters")
+}
+
+func matches(field, value string, pattern *regexp.Regexp, what string) rule {
+	return check(field, value == "" || pattern.MatchString(value), "may only contain %s", what)
+}
+
+// Rule sets per input. Optional fields are only checked when present.
+
+func (l Limits) usernameRules(username string) []rule {
+	return []rule{
+		required("username", username),
+		maxLength("username", username, l.MaxNameLength),
+		matches("username", username, usernamePattern, "letters, digits, '.', '_' and '-'"),
+	}
+}
+
+func (l Limits) passwordRules(password string) []rule {
+	n := utf8.RuneCountInString(password)
+	return []rule{
+		required("password", password),
+		check("password", n >= l.MinPasswordLength, "must be at least %d characters", l.MinPasswordLength),
+		maxLength("password", password, l.MaxPasswordLength),
+	}
+}
+
+func (l Limits) projectRules(project string) []rule {
+	return l.projectNameRules("project", project)
+}
+
+func (l Limits) projectNameRules(field, name string) []rule {
+	return []rule{
+		required(field, name),
+		maxLength(field, name, l.MaxNameLength),
+		printable(field, name),
+		check(field, strings.TrimSpace(name) == name, "must not start or end with spaces"),
+	}
+}
+
+func (l Limits) taskIDRules(id string) []rule {
+	return []rule{
+		maxLength("id", id, l.MaxIDLength),
+		matches("id", id, idPattern, "letters, digits, '_' and '-'"),
+	}
+}
+
+func (l Limits) taskRules(task models.Task) []rule {
+	rules := []rule{
+		required("content", task.Content),
+		maxLength("content", task.Content, l.MaxContentLength),
+		check("priority", task.Priority >= l.MinPriority && task.Priority <= l.MaxPriority,
+			"must be between %d and %d", l.MinPriority, l.MaxPriority),
+	}
+	if task.Recurrence != nil {
+		rules = append(rules, check("recurrence", task.Recurrence.Valid(),
+			"must repeat every 1 or more days, weekdays, weeks, months or years"))
+	}
+	if !task.Due.IsZero() {
+		rules = append(rules, check("due", !task.Due.Before(l.EarliestDue) && !task.Due.After(l.LatestDue),
+			"must be between %s and %s", l.EarliestDue.Format(time.DateOnly), l.LatestDue.Format(time.DateOnly)))
+	}
+	return rules
+}
+
+// Validators used by the services. Names are checked in full when a project
+// or task is created; references to existing ones only need to be present, so
+// data created before a limit was tightened stays reachable. Features with
+// input of their own validate it next to their service code.
+
+func (l Limits) validateUser(username, password string) error {
+	return validate(append(l.usernameRules(username), l.passwordRules(password)...)...)
+}
+
+func (l Limits) validateNewProject(project string) error {
+	return validate(l.projectRules(project)...)
+}
+
+// validateTask checks a task about to be written; exists tells whether it
+// updates a task rather than creating one.
+func (l Limits) validateTask(project string, task models.Task, exists bool) error {
+	if exists {
+		return validate(append([]rule{required("project", project)}, l.taskRules(task)...)...)
+	}
+	rules := append(l.projectRules(project), l.taskIDRules(task.ID)...)
+	return validate(append(rules, l.taskRules(task)...)...)
+}
+
+func validateProjectRef(project string) error {
+	return validate(required("project", project))
+}
+
+func validateTaskRef(project, taskID string) error {
+	return validate(required("project", project), required("id", taskID))
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"todolist/internal/models"
+)
+
+func TestValidateCollectsOneErrorPerField(t *testing.T) {
+	err := DefaultLimits().validateUser("bad name!", "")
+	var verr *ValidationError
+	if !errors.As(err, &verr) || !errors.Is(err, ErrValidation) {
+		t.Fatalf("validateUser = %v, want a *ValidationError matching ErrValidation", err)
+	}
+	// The password is both missing and too short, but is reported once.
+	want := []FieldError{
+		{Field: "username", Message: "may only contain letters, digits, '.', '_' and '-'"},
+		{Field: "password", Message: "is required"},
+	}
+	if len(verr.Fields) != len(want) {
+		t.Fatalf("fields = %+v, want %+v", verr.Fields, want)
+	}
+	for i := range want {
+		if verr.Fields[i] != want[i] {
+			t.Errorf("field %d = %+v, want %+v", i, verr.Fields[i], want[i])
+		}
+	}
+	if verr.Message != "validation failed" {
+		t.Errorf("message = %q", verr.Message)
+	}
+	if got := err.Error(); got != "validation failed: username may only contain letters, digits, '.', '_' and '-'; password is required" {
+		t.Errorf("Error() = %q", got)
+	}
+
+	if err := DefaultLimits().validateUser("alice", "secret1"); err != nil {
+		t.Errorf("validateUser of valid input = %v", err)
+	}
+}
+
+func TestValidateLimits(t *testing.T) {
+	l := DefaultLimits()
+	due := func(t time.Time) models.Task { return models.Task{Content: "x", Due: t} }
+	tests := []struct {
+		name  string
+		err   error
+		field string // "" when valid
+	}{
+		{"name at limit", l.validateNewProject(strings.Repeat("é", l.MaxNameLength)), ""},
+		{"name over limit", l.validateNewProject(strings.Repeat("é", l.MaxNameLength+1)), "project"},
+		{"name with spaces around", l.validateNewProject(" home"), "project"},
+		{"name with a control character", l.validateNewProject("ho\nme"), "project"},
+		{"username at limit", l.validateUser(strings.Repeat("a", l.MaxNameLength), "secret1"), ""},
+		{"username over limit", l.validateUser(strings.Repeat("a", l.MaxNameLength+1), "secret1"), "username"},
+		{"password at minimum", l.validateUser("alice", strings.Repeat("p", l.MinPasswordLength)), ""},
+		{"password under minimum", l.validateUser("alice", strings.Repeat("p", l.MinPasswordLength-1)), "password"},
+		{"password at maximum", l.validateUser("alice", strings.Repeat("p", l.MaxPasswordLength)), ""},
+		{"password over maximum", l.validateUser("alice", strings.Repeat("p", l.MaxPasswordLength+1)), "password"},
+		{"content at limit", l.validateTask("home", models.Task{Content: strings.Repeat("c", l.MaxContentLength)}, true), ""},
+		{"content over limit", l.validateTask("home", models.Task{Content: strings.Repeat("c", l.MaxContentLength+1)}, true), "content"},
+		{"blank content", l.validateTask("home", models.Task{Content: "  "}, true), "content"},
+		{"lowest priority", l.validateTask("home", models.Task{Content: "x", Priority: l.MinPriority}, true), ""},
+		{"priority under minimum", l.validateTask("home", models.Task{Content: "x", Priority: l.MinPriority - 1}, true), "priority"},
+		{"highest priority", l.validateTask("home", models.Task{Content: "x", Priority: l.MaxPriority}, true), ""},
+		{"priority over maximum", l.validateTask("home", models.Task{Content: "x", Priority: l.MaxPriority + 1}, true), "priority"},
+		{"earliest due", l.validateTask("home", due(l.EarliestDue), true), ""},
+		{"due before earliest", l.validateTask("home", due(l.EarliestDue.Add(-time.Second)), true), "due"},
+		{"latest due", l.validateTask("home", due(l.LatestDue), true), ""},
+		{"due after latest", l.validateTask("home", due(l.LatestDue.Add(time.Second)), true), "due"},
+		{"id at limit", l.validateTask("home", models.Task{ID: strings.Repeat("i", l.MaxIDLength), Content: "x"}, false), ""},
+		{"id over limit", l.validateTask("home", models.Task{ID: strings.Repeat("i", l.MaxIDLength+1), Content: "x"}, false), "id"},
+		{"id with a slash", l.validateTask("home", models.Task{ID: "a/b", Content: "x"}, false), "id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verr *ValidationError
+			switch {
+			case tt.field == "" && tt.err != nil:
+				t.Errorf("err = %v, want none", tt.err)
+			case tt.field != "" && (!errors.As(tt.err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != tt.field):
+				t.Errorf("err = %v, want one error on %s", tt.err, tt.field)
+			}
+		})
+	}
+}
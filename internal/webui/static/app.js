@@ -0,0 +1,357 @@
+// Single-page frontend for the todo list server. It talks to the same JSON
+// and text endpoints as the CLI, authenticating every request with the
+// credentials kept in sessionStorage.
+(function () {
+  "use strict";
+
+  // The server stores tasks without a due date at this sentinel.
+  const NO_DUE = "2099-12-31T23:59:59Z";
+
+  const $ = (sel) => document.querySelector(sel);
+  const state = { auth: sessionStorage.getItem("auth"), user: sessionStorage.getItem("user"), project: null, tasks: [], settings: null };
+
+  class APIError extends Error {
+    constructor(status, message) {
+      super(message);
+      this.status = status;
+    }
+  }
+
+  async function api(method, path, params, body) {
+    const url = new URL(path, window.location.origin);
+    for (const [k, v] of Object.entries(params || {})) url.searchParams.set(k, v);
+    const headers = { "X-Requested-With": "XMLHttpRequest" };
+    if (state.auth) headers["Authorization"] = "Basic " + state.auth;
+    if (body !== undefined) headers["Content-Type"] = "application/json";
+    const resp = await fetch(url, { method, headers, body: body === undefined ? undefined : JSON.stringify(body) });
+    const text = await resp.text();
+    if (!resp.ok) {
+      if (resp.status === 401 && state.auth) logout();
+      throw new APIError(resp.status, errorMessage(resp, text));
+    }
+    return { text, undoToken: resp.headers.get("X-Undo-Token") };
+  }
+
+  // errorMessage formats an error body; validation failures are JSON listing
+  // the rejected fields.
+  function errorMessage(resp, text) {
+    if ((resp.headers.get("Content-Type") || "").startsWith("application/json")) {
+      try {
+        const body = JSON.parse(text);
+        return (body.fields || []).map((f) => `${f.field} ${f.message}`).join("; ") || body.error;
+      } catch (_) {
+        // fall through to the raw text
+      }
+    }
+    return text.trim() || resp.statusText;
+  }
+
+  // ---- notifications ------------------------------------------------------
+
+  let toastTimer;
+  function toast(message, { error = false, undoToken = null } = {}) {
+    const el = $("#toast");
+    el.textContent = message;
+    el.className = error ? "error" : "";
+    if (undoToken) {
+      const btn = document.createElement("button");
+      btn.textContent = "Undo";
+      btn.onclick = () => undo(undoToken);
+      el.appendChild(btn);
+    }
+    el.hidden = false;
+    clearTimeout(toastTimer);
+    toastTimer = setTimeout(() => (el.hidden = true), undoToken ? 10000 : 4000);
+  }
+
+  function fail(err) {
+    toast(err.message || String(err), { error: true });
+  }
+
+  async function undo(token) {
+    try {
+      const res = await api("POST", "/undo", { token });
+      toast(res.text);
+      await loadProjects();
+    } catch (err) {
+      fail(err);
+    }
+  }
+
+  // ---- session ------------------------------------------------------------
+
+  function showView() {
+    const loggedIn = !!state.auth;
+    $("#login-view").hidden = loggedIn;
+    $("#app-view").hidden = !loggedIn;
+    $("#logout").hidden = !loggedIn;
+    $("#whoami").textContent = loggedIn ? state.user : "";
+    if (loggedIn) loadSettings().then(loadProjects).catch(fail);
+  }
+
+  // Due dates are shown in the user's time zone and locale, as set with
+  // /updateSettings.
+  async function loadSettings() {
+    const { text } = await api("GET", "/settings");
+    state.settings = JSON.parse(text);
+  }
+
+  function formatDue(due) {
+    const s = state.settings;
+    if (!s) return new Date(due).toLocaleString();
+    return new Date(due).toLocaleString(s.locale, { timeZone: s.timeZone });
+  }
+
+  function logout() {
+    sessionStorage.clear();
+    state.auth = state.user = state.project = state.settings = null;
+    state.tasks = [];
+    showView();
+  }
+
+  async function login(username, password) {
+    state.auth = btoa(unescape(encodeURIComponent(username + ":" + password)));
+    try {
+      await api("GET", "/welcome");
+    } catch (err) {
+      state.auth = null;
+      throw err.status === 401 ? new Error("Invalid username or password") : err;
+    }
+    state.user = username;
+    sessionStorage.setItem("auth", state.auth);
+    sessionStorage.setItem("user", username);
+    showView();
+  }
+
+  $("#login-form").addEventListener("submit", (e) => {
+    e.preventDefault();
+    const f = e.target;
+    login(f.username.value, f.password.value).catch(fail);
+  });
+
+  $("#register").addEventListener("click", async () => {
+    const f = $("#login-form");
+    if (!f.reportValidity()) return;
+    try {
+      await api("POST", "/register", null, { username: f.username.value, password: f.password.value });
+      await login(f.username.value, f.password.value);
+      toast("Account created");
+    } catch (err) {
+      fail(err);
+    }
+  });
+
+  $("#logout").addEventListener("click", logout);
+
+  // ---- projects -----------------------------------------------------------
+
+  async function loadProjects() {
+    const res = await api("GET", "/printProjectTree");
+    // Sorted by name at every level; archived projects are left out.
+    const projects = [];
+    const flatten = (nodes, depth) => {
+      for (const node of nodes) {
+        projects.push({ ...node.project, depth, open: node.totalOpenTaskCount });
+        flatten(node.children, depth + 1);
+      }
+    };
+    flatten(JSON.parse(res.text || "[]") || [], 0);
+    if (!projects.some((p) => p.name === state.project)) state.project = projects[0]?.name || null;
+
+    const list = $("#projects");
+    list.replaceChildren();
+    for (const project of projects) {
+      const li = document.createElement("li");
+      li.textContent = project.name;
+      li.title = `${project.description}${project.description ? "\n" : ""}${project.open} open`;
+      li.style.marginLeft = `${project.depth}rem`;
+      if (project.color) li.style.borderLeft = `4px solid ${project.color}`;
+      li.classList.toggle("active", project.name === state.project);
+      li.onclick = () => selectProject(project.name);
+      list.appendChild(li);
+    }
+    await loadTasks();
+  }
+
+  async function selectProject(name) {
+    state.project = name;
+    for (const li of $("#projects").children) li.classList.toggle("active", li.textContent === name);
+    await loadTasks().catch(fail);
+  }
+
+  $("#project-form").addEventListener("submit", async (e) => {
+    e.preventDefault();
+    const name = e.target.name.value.trim();
+    if (!name) return;
+    try {
+      const res = await api("POST", "/createProject", { pjt: name });
+      e.target.reset();
+      state.project = name;
+      toast(`Project "${name}" created`, { undoToken: res.undoToken });
+      await loadProjects();
+    } catch (err) {
+      fail(err);
+    }
+  });
+
+  $("#remove-project").addEventListener("click", async () => {
+    if (!state.project || !confirm(`Move project "${state.project}" and all its tasks to the trash?`)) return;
+    try {
+      const res = await api("DELETE", "/removeProject", { pjt: state.project });
+      toast(res.text, { undoToken: res.undoToken });
+      state.project = null;
+      await loadProjects();
+    } catch (err) {
+      fail(err);
+    }
+  });
+
+  // ---- tasks --------------------------------------------------------------
+
+  async function loadTasks() {
+    $("#project-title").textContent = state.project || "Select a project";
+    $("#remove-project").hidden = $("#add-task").hidden = !state.project;
+    state.tasks = [];
+    if (state.project) {
+      const res = await api("GET", "/printTasks", { pjt: state.project });
+      state.tasks = JSON.parse(res.text || "[]") || [];
+    }
+    renderTasks();
+  }
+
+  function hasDue(task) {
+    return task.due && new Date(task.due).getTime() !== new Date(NO_DUE).getTime();
+  }
+
+  function renderTasks() {
+    const status = $("#filter-status").value;
+    const maxPriority = Number($("#filter-priority").value);
+    const text = $("#filter-text").value.trim().toLowerCase();
+    const sortBy = $("#sort").value;
+
+    const tasks = state.tasks
+      .filter((t) => status === "all" || t.completed === (status === "done"))
+      .filter((t) => t.priority <= maxPriority)
+      .filter((t) => !text || t.content.toLowerCase().includes(text))
+      .sort((a, b) =>
+        sortBy === "due"
+          ? new Date(a.due) - new Date(b.due) || a.priority - b.priority
+          : a.priority - b.priority || new Date(a.due) - new Date(b.due)
+      );
+
+    const body = $("#tasks");
+    body.replaceChildren();
+    const now = Date.now();
+    for (const task of tasks) {
+      const tr = document.createElement("tr");
+      tr.classList.toggle("done", task.completed);
+      tr.classList.toggle("overdue", !task.completed && hasDue(task) && new Date(task.due) < now);
+
+      const check = document.createElement("input");
+      check.type = "checkbox";
+      check.checked = task.completed;
+      check.disabled = task.completed;
+      check.title = "Mark complete";
+      check.onchange = () => completeTask(task);
+
+      const edit = document.createElement("button");
+      edit.textContent = "Edit";
+      edit.onclick = () => openTaskDialog(task);
+      const del = document.createElement("button");
+      del.textContent = "Delete";
+      del.className = "danger";
+      del.onclick = () => removeTask(task);
+
+      const cells = [
+        check,
+        String(task.priority),
+        hasDue(task) ? formatDue(task.due) : "",
+        task.content,
+        [edit, del],
+      ];
+      cells.forEach((c, i) => {
+        const td = document.createElement("td");
+        if (i === 2) td.className = "due";
+        if (i === 4) td.className = "actions";
+        if (typeof c === "string") td.textContent = c;
+        else td.append(...[].concat(c));
+        tr.appendChild(td);
+      });
+      body.appendChild(tr);
+    }
+    if (state.project && tasks.length === 0) {
+      const tr = document.createElement("tr");
+      const td = document.createElement("td");
+      td.colSpan = 5;
+      td.textContent = state.tasks.length ? "No tasks match the filters." : "No tasks yet.";
+      tr.appendChild(td);
+      body.appendChild(tr);
+    }
+  }
+
+  for (const id of ["#filter-status", "#filter-priority", "#filter-text", "#sort"]) {
+    $(id).addEventListener("input", renderTasks);
+  }
+
+  async function completeTask(task) {
+    try {
+      const res = await api("GET", "/completeTask", { pjt: state.project, key: task.id });
+      toast(res.text, { undoToken: res.undoToken });
+      await loadTasks();
+    } catch (err) {
+      fail(err);
+    }
+  }
+
+  async function removeTask(task) {
+    try {
+      const res = await api("DELETE", "/removeTask", { pjt: state.project, key: task.id });
+      toast(res.text, { undoToken: res.undoToken });
+      await loadTasks();
+    } catch (err) {
+      fail(err);
+    }
+  }
+
+  // datetime-local inputs work in local time without a zone suffix.
+  function toLocalInput(iso) {
+    const d = new Date(iso);
+    const pad = (n) => String(n).padStart(2, "0");
+    return `${d.getFullYear()}-${pad(d.getMonth() + 1)}-${pad(d.getDate())}T${pad(d.getHours())}:${pad(d.getMinutes())}`;
+  }
+
+  function openTaskDialog(task) {
+    const f = $("#task-form");
+    f.reset();
+    $("#task-dialog-title").textContent = task ? "Edit task" : "New task";
+    f.id.value = task ? task.id : "";
+    f.content.value = task ? task.content : "";
+    f.priority.value = task ? task.priority : 0;
+    f.due.value = task && hasDue(task) ? toLocalInput(task.due) : "";
+    f.completed.checked = task ? task.completed : false;
+    $("#task-dialog").showModal();
+  }
+
+  $("#add-task").addEventListener("click", () => openTaskDialog(null));
+
+  $("#task-dialog").addEventListener("close", async () => {
+    if ($("#task-dialog").returnValue !== "save") return;
+    const f = $("#task-form");
+    const task = {
+      id: f.id.value,
+      content: f.content.value,
+      priority: Number(f.priority.value),
+      due: f.due.value ? new Date(f.due.value).toISOString() : NO_DUE,
+      completed: f.completed.checked,
+    };
+    try {
+      const res = await api("POST", "/writeTask", { pjt: state.project }, task);
+      toast(task.id ? "Task updated" : "Task added", { undoToken: res.undoToken });
+      await loadTasks();
+    } catch (err) {
+      fail(err);
+    }
+  });
+
+  showView();
+})();
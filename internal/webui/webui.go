@@ -0,0 +1,64 @@
+// Package webui serves the single-page web frontend embedded in the binary.
+package webui
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the frontend under prefix (e.g. "/app"). index.html is
+// always revalidated so a new deployment is picked up immediately; the other
+// assets may be cached for an hour and are revalidated by ETag.
+func Handler(prefix string) http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err) // the embedded directory is fixed at build time
+	}
+	etags := make(map[string]string)
+	fs.WalkDir(files, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := fs.ReadFile(files, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(b)
+		etags["/"+name] = `"` + hex.EncodeToString(sum[:8]) + `"`
+		return nil
+	})
+	fileServer := http.FileServer(http.FS(files))
+
+	return http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)
+		if name == "/" {
+			name = "/index.html"
+		}
+		etag, ok := etags[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasSuffix(name, ".html") {
+			w.Header().Set("Cache-Control", "no-cache")
+		} else {
+			w.Header().Set("Cache-Control", "public, max-age=3600")
+		}
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		// FileServer redirects explicit index.html requests to the directory.
+		r.URL.Path = strings.TrimSuffix(name, "index.html")
+		fileServer.ServeHTTP(w, r)
+	}))
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Today returns the open tasks overdue or due today, with days counted in
+// IANA time zone tz, the user's own when empty.
+func (c *Client) Today(ctx context.Context, tz string, archived bool) (Agenda, error) {
+	return c.agenda(ctx, "/today", url.Values{}, tz, archived)
+}
+
+// Upcoming returns the open tasks overdue or due in the next days days, today
+// included; days is the server default when zero.
+func (c *Client) Upcoming(ctx context.Context, days int, tz string, archived bool) (Agenda, error) {
+	query := url.Values{}
+	if days > 0 {
+		query.Set("days", strconv.Itoa(days))
+	}
+	return c.agenda(ctx, "/upcoming", query, tz, archived)
+}
+
+func (c *Client) agenda(ctx context.Context, path string, query url.Values, tz string, archived bool) (Agenda, error) {
+	if tz != "" {
+		query.Set("tz", tz)
+	}
+	if archived {
+		query.Set("archived", "true")
+	}
+	res, err := c.do(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return Agenda{}, err
+	}
+	var agenda Agenda
+	if err := json.Unmarshal(res.body, &agenda); err != nil {
+		return Agenda{}, err
+	}
+	return agenda, nil
+}
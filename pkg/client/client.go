@@ -0,0 +1,181 @@
+// Package client is a typed Go client for the todo list HTTP API.
+//
+//	c := client.New("http://localhost:7071", client.WithBasicAuth("test", "test123"))
+//	task, undo, err := c.WriteTask(ctx, "home", client.Task{Content: "Buy groceries"})
+//
+// Methods mirror services.TaskService and services.UserService. Failed
+// requests return an *Error that matches the sentinel errors of this package
+// with errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"todolist/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type (
+	Task           = models.Task
+	Project        = models.Project
+	ProjectUpdate  = models.ProjectUpdate
+	ProjectNode    = models.ProjectNode
+	Template       = models.Template
+	SmartList      = models.SmartList
+	ProjectTask    = models.ProjectTask
+	Agenda         = models.Agenda
+	AgendaDay      = models.AgendaDay
+	SearchHit      = models.SearchHit
+	TextRange      = models.TextRange
+	TrashItem      = models.TrashItem
+	TaskChange     = models.TaskChange
+	FieldChange    = models.FieldChange
+	QuickAdd       = models.QuickAdd
+	QuickPart      = models.QuickPart
+	QuickAddResult = models.QuickAddResult
+	Recurrence     = models.Recurrence
+	UserSettings   = models.UserSettings
+	SettingsUpdate = models.SettingsUpdate
+)
+
+// Auth adds credentials to outgoing requests.
+type Auth interface {
+	apply(req *http.Request)
+}
+
+type basicAuth struct{ username, password string }
+
+func (a basicAuth) apply(req *http.Request) { req.SetBasicAuth(a.username, a.password) }
+
+type tokenAuth struct{ token string }
+
+func (a tokenAuth) apply(req *http.Request) { req.Header.Set("Authorization", "Bearer "+a.token) }
+
+// BasicAuth authenticates with a username and password.
+func BasicAuth(username, password string) Auth { return basicAuth{username, password} }
+
+// TokenAuth authenticates with a bearer token issued by Login.
+func TokenAuth(token string) Auth { return tokenAuth{token} }
+
+type Client struct {
+	baseURL    string
+	auth       Auth
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+type Option func(*Client)
+
+// WithAuth sets the credentials sent with every request.
+func WithAuth(auth Auth) Option { return func(c *Client) { c.auth = auth } }
+
+// WithBasicAuth is shorthand for WithAuth(BasicAuth(username, password)).
+func WithBasicAuth(username, password string) Option {
+	return WithAuth(BasicAuth(username, password))
+}
+
+// WithToken is shorthand for WithAuth(TokenAuth(token)).
+func WithToken(token string) Option { return WithAuth(TokenAuth(token)) }
+
+// WithHTTPClient replaces the default http.Client (30s timeout).
+func WithHTTPClient(hc *http.Client) Option { return func(c *Client) { c.httpClient = hc } }
+
+// WithRetry retries requests that fail with a 5xx status or a transport
+// error up to maxRetries times, doubling the delay from backoff each time.
+// Writes are sent with an Idempotency-Key that is reused across retries, so
+// the server applies each write at most once.
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) { c.maxRetries, c.backoff = maxRetries, backoff }
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		maxRetries: 2,
+		backoff:    200 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// result is a successful response.
+type result struct {
+	body      []byte
+	undoToken string
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body any) (*result, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var idempotencyKey string
+	if method != http.MethodGet {
+		idempotencyKey = uuid.New().String()
+	}
+	delay := c.backoff
+	for attempt := 0; ; attempt++ {
+		res, err := c.attempt(ctx, method, u, payload, idempotencyKey)
+		if err == nil || attempt >= c.maxRetries || !retryable(err) {
+			return res, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method, u string, payload []byte, idempotencyKey string) (*result, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	if c.auth != nil {
+		c.auth.apply(req)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, newError(resp, b)
+	}
+	return &result{body: b, undoToken: resp.Header.Get("X-Undo-Token")}, nil
+}
@@ -0,0 +1,363 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"todolist/internal/server/servertest"
+)
+
+// newUser registers an account on the server at url and returns a client
+// logged in with Basic credentials.
+func newUser(t *testing.T, url string, opts ...Option) *Client {
+	t.Helper()
+	ctx := context.Background()
+	if err := New(url).RegisterUser(ctx, "alice", "secret1"); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+	return New(url, append([]Option{WithBasicAuth("alice", "secret1")}, opts...)...)
+}
+
+func TestTasksRoundTrip(t *testing.T) {
+	srv := servertest.New(t, nil)
+	c := newUser(t, srv.URL)
+	ctx := context.Background()
+
+	project, undo, err := c.CreateProject(ctx, "home", "")
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	if project.Name != "home" || project.ID == "" || undo == "" {
+		t.Fatalf("CreateProject = %+v, undo %q; want project home with an ID and an undo token", project, undo)
+	}
+	again, undo, err := c.CreateProject(ctx, "home", "")
+	if err != nil || again.ID != project.ID || undo != "" {
+		t.Fatalf("CreateProject of an existing name = %+v, %q, %v; want %s without undo token", again, undo, err, project.ID)
+	}
+
+	tasks, err := c.GetTasks(ctx, "home")
+	if err != nil || tasks == nil || len(tasks) != 0 {
+		t.Fatalf("GetTasks of an empty project = %v, %v; want an empty list", tasks, err)
+	}
+
+	due := time.Date(2030, 5, 9, 15, 4, 5, 0, time.UTC)
+	before := time.Now()
+	written, undo, err := c.WriteTask(ctx, "home", Task{Content: "Buy groceries", Priority: 2, Due: due})
+	if err != nil {
+		t.Fatalf("WriteTask: %v", err)
+	}
+	if written.ID == "" || written.Content != "Buy groceries" || written.Priority != 2 || !written.Due.Equal(due) || undo == "" {
+		t.Fatalf("WriteTask = %+v, undo %q", written, undo)
+	}
+	if written.UpdatedTime.Before(before.Add(-time.Second)) {
+		t.Errorf("UpdatedTime = %v, want the time of the write", written.UpdatedTime)
+	}
+
+	tasks, err = c.GetTasks(ctx, project.ID)
+	if err != nil || len(tasks) != 1 || tasks[0].ID != written.ID {
+		t.Fatalf("GetTasks = %v, %v; want [%s]", tasks, err, written.ID)
+	}
+
+	if _, err := c.RemoveTask(ctx, "home", written.ID); err != nil {
+		t.Fatalf("RemoveTask: %v", err)
+	}
+	purged, err := c.EmptyTrash(ctx)
+	if err != nil || purged != 1 {
+		t.Fatalf("EmptyTrash = %d, %v; want 1", purged, err)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	srv := servertest.New(t, nil)
+	newUser(t, srv.URL)
+	ctx := context.Background()
+	token, expires, err := New(srv.URL, WithBasicAuth("alice", "secret1")).Login(ctx)
+	if err != nil || token == "" || !expires.After(time.Now()) {
+		t.Fatalf("Login = %q, %v, %v", token, expires, err)
+	}
+	if _, _, err := New(srv.URL, WithBasicAuth("alice", "wrong")).Login(ctx); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Login with a wrong password: err = %v, want ErrUnauthorized", err)
+	}
+
+	tests := []struct {
+		name string
+		auth Option
+		ok   bool
+	}{
+		{"basic", WithBasicAuth("alice", "secret1"), true},
+		{"token", WithToken(token), true},
+		{"wrong password", WithBasicAuth("alice", "wrong"), false},
+		{"wrong token", WithToken(token[:len(token)-2] + "xx"), false},
+		{"credentials as token", WithToken(base64.StdEncoding.EncodeToString([]byte("alice:secret1"))), false},
+		{"malformed token", WithToken("not base64"), false},
+		{"none", WithAuth(nil), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(srv.URL, tt.auth)
+			ok, err := c.AuthenticateUser(ctx)
+			if err != nil || ok != tt.ok {
+				t.Fatalf("AuthenticateUser = %v, %v; want %v", ok, err, tt.ok)
+			}
+			_, err = c.GetProjects(ctx, false)
+			if tt.ok && err != nil {
+				t.Fatalf("GetProjects: %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrUnauthorized) {
+				t.Fatalf("GetProjects: err = %v, want ErrUnauthorized", err)
+			}
+		})
+	}
+
+	c := New(srv.URL, WithToken(token))
+	if err := c.Logout(ctx); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if _, err := c.GetProjects(ctx, false); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("GetProjects after Logout: err = %v, want ErrUnauthorized", err)
+	}
+	if err := c.Logout(ctx); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("second Logout: err = %v, want ErrUnauthorized", err)
+	}
+}
+
+// flaky fails the first n requests to path with 503, recording the
+// Idempotency-Key of every request to it.
+type flaky struct {
+	path string
+	n    int
+
+	mu   sync.Mutex
+	keys []string
+}
+
+func (f *flaky) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != f.path {
+			next.ServeHTTP(w, r)
+			return
+		}
+		f.mu.Lock()
+		f.keys = append(f.keys, r.Header.Get("Idempotency-Key"))
+		fail := len(f.keys) <= f.n
+		f.mu.Unlock()
+		if fail {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestRetryOnServerError(t *testing.T) {
+	f := &flaky{path: "/writeTask", n: 2}
+	srv := servertest.New(t, f.wrap)
+	c := newUser(t, srv.URL, WithRetry(2, time.Millisecond))
+	ctx := context.Background()
+	if _, _, err := c.CreateProject(ctx, "home", ""); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+
+	written, _, err := c.WriteTask(ctx, "home", Task{Content: "milk"})
+	if err != nil {
+		t.Fatalf("WriteTask after two 503s: %v", err)
+	}
+	if len(f.keys) != 3 {
+		t.Fatalf("%d attempts, want 3", len(f.keys))
+	}
+	for _, key := range f.keys {
+		if key == "" || key != f.keys[0] {
+			t.Fatalf("Idempotency-Keys %q, want one key reused by every attempt", f.keys)
+		}
+	}
+	tasks, err := c.GetTasks(ctx, "home")
+	if err != nil || len(tasks) != 1 || tasks[0].ID != written.ID {
+		t.Fatalf("GetTasks = %v, %v; want only %s", tasks, err, written.ID)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	f := &flaky{path: "/writeTask", n: 10}
+	srv := servertest.New(t, f.wrap)
+	c := newUser(t, srv.URL, WithRetry(2, time.Millisecond))
+	ctx := context.Background()
+	c.CreateProject(ctx, "home", "")
+
+	_, _, err := c.WriteTask(ctx, "home", Task{Content: "milk"})
+	if !errors.Is(err, ErrServer) {
+		t.Fatalf("WriteTask: err = %v, want ErrServer", err)
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Message != "try again" {
+		t.Fatalf("WriteTask: err = %#v, want the 503 response", err)
+	}
+	if len(f.keys) != 3 {
+		t.Fatalf("%d attempts, want 3", len(f.keys))
+	}
+}
+
+func TestNoRetryOnClientError(t *testing.T) {
+	var requests atomic.Int32
+	srv := servertest.New(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/completeTask" {
+				requests.Add(1)
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	c := newUser(t, srv.URL, WithRetry(3, time.Millisecond))
+	ctx := context.Background()
+	c.CreateProject(ctx, "home", "")
+
+	if _, err := c.MarkTaskComplete(ctx, "home", "task_missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("MarkTaskComplete: err = %v, want ErrNotFound", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("%d requests, want 1", n)
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	t.Run("during backoff", func(t *testing.T) {
+		f := &flaky{path: "/printProjects", n: 10}
+		srv := servertest.New(t, f.wrap)
+		c := newUser(t, srv.URL, WithRetry(5, time.Hour))
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := c.GetProjects(ctx, false)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("GetProjects: err = %v, want context.Canceled", err)
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("GetProjects kept waiting after cancellation")
+		}
+		if len(f.keys) != 1 {
+			t.Fatalf("%d attempts, want 1", len(f.keys))
+		}
+	})
+	t.Run("during the request", func(t *testing.T) {
+		release := make(chan struct{})
+		srv := servertest.New(t, func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/printProjects" {
+					select {
+					case <-release:
+					case <-r.Context().Done():
+					}
+				}
+				next.ServeHTTP(w, r)
+			})
+		})
+		defer close(release)
+		c := newUser(t, srv.URL, WithRetry(3, time.Millisecond))
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		if _, err := c.GetProjects(ctx, false); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("GetProjects: err = %v, want context.DeadlineExceeded", err)
+		}
+	})
+}
+
+func TestErrorMapping(t *testing.T) {
+	srv := servertest.New(t, nil)
+	c := newUser(t, srv.URL)
+	ctx := context.Background()
+	c.CreateProject(ctx, "home", "")
+	c.CreateProject(ctx, "old", "")
+	c.RemoveProject(ctx, "old", "")
+
+	tests := []struct {
+		name   string
+		call   func() error
+		want   error
+		status int
+		field  string
+	}{
+		{"validation", func() error {
+			_, _, err := c.WriteTask(ctx, "home", Task{Content: "", Priority: 11})
+			return err
+		}, ErrBadRequest, http.StatusBadRequest, "content"},
+		{"missing task", func() error {
+			_, err := c.MarkTaskComplete(ctx, "home", "task_missing")
+			return err
+		}, ErrNotFound, http.StatusNotFound, ""},
+		{"trashed name", func() error {
+			_, _, err := c.CreateProject(ctx, "old", "")
+			return err
+		}, ErrConflict, http.StatusConflict, ""},
+		{"unknown undo token", func() error {
+			return c.Undo(ctx, "chg_missing")
+		}, ErrNotFound, http.StatusNotFound, ""},
+		{"bad credentials", func() error {
+			_, err := New(srv.URL, WithBasicAuth("alice", "wrong")).GetTasks(ctx, "home")
+			return err
+		}, ErrUnauthorized, http.StatusUnauthorized, ""},
+	}
+	sentinels := []error{ErrBadRequest, ErrUnauthorized, ErrNotFound, ErrConflict, ErrGone, ErrServer}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want an *Error", err)
+			}
+			if apiErr.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, tt.status)
+			}
+			if apiErr.RequestID == "" {
+				t.Error("RequestID is empty")
+			}
+			for _, sentinel := range sentinels {
+				if got := errors.Is(err, sentinel); got != (sentinel == tt.want) {
+					t.Errorf("errors.Is(err, %v) = %v", sentinel, got)
+				}
+			}
+			if tt.field != "" && !hasField(apiErr.Fields, tt.field) {
+				t.Errorf("Fields = %v, want %s among them", apiErr.Fields, tt.field)
+			}
+		})
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusBadRequest, ErrBadRequest},
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusConflict, ErrConflict},
+		{http.StatusGone, ErrGone},
+		{http.StatusInternalServerError, ErrServer},
+		{http.StatusBadGateway, ErrServer},
+		{http.StatusTeapot, nil},
+	}
+	sentinels := []error{ErrBadRequest, ErrUnauthorized, ErrNotFound, ErrConflict, ErrGone, ErrServer}
+	for _, tt := range tests {
+		err := &Error{StatusCode: tt.status}
+		for _, sentinel := range sentinels {
+			if got := errors.Is(err, sentinel); got != (sentinel == tt.want) {
+				t.Errorf("HTTP %d: errors.Is(err, %v) = %v", tt.status, sentinel, got)
+			}
+		}
+	}
+}
+
+func hasField(fields []FieldError, name string) bool {
+	for _, f := range fields {
+		if f.Field == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors matched by *Error through errors.Is.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrGone         = errors.New("gone")
+	ErrServer       = errors.New("server error")
+)
+
+// Error is a non-2xx response from the server. Message is the server's
+// plain-text explanation. Fields lists the rejected fields when the request
+// failed validation.
+type Error struct {
+	StatusCode int
+	Message    string
+	Fields     []FieldError
+	RequestID  string
+}
+
+// FieldError is an input field the server rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func newError(resp *http.Response, body []byte) *Error {
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		var v struct {
+			Error  string       `json:"error"`
+			Fields []FieldError `json:"fields"`
+		}
+		if err := json.Unmarshal(body, &v); err == nil {
+			e.Message, e.Fields = v.Error, v.Fields
+			for _, f := range v.Fields {
+				e.Message += fmt.Sprintf("; %s %s", f.Field, f.Message)
+			}
+		}
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("todo api: %s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrGone:
+		return e.StatusCode == http.StatusGone
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// retryable reports whether a failed attempt may succeed when repeated.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	return true
+}
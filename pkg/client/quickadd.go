@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// ParseQuickAdd returns what the server recognizes in quick-add text, such
+// as "Pay rent tomorrow 9am p1 #home", without creating anything.
+func (c *Client) ParseQuickAdd(ctx context.Context, text string) (QuickAdd, error) {
+	res, err := c.do(ctx, http.MethodGet, "/parseQuickAdd", url.Values{"q": {text}}, nil)
+	if err != nil {
+		return QuickAdd{}, err
+	}
+	var parsed QuickAdd
+	if err := json.Unmarshal(res.body, &parsed); err != nil {
+		return QuickAdd{}, err
+	}
+	return parsed, nil
+}
+
+// QuickAdd creates the task parsed from text, in the #project it names or
+// else in project, and returns it with an undo token.
+func (c *Client) QuickAdd(ctx context.Context, text, project string) (QuickAddResult, string, error) {
+	query := url.Values{"q": {text}}
+	if project != "" {
+		query.Set("pjt", project)
+	}
+	res, err := c.do(ctx, http.MethodPost, "/quickAdd", query, nil)
+	if err != nil {
+		return QuickAddResult{}, "", err
+	}
+	var result QuickAddResult
+	if err := json.Unmarshal(res.body, &result); err != nil {
+		return QuickAddResult{}, "", err
+	}
+	return result, res.undoToken, nil
+}
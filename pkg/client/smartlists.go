@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// FilterTasks returns the tasks of all projects matching the filter query,
+// such as "priority <= 2 and due < +3d and not completed". A malformed query
+// fails with ErrBadRequest.
+func (c *Client) FilterTasks(ctx context.Context, query string) ([]ProjectTask, error) {
+	return c.projectTasks(ctx, "/filter", url.Values{"q": {query}})
+}
+
+// SaveSmartList saves the filter query as smart list name, replacing the
+// query of the smart list with that name if there is one.
+func (c *Client) SaveSmartList(ctx context.Context, name, query string) (SmartList, error) {
+	res, err := c.do(ctx, http.MethodPost, "/saveSmartList", url.Values{"name": {name}, "q": {query}}, nil)
+	if err != nil {
+		return SmartList{}, err
+	}
+	var list SmartList
+	if err := json.Unmarshal(res.body, &list); err != nil {
+		return SmartList{}, err
+	}
+	return list, nil
+}
+
+func (c *Client) GetSmartLists(ctx context.Context) ([]SmartList, error) {
+	res, err := c.do(ctx, http.MethodGet, "/printSmartLists", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	lists := []SmartList{}
+	if err := json.Unmarshal(res.body, &lists); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// RunSmartList returns the tasks currently matching the smart list.
+func (c *Client) RunSmartList(ctx context.Context, list string) ([]ProjectTask, error) {
+	return c.projectTasks(ctx, "/runSmartList", url.Values{"list": {list}})
+}
+
+func (c *Client) RemoveSmartList(ctx context.Context, list string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/removeSmartList", url.Values{"list": {list}}, nil)
+	return err
+}
+
+func (c *Client) projectTasks(ctx context.Context, path string, query url.Values) ([]ProjectTask, error) {
+	res, err := c.do(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	tasks := []ProjectTask{}
+	if err := json.Unmarshal(res.body, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
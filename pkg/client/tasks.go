@@ -0,0 +1,299 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Mutating methods return the undo token of the change, for use with Undo.
+
+// CreateProject creates a project, nested in parent when that is not empty.
+// When a project with that name exists, it is returned unchanged with an
+// empty undo token.
+func (c *Client) CreateProject(ctx context.Context, project, parent string) (Project, string, error) {
+	query := url.Values{"pjt": {project}}
+	if parent != "" {
+		query.Set("parent", parent)
+	}
+	res, err := c.do(ctx, http.MethodPost, "/createProject", query, nil)
+	if err != nil {
+		return Project{}, "", err
+	}
+	var created Project
+	if err := json.Unmarshal(res.body, &created); err != nil {
+		return Project{}, "", err
+	}
+	return created, res.undoToken, nil
+}
+
+// WriteTask creates the task, or replaces it when task.ID already exists in
+// the project, and returns it as stored, with the ID assigned by the server.
+func (c *Client) WriteTask(ctx context.Context, project string, task Task) (Task, string, error) {
+	res, err := c.do(ctx, http.MethodPost, "/writeTask", url.Values{"pjt": {project}}, task)
+	if err != nil {
+		return Task{}, "", err
+	}
+	var written Task
+	if err := json.Unmarshal(res.body, &written); err != nil {
+		return Task{}, "", err
+	}
+	return written, res.undoToken, nil
+}
+
+// PatchTask changes only the fields set in patch, a JSON Merge Patch such as
+// map[string]any{"priority": 1}; a nil value clears the due date. The undo
+// token is empty when the patch changed nothing.
+func (c *Client) PatchTask(ctx context.Context, project, taskID string, patch map[string]any) (Task, string, error) {
+	res, err := c.do(ctx, http.MethodPatch, "/patchTask", url.Values{"pjt": {project}, "key": {taskID}}, patch)
+	if err != nil {
+		return Task{}, "", err
+	}
+	var task Task
+	if err := json.Unmarshal(res.body, &task); err != nil {
+		return Task{}, "", err
+	}
+	return task, res.undoToken, nil
+}
+
+// MoveTask moves a task to another project, keeping its ID. It fails with
+// ErrConflict when the target already has a task with that ID.
+func (c *Client) MoveTask(ctx context.Context, from, to, taskID string) (Task, string, error) {
+	return c.transfer(ctx, "/moveTask", from, to, taskID)
+}
+
+// CopyTask copies a task into a project under a new ID.
+func (c *Client) CopyTask(ctx context.Context, from, to, taskID string) (Task, string, error) {
+	return c.transfer(ctx, "/copyTask", from, to, taskID)
+}
+
+func (c *Client) transfer(ctx context.Context, path, from, to, taskID string) (Task, string, error) {
+	res, err := c.do(ctx, http.MethodPost, path, url.Values{"pjt": {from}, "key": {taskID}, "to": {to}}, nil)
+	if err != nil {
+		return Task{}, "", err
+	}
+	var task Task
+	if err := json.Unmarshal(res.body, &task); err != nil {
+		return Task{}, "", err
+	}
+	return task, res.undoToken, nil
+}
+
+func (c *Client) MarkTaskComplete(ctx context.Context, project, taskID string) (string, error) {
+	res, err := c.do(ctx, http.MethodGet, "/completeTask", url.Values{"pjt": {project}, "key": {taskID}}, nil)
+	if err != nil {
+		return "", err
+	}
+	return res.undoToken, nil
+}
+
+func (c *Client) GetTasks(ctx context.Context, project string) ([]Task, error) {
+	res, err := c.do(ctx, http.MethodGet, "/printTasks", url.Values{"pjt": {project}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	tasks := []Task{}
+	if err := json.Unmarshal(res.body, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetProjects returns the user's projects sorted by name, archived ones
+// only when archived is set.
+func (c *Client) GetProjects(ctx context.Context, archived bool) ([]Project, error) {
+	query := url.Values{}
+	if archived {
+		query.Set("archived", "true")
+	}
+	res, err := c.do(ctx, http.MethodGet, "/printProjects", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	projects := []Project{}
+	if err := json.Unmarshal(res.body, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// UpdateProject changes the fields set in update of the project with the
+// given name or ID. It fails with ErrConflict when renaming to a name that
+// is taken. The undo token is empty when the update changed nothing.
+func (c *Client) UpdateProject(ctx context.Context, project string, update ProjectUpdate) (Project, string, error) {
+	res, err := c.do(ctx, http.MethodPost, "/updateProject", url.Values{"pjt": {project}}, update)
+	if err != nil {
+		return Project{}, "", err
+	}
+	var updated Project
+	if err := json.Unmarshal(res.body, &updated); err != nil {
+		return Project{}, "", err
+	}
+	return updated, res.undoToken, nil
+}
+
+// MoveProject nests the project in parent, or moves it to the top level when
+// parent is empty. It fails with ErrBadRequest when parent is the project itself
+// or one of its sub-projects.
+func (c *Client) MoveProject(ctx context.Context, project, parent string) (Project, string, error) {
+	res, err := c.do(ctx, http.MethodPost, "/moveProject", url.Values{"pjt": {project}, "parent": {parent}}, nil)
+	if err != nil {
+		return Project{}, "", err
+	}
+	var moved Project
+	if err := json.Unmarshal(res.body, &moved); err != nil {
+		return Project{}, "", err
+	}
+	return moved, res.undoToken, nil
+}
+
+// GetProjectTree returns the projects as a tree with task counts, only the
+// tree below root when that is not empty.
+func (c *Client) GetProjectTree(ctx context.Context, root string, archived bool) ([]ProjectNode, error) {
+	query := url.Values{}
+	if root != "" {
+		query.Set("pjt", root)
+	}
+	if archived {
+		query.Set("archived", "true")
+	}
+	res, err := c.do(ctx, http.MethodGet, "/printProjectTree", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	tree := []ProjectNode{}
+	if err := json.Unmarshal(res.body, &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// RemoveProject moves the project and its tasks to the trash. children says
+// what happens to its sub-projects: "trash" (the default when empty) or
+// "lift" to move them up to the project's parent.
+func (c *Client) RemoveProject(ctx context.Context, project, children string) (string, error) {
+	query := url.Values{"pjt": {project}}
+	if children != "" {
+		query.Set("children", children)
+	}
+	res, err := c.do(ctx, http.MethodDelete, "/removeProject", query, nil)
+	if err != nil {
+		return "", err
+	}
+	return res.undoToken, nil
+}
+
+// RemoveTask moves the task to the trash.
+func (c *Client) RemoveTask(ctx context.Context, project, taskID string) (string, error) {
+	res, err := c.do(ctx, http.MethodDelete, "/removeTask", url.Values{"pjt": {project}, "key": {taskID}}, nil)
+	if err != nil {
+		return "", err
+	}
+	return res.undoToken, nil
+}
+
+// Search returns the tasks whose content contains every word of query, best
+// matches first. project limits the search when not empty; limit is the
+// server default when zero.
+func (c *Client) Search(ctx context.Context, query, project string, limit int) ([]SearchHit, error) {
+	params := url.Values{"q": {query}}
+	if project != "" {
+		params.Set("pjt", project)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	res, err := c.do(ctx, http.MethodGet, "/search", params, nil)
+	if err != nil {
+		return nil, err
+	}
+	hits := []SearchHit{}
+	if err := json.Unmarshal(res.body, &hits); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+func (c *Client) GetTrash(ctx context.Context) ([]TrashItem, error) {
+	res, err := c.do(ctx, http.MethodGet, "/printTrash", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	items := []TrashItem{}
+	if err := json.Unmarshal(res.body, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (c *Client) RestoreTask(ctx context.Context, project, taskID string) (string, error) {
+	res, err := c.do(ctx, http.MethodPost, "/restoreTrash", url.Values{"pjt": {project}, "key": {taskID}}, nil)
+	if err != nil {
+		return "", err
+	}
+	return res.undoToken, nil
+}
+
+func (c *Client) RestoreProject(ctx context.Context, project string) (string, error) {
+	res, err := c.do(ctx, http.MethodPost, "/restoreTrash", url.Values{"pjt": {project}}, nil)
+	if err != nil {
+		return "", err
+	}
+	return res.undoToken, nil
+}
+
+// PurgeTask permanently deletes a trashed task.
+func (c *Client) PurgeTask(ctx context.Context, project, taskID string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/purgeTrash", url.Values{"pjt": {project}, "key": {taskID}}, nil)
+	return err
+}
+
+// PurgeProject permanently deletes a trashed project.
+func (c *Client) PurgeProject(ctx context.Context, project string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/purgeTrash", url.Values{"pjt": {project}}, nil)
+	return err
+}
+
+// EmptyTrash permanently deletes everything in the trash and returns the
+// number of purged items.
+func (c *Client) EmptyTrash(ctx context.Context) (int, error) {
+	res, err := c.do(ctx, http.MethodDelete, "/purgeTrash", nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	var v struct {
+		Purged int `json:"purged"`
+	}
+	if err := json.Unmarshal(res.body, &v); err != nil {
+		return 0, err
+	}
+	return v.Purged, nil
+}
+
+func (c *Client) GetTaskHistory(ctx context.Context, project, taskID string) ([]TaskChange, error) {
+	return c.history(ctx, url.Values{"pjt": {project}, "key": {taskID}})
+}
+
+func (c *Client) GetProjectHistory(ctx context.Context, project string) ([]TaskChange, error) {
+	return c.history(ctx, url.Values{"pjt": {project}})
+}
+
+func (c *Client) history(ctx context.Context, query url.Values) ([]TaskChange, error) {
+	res, err := c.do(ctx, http.MethodGet, "/printHistory", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	changes := []TaskChange{}
+	if err := json.Unmarshal(res.body, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Undo reverts the mutation that returned token.
+func (c *Client) Undo(ctx context.Context, token string) error {
+	_, err := c.do(ctx, http.MethodPost, "/undo", url.Values{"token": {token}}, nil)
+	return err
+}
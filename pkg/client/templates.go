@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// SaveTemplate saves the project's tasks as a template named name, or after
+// the project when name is empty, with due dates relative to anchor (now when
+// zero).
+func (c *Client) SaveTemplate(ctx context.Context, project, name string, anchor time.Time) (Template, error) {
+	query := url.Values{"pjt": {project}}
+	if name != "" {
+		query.Set("name", name)
+	}
+	setAnchor(query, anchor)
+	res, err := c.do(ctx, http.MethodPost, "/saveTemplate", query, nil)
+	if err != nil {
+		return Template{}, err
+	}
+	var template Template
+	if err := json.Unmarshal(res.body, &template); err != nil {
+		return Template{}, err
+	}
+	return template, nil
+}
+
+func (c *Client) GetTemplates(ctx context.Context) ([]Template, error) {
+	res, err := c.do(ctx, http.MethodGet, "/printTemplates", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	templates := []Template{}
+	if err := json.Unmarshal(res.body, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// InstantiateTemplate creates project name from the template, nested in
+// parent when that is not empty, with due dates relative to anchor (now when
+// zero). It fails with ErrConflict when the name is taken.
+func (c *Client) InstantiateTemplate(ctx context.Context, template, name, parent string, anchor time.Time) (Project, error) {
+	query := url.Values{"template": {template}, "name": {name}}
+	if parent != "" {
+		query.Set("parent", parent)
+	}
+	setAnchor(query, anchor)
+	return c.copyProject(ctx, "/instantiateTemplate", query)
+}
+
+// CloneProject copies the project with all of its tasks into project name.
+func (c *Client) CloneProject(ctx context.Context, project, name string) (Project, error) {
+	return c.copyProject(ctx, "/cloneProject", url.Values{"pjt": {project}, "name": {name}})
+}
+
+func (c *Client) copyProject(ctx context.Context, path string, query url.Values) (Project, error) {
+	res, err := c.do(ctx, http.MethodPost, path, query, nil)
+	if err != nil {
+		return Project{}, err
+	}
+	var project Project
+	if err := json.Unmarshal(res.body, &project); err != nil {
+		return Project{}, err
+	}
+	return project, nil
+}
+
+func (c *Client) RemoveTemplate(ctx context.Context, template string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/removeTemplate", url.Values{"template": {template}}, nil)
+	return err
+}
+
+func setAnchor(query url.Values, anchor time.Time) {
+	if !anchor.IsZero() {
+		query.Set("anchor", anchor.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// RegisterUser creates an account. It does not change the client's credentials.
+func (c *Client) RegisterUser(ctx context.Context, username, password string) error {
+	body := map[string]string{"username": username, "password": password}
+	_, err := c.do(ctx, http.MethodPost, "/register", nil, body)
+	return err
+}
+
+// AuthenticateUser reports whether the client's credentials are accepted.
+func (c *Client) AuthenticateUser(ctx context.Context) (bool, error) {
+	_, err := c.do(ctx, http.MethodGet, "/welcome", nil, nil)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, ErrUnauthorized) {
+		return false, nil
+	}
+	return false, err
+}
+
+// Login exchanges the client's Basic credentials for a bearer token, to be
+// used with WithToken until it expires.
+func (c *Client) Login(ctx context.Context) (string, time.Time, error) {
+	res, err := c.do(ctx, http.MethodPost, "/login", nil, nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	var body struct {
+		Token   string    `json:"token"`
+		Expires time.Time `json:"expires"`
+	}
+	if err := json.Unmarshal(res.body, &body); err != nil {
+		return "", time.Time{}, err
+	}
+	return body.Token, body.Expires, nil
+}
+
+// Logout revokes the client's bearer token.
+func (c *Client) Logout(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodPost, "/logout", nil, nil)
+	return err
+}
+
+// DeactivateUser deactivates the client's account and returns the time after
+// which it will be purged unless reactivated.
+func (c *Client) DeactivateUser(ctx context.Context) (time.Time, error) {
+	res, err := c.do(ctx, http.MethodDelete, "/deactivate", nil, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var body struct {
+		PurgeAfter time.Time `json:"purgeAfter"`
+	}
+	if err := json.Unmarshal(res.body, &body); err != nil {
+		return time.Time{}, err
+	}
+	return body.PurgeAfter, nil
+}
+
+// ReactivateUser restores the client's deactivated account.
+func (c *Client) ReactivateUser(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodPost, "/reactivate", nil, nil)
+	return err
+}
+
+// GetSettings returns the client's account settings.
+func (c *Client) GetSettings(ctx context.Context) (UserSettings, error) {
+	return c.settings(ctx, http.MethodGet, "/settings", nil)
+}
+
+// UpdateSettings changes the settings set in update and returns the settings
+// now in effect.
+func (c *Client) UpdateSettings(ctx context.Context, update SettingsUpdate) (UserSettings, error) {
+	return c.settings(ctx, http.MethodPost, "/updateSettings", update)
+}
+
+func (c *Client) settings(ctx context.Context, method, path string, body any) (UserSettings, error) {
+	res, err := c.do(ctx, method, path, nil, body)
+	if err != nil {
+		return UserSettings{}, err
+	}
+	var settings UserSettings
+	if err := json.Unmarshal(res.body, &settings); err != nil {
+		return UserSettings{}, err
+	}
+	return settings, nil
+}